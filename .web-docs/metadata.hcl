@@ -23,6 +23,31 @@ integration {
     name = "Amazon AMI"
     slug = "ami"
   }
+  component {
+    type = "data-source"
+    name = "Amazon AMIs"
+    slug = "amis"
+  }
+  component {
+    type = "data-source"
+    name = "Caller Identity"
+    slug = "caller-identity"
+  }
+  component {
+    type = "data-source"
+    name = "Assume Role"
+    slug = "assume-role"
+  }
+  component {
+    type = "data-source"
+    name = "VPC Network"
+    slug = "vpc-network"
+  }
+  component {
+    type = "data-source"
+    name = "Instance Types"
+    slug = "instance-types"
+  }
   component {
     type = "builder"
     name = "Amazon chroot"
@@ -53,4 +78,54 @@ integration {
     name = "Amazon Import"
     slug = "import"
   }
+  component {
+    type = "post-processor"
+    name = "Amazon Export"
+    slug = "export"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon EBS Direct"
+    slug = "ebs-direct"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon AMI Share"
+    slug = "ami-share"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon AMI Copy"
+    slug = "ami-copy"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon SSM Parameter"
+    slug = "ssm-parameter"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon Launch Template"
+    slug = "launch-template"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon AMI Retention"
+    slug = "ami-retention"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon AMI Smoke Test"
+    slug = "ami-smoke-test"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon AMI Store"
+    slug = "ami-store"
+  }
+  component {
+    type = "post-processor"
+    name = "Amazon Notify"
+    slug = "notify"
+  }
 }
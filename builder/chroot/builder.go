@@ -159,12 +159,28 @@ type Config struct {
 	//-   `most_recent` (boolean) - Selects the newest created image when true.
 	//	This is most useful for selecting a daily distro build.
 	//
+	//-   `version_regex`, `version_tag`, `version_constraint`,
+	//	`exclude_name_regex` and `exclude_tags` - Select the image with the
+	//	highest version rather than the newest one, and ignore images by name
+	//	or tag, like the
+	//	[amazon-ami](/packer/integrations/hashicorp/amazon/latest/components/data-source/ami)
+	//	data source.
+	//
 	//You may set this in place of `source_ami` or in conjunction with it. If you
 	//set this in conjunction with `source_ami`, the `source_ami` will be added
 	//to the filter. The provided `source_ami` must meet all of the filtering
 	//criteria provided in `source_ami_filter`; this pins the AMI returned by the
 	//filter, but will cause Packer to fail if the `source_ami` does not exist.
 	SourceAmiFilter awscommon.AmiFilterOptions `mapstructure:"source_ami_filter" required:"false"`
+	// The name or ARN of an SSM parameter holding the ID of the source AMI,
+	// such as the public
+	// `/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64`.
+	// The parameter is resolved in the build region when the build starts,
+	// and may select a version or a label, like `/golden/base:3` or
+	// `/golden/base:prod`. You may set this in place of `source_ami`; if you
+	// also set `source_ami_filter`, the AMI of the parameter must meet all of
+	// its criteria. Note: this is not used when from_scratch is set to true.
+	SourceAmiSSMParameter string `mapstructure:"source_ami_ssm_parameter" required:"false"`
 	// Key/value pair tags to apply to the volumes that are *launched*. This is
 	// a [template engine](/packer/docs/templates/legacy_json_templates/engine), see [Build template
 	// data](#build-template-data) for more information.
@@ -314,8 +330,8 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	}
 
 	if b.config.FromScratch {
-		if b.config.SourceAmi != "" || !b.config.SourceAmiFilter.Empty() {
-			warns = append(warns, "source_ami and source_ami_filter are unused when from_scratch is true")
+		if b.config.SourceAmi != "" || b.config.SourceAmiSSMParameter != "" || !b.config.SourceAmiFilter.Empty() {
+			warns = append(warns, "source_ami, source_ami_filter and source_ami_ssm_parameter are unused when from_scratch is true")
 		}
 		if b.config.RootVolumeSize == 0 {
 			errs = packersdk.MultiErrorAppend(
@@ -338,9 +354,16 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 				errs, errors.New("ami_block_device_mappings is required with from_scratch."))
 		}
 	} else {
-		if b.config.SourceAmi == "" && b.config.SourceAmiFilter.Empty() {
+		if b.config.SourceAmi != "" && b.config.SourceAmiSSMParameter != "" {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("only one of source_ami and source_ami_ssm_parameter can be specified."))
+		}
+		if b.config.SourceAmi == "" && b.config.SourceAmiSSMParameter == "" && b.config.SourceAmiFilter.Empty() {
 			errs = packersdk.MultiErrorAppend(
-				errs, errors.New("source_ami or source_ami_filter is required."))
+				errs, errors.New("source_ami, source_ami_filter or source_ami_ssm_parameter is required."))
+		}
+		if b.config.SourceAmi == "" {
+			errs = packersdk.MultiErrorAppend(errs, b.config.SourceAmiFilter.Prepare()...)
 		}
 		if len(b.config.AMIMappings) > 0 && b.config.RootDeviceName != "" {
 			if b.config.RootVolumeSize == 0 {
@@ -453,6 +476,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		steps = append(steps,
 			&awscommon.StepSourceAMIInfo{
 				SourceAmi:                b.config.SourceAmi,
+				SourceAmiSSMParameter:    b.config.SourceAmiSSMParameter,
 				EnableAMISriovNetSupport: b.config.AMISriovNetSupport,
 				EnableAMIENASupport:      b.config.AMIENASupport,
 				AmiFilters:               b.config.SourceAmiFilter,
@@ -81,6 +81,7 @@ type FlatConfig struct {
 	RootVolumeType                 *string                                     `mapstructure:"root_volume_type" required:"false" cty:"root_volume_type" hcl:"root_volume_type"`
 	SourceAmi                      *string                                     `mapstructure:"source_ami" required:"true" cty:"source_ami" hcl:"source_ami"`
 	SourceAmiFilter                *common.FlatAmiFilterOptions                `mapstructure:"source_ami_filter" required:"false" cty:"source_ami_filter" hcl:"source_ami_filter"`
+	SourceAmiSSMParameter          *string                                     `mapstructure:"source_ami_ssm_parameter" required:"false" cty:"source_ami_ssm_parameter" hcl:"source_ami_ssm_parameter"`
 	RootVolumeTags                 map[string]string                           `mapstructure:"root_volume_tags" required:"false" cty:"root_volume_tags" hcl:"root_volume_tags"`
 	RootVolumeTag                  []config.FlatKeyValue                       `mapstructure:"root_volume_tag" required:"false" cty:"root_volume_tag" hcl:"root_volume_tag"`
 	RootVolumeEncryptBoot          *bool                                       `mapstructure:"root_volume_encrypt_boot" required:"false" cty:"root_volume_encrypt_boot" hcl:"root_volume_encrypt_boot"`
@@ -172,6 +173,7 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"root_volume_type":               &hcldec.AttrSpec{Name: "root_volume_type", Type: cty.String, Required: false},
 		"source_ami":                     &hcldec.AttrSpec{Name: "source_ami", Type: cty.String, Required: false},
 		"source_ami_filter":              &hcldec.BlockSpec{TypeName: "source_ami_filter", Nested: hcldec.ObjectSpec((*common.FlatAmiFilterOptions)(nil).HCL2Spec())},
+		"source_ami_ssm_parameter":       &hcldec.AttrSpec{Name: "source_ami_ssm_parameter", Type: cty.String, Required: false},
 		"root_volume_tags":               &hcldec.AttrSpec{Name: "root_volume_tags", Type: cty.Map(cty.String), Required: false},
 		"root_volume_tag":                &hcldec.BlockListSpec{TypeName: "root_volume_tag", Nested: hcldec.ObjectSpec((*config.FlatKeyValue)(nil).HCL2Spec())},
 		"root_volume_encrypt_boot":       &hcldec.AttrSpec{Name: "root_volume_encrypt_boot", Type: cty.Bool, Required: false},
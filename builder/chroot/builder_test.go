@@ -117,6 +117,27 @@ func TestBuilderPrepare_SourceAmi(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_SourceAmiSSMParameter(t *testing.T) {
+	b := &Builder{}
+	config := testConfig()
+
+	config["source_ami"] = ""
+	config["source_ami_ssm_parameter"] = "/golden/base"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Errorf("err: %s", err)
+	}
+
+	config["source_ami"] = "foo"
+	_, _, err = (&Builder{}).Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
 func TestBuilderPrepare_CommandWrapper(t *testing.T) {
 	b := &Builder{}
 	config := testConfig()
@@ -302,10 +323,16 @@ func TestBuilderPrepare_ReturnGeneratedData(t *testing.T) {
 	if generatedData[5] != "SourceAMIOwnerName" {
 		t.Fatalf("Generated data should contain SourceAMIOwnerName")
 	}
-	if generatedData[6] != "Device" {
+	if generatedData[6] != "SourceAMISSMParameter" {
+		t.Fatalf("Generated data should contain SourceAMISSMParameter")
+	}
+	if generatedData[7] != "SourceAMISSMParameterVersion" {
+		t.Fatalf("Generated data should contain SourceAMISSMParameterVersion")
+	}
+	if generatedData[8] != "Device" {
 		t.Fatalf("Generated data should contain Device")
 	}
-	if generatedData[7] != "MountPath" {
+	if generatedData[9] != "MountPath" {
 		t.Fatalf("Generated data should contain MountPath")
 	}
 }
@@ -7,8 +7,11 @@ package common
 import (
 	"fmt"
 	"log"
+	"regexp"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/hashicorp/go-version"
 )
 
 type AmiFilterOptions struct {
@@ -29,6 +32,32 @@ type AmiFilterOptions struct {
 	// If you are the AMI owner, deprecated AMIs appear in the response
 	// regardless of what is specified for `include_deprecated`.
 	IncludeDeprecated bool `mapstructure:"include_deprecated"`
+	// A regular expression matched against the names of the images, whose
+	// first capture group is the version of the image, such as
+	// `-server-(\\d{8}(?:\\.\\d+)?)$`. The image with the highest version is
+	// selected, even if it was created before others, which `most_recent`
+	// would pick. Images whose name does not match are ignored. Versions are
+	// compared segment by segment, so `22.04.10` is higher than `22.04.9`.
+	// Images with the same version are ordered by creation date. Cannot be
+	// set with `version_tag`.
+	VersionRegex string `mapstructure:"version_regex"`
+	// The key of a tag holding the version of the images, such as
+	// `Version`. Selects the image with the highest version like
+	// `version_regex`. Images without the tag, or with a value that is not a
+	// version, are ignored.
+	VersionTag string `mapstructure:"version_tag"`
+	// A constraint the version of the selected image must satisfy, such as
+	// `~> 22.04.3` or `>= 1.2, < 2.0`, in the syntax of
+	// [version constraints](/packer/docs/templates/hcl_templates/blocks/packer#version-constraint-syntax).
+	// Requires `version_regex` or `version_tag`.
+	VersionConstraint string `mapstructure:"version_constraint"`
+	// A regular expression matched against the names of the images. The
+	// matching images are ignored, such as `-rc\\d*$` for release candidates.
+	ExcludeNameRegex string `mapstructure:"exclude_name_regex"`
+	// Tag keys and regular expressions matched against their values. The
+	// images with a matching tag are ignored, such as `{ Status =
+	// "^(broken|testing)$" }`.
+	ExcludeTags map[string]string `mapstructure:"exclude_tags"`
 }
 
 func (d *AmiFilterOptions) GetOwners() []*string {
@@ -48,6 +77,149 @@ func (d *AmiFilterOptions) NoOwner() bool {
 	return len(d.Owners) == 0
 }
 
+// Prepare validates the version selection and the exclusions.
+func (d *AmiFilterOptions) Prepare() []error {
+	var errs []error
+
+	if d.VersionRegex != "" && d.VersionTag != "" {
+		errs = append(errs, fmt.Errorf("only one of version_regex and version_tag can be set"))
+	}
+	if d.VersionRegex != "" {
+		re, err := regexp.Compile(d.VersionRegex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid version_regex: %s", err))
+		} else if re.NumSubexp() == 0 {
+			errs = append(errs, fmt.Errorf("version_regex must have a capture group for the version"))
+		}
+	}
+	if d.VersionConstraint != "" {
+		if d.VersionRegex == "" && d.VersionTag == "" {
+			errs = append(errs, fmt.Errorf("version_constraint requires version_regex or version_tag"))
+		}
+		if _, err := version.NewConstraint(d.VersionConstraint); err != nil {
+			errs = append(errs, fmt.Errorf("invalid version_constraint: %s", err))
+		}
+	}
+	if d.ExcludeNameRegex != "" {
+		if _, err := regexp.Compile(d.ExcludeNameRegex); err != nil {
+			errs = append(errs, fmt.Errorf("invalid exclude_name_regex: %s", err))
+		}
+	}
+	for key, pattern := range d.ExcludeTags {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("invalid exclude_tags pattern for %s: %s", key, err))
+		}
+	}
+
+	return errs
+}
+
+// versioned returns whether the image is selected by version rather than
+// by creation date.
+func (d *AmiFilterOptions) versioned() bool {
+	return d.VersionRegex != "" || d.VersionTag != ""
+}
+
+// exclude returns the images that neither exclude_name_regex nor
+// exclude_tags match.
+func (d *AmiFilterOptions) exclude(images []*ec2.Image) ([]*ec2.Image, error) {
+	if d.ExcludeNameRegex == "" && len(d.ExcludeTags) == 0 {
+		return images, nil
+	}
+
+	var nameRe *regexp.Regexp
+	if d.ExcludeNameRegex != "" {
+		re, err := regexp.Compile(d.ExcludeNameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_name_regex: %s", err)
+		}
+		nameRe = re
+	}
+	tagRes := make(map[string]*regexp.Regexp, len(d.ExcludeTags))
+	for key, pattern := range d.ExcludeTags {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_tags pattern for %s: %s", key, err)
+		}
+		tagRes[key] = re
+	}
+
+	kept := make([]*ec2.Image, 0, len(images))
+	for _, image := range images {
+		excluded := nameRe != nil && nameRe.MatchString(aws.StringValue(image.Name))
+		for _, tag := range image.Tags {
+			if re, ok := tagRes[aws.StringValue(tag.Key)]; ok && re.MatchString(aws.StringValue(tag.Value)) {
+				excluded = true
+			}
+		}
+		if excluded {
+			log.Printf("Excluding AMI %s (%s)", aws.StringValue(image.ImageId), aws.StringValue(image.Name))
+			continue
+		}
+		kept = append(kept, image)
+	}
+	return kept, nil
+}
+
+// highestVersion returns the image with the highest version satisfying
+// version_constraint, the most recent one among those with that version.
+func (d *AmiFilterOptions) highestVersion(images []*ec2.Image) (*ec2.Image, error) {
+	var versionRe *regexp.Regexp
+	if d.VersionRegex != "" {
+		re, err := regexp.Compile(d.VersionRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version_regex: %s", err)
+		}
+		versionRe = re
+	}
+	var constraint version.Constraints
+	if d.VersionConstraint != "" {
+		c, err := version.NewConstraint(d.VersionConstraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version_constraint: %s", err)
+		}
+		constraint = c
+	}
+
+	var best *ec2.Image
+	var bestVersion *version.Version
+	for _, image := range images {
+		raw := ""
+		if versionRe != nil {
+			if m := versionRe.FindStringSubmatch(aws.StringValue(image.Name)); m != nil {
+				raw = m[1]
+			}
+		} else {
+			for _, tag := range image.Tags {
+				if aws.StringValue(tag.Key) == d.VersionTag {
+					raw = aws.StringValue(tag.Value)
+				}
+			}
+		}
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			log.Printf("Ignoring AMI %s (%s), without a version", aws.StringValue(image.ImageId), aws.StringValue(image.Name))
+			continue
+		}
+		if constraint != nil && !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(bestVersion) ||
+			(v.Equal(bestVersion) && imageSort([]*ec2.Image{best, image}).Less(0, 1)) {
+			best, bestVersion = image, v
+		}
+	}
+
+	if best == nil {
+		if d.VersionConstraint != "" {
+			return nil, fmt.Errorf("No AMI matching filters has a version satisfying %q", d.VersionConstraint)
+		}
+		return nil, fmt.Errorf("No AMI matching filters has a version")
+	}
+	log.Printf("Selected AMI %s (%s), of version %s", aws.StringValue(best.ImageId), aws.StringValue(best.Name), bestVersion)
+	return best, nil
+}
+
 func (d *AmiFilterOptions) GetFilteredImage(params *ec2.DescribeImagesInput, ec2conn *ec2.EC2) (*ec2.Image, error) {
 	// We have filters to apply
 	if len(d.Filters) > 0 {
@@ -74,21 +246,30 @@ func (d *AmiFilterOptions) GetFilteredImage(params *ec2.DescribeImagesInput, ec2
 		return nil, err
 	}
 
-	if len(imageResp.Images) == 0 {
+	images, err := d.exclude(imageResp.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images) == 0 {
 		err := fmt.Errorf("No AMI was found matching filters: %v", params)
 		return nil, err
 	}
 
-	if len(imageResp.Images) > 1 && !d.MostRecent {
+	if d.versioned() {
+		return d.highestVersion(images)
+	}
+
+	if len(images) > 1 && !d.MostRecent {
 		err := fmt.Errorf("Your query returned more than one result. Please try a more specific search, or set most_recent to true.")
 		return nil, err
 	}
 
 	var image *ec2.Image
 	if d.MostRecent {
-		image = mostRecentAmi(imageResp.Images)
+		image = mostRecentAmi(images)
 	} else {
-		image = imageResp.Images[0]
+		image = images[0]
 	}
 	return image, nil
 }
@@ -0,0 +1,68 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func testVersionedImages() []*ec2.Image {
+	image := func(id, name, created, status string) *ec2.Image {
+		image := &ec2.Image{
+			ImageId:      aws.String(id),
+			Name:         aws.String(name),
+			CreationDate: aws.String(created),
+		}
+		if status != "" {
+			image.Tags = []*ec2.Tag{{Key: aws.String("Status"), Value: aws.String(status)}}
+		}
+		return image
+	}
+	return []*ec2.Image{
+		image("ami-1", "base-22.04.9", "2025-01-01T00:00:00.000Z", ""),
+		image("ami-2", "base-22.04.10", "2025-01-02T00:00:00.000Z", ""),
+		image("ami-3", "base-22.04.11", "2025-01-03T00:00:00.000Z", "broken"),
+		image("ami-4", "base-20.04.6", "2025-01-04T00:00:00.000Z", ""),
+	}
+}
+
+func TestAmiFilterOptions_Prepare(t *testing.T) {
+	valid := AmiFilterOptions{VersionRegex: `-(\d+\.\d+\.\d+)$`, VersionConstraint: "~> 22.04.3"}
+	if errs := valid.Prepare(); len(errs) > 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	invalid := AmiFilterOptions{VersionRegex: `\d+`, VersionTag: "Version", ExcludeNameRegex: "("}
+	if errs := invalid.Prepare(); len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+}
+
+func TestAmiFilterOptions_highestVersion(t *testing.T) {
+	tests := map[string]struct {
+		options AmiFilterOptions
+		want    string
+	}{
+		"highest":    {AmiFilterOptions{VersionRegex: `-(\d+\.\d+\.\d+)$`}, "ami-3"},
+		"excluded":   {AmiFilterOptions{VersionRegex: `-(\d+\.\d+\.\d+)$`, ExcludeTags: map[string]string{"Status": "broken"}}, "ami-2"},
+		"constraint": {AmiFilterOptions{VersionRegex: `-(\d+\.\d+\.\d+)$`, VersionConstraint: "< 22.0"}, "ami-4"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			images, err := tt.options.exclude(testVersionedImages())
+			if err != nil {
+				t.Fatalf("exclude() failed: %s", err)
+			}
+			image, err := tt.options.highestVersion(images)
+			if err != nil {
+				t.Fatalf("highestVersion() failed: %s", err)
+			}
+			if aws.StringValue(image.ImageId) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, aws.StringValue(image.ImageId))
+			}
+		})
+	}
+}
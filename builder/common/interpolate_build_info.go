@@ -18,6 +18,9 @@ type BuildInfoTemplate struct {
 	SourceAMIOwner        string
 	SourceAMIOwnerName    string
 	SourceAMITags         map[string]string
+
+	SourceAMISSMParameter        string
+	SourceAMISSMParameterVersion string
 }
 
 func extractBuildInfo(region string, state multistep.StateBag, generatedData *packerbuilderdata.GeneratedData) *BuildInfoTemplate {
@@ -43,6 +46,10 @@ func extractBuildInfo(region string, state multistep.StateBag, generatedData *pa
 		SourceAMIOwnerName:    aws.StringValue(sourceAMI.ImageOwnerAlias),
 		SourceAMITags:         sourceAMITags,
 	}
+	if parameter, ok := state.GetOk("source_ami_ssm_parameter"); ok {
+		buildInfoTemplate.SourceAMISSMParameter = parameter.(string)
+		buildInfoTemplate.SourceAMISSMParameterVersion = state.Get("source_ami_ssm_parameter_version").(string)
+	}
 
 	generatedData.Put("BuildRegion", buildInfoTemplate.BuildRegion)
 	generatedData.Put("SourceAMI", buildInfoTemplate.SourceAMI)
@@ -50,6 +57,8 @@ func extractBuildInfo(region string, state multistep.StateBag, generatedData *pa
 	generatedData.Put("SourceAMIName", buildInfoTemplate.SourceAMIName)
 	generatedData.Put("SourceAMIOwner", buildInfoTemplate.SourceAMIOwner)
 	generatedData.Put("SourceAMIOwnerName", buildInfoTemplate.SourceAMIOwnerName)
+	generatedData.Put("SourceAMISSMParameter", buildInfoTemplate.SourceAMISSMParameter)
+	generatedData.Put("SourceAMISSMParameterVersion", buildInfoTemplate.SourceAMISSMParameterVersion)
 
 	return buildInfoTemplate
 }
@@ -62,5 +71,7 @@ func GetGeneratedDataList() []string {
 		"SourceAMICreationDate",
 		"SourceAMIOwner",
 		"SourceAMIOwnerName",
+		"SourceAMISSMParameter",
+		"SourceAMISSMParameterVersion",
 	}
 }
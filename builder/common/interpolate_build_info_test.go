@@ -92,3 +92,20 @@ func TestInterpolateBuildInfo_extractBuildInfo_GeneratedDataWithSourceImageName(
 		t.Fatalf("Unexpected state SourceAMIName: expected %#v got %#v\n", "ami_test_name", generatedDataState["SourceAMIName"])
 	}
 }
+
+func TestInterpolateBuildInfo_extractBuildInfo_GeneratedDataWithSSMParameter(t *testing.T) {
+	state := testState()
+	state.Put("source_image", testImage())
+	state.Put("source_ami_ssm_parameter", "/golden/base")
+	state.Put("source_ami_ssm_parameter_version", "3")
+	generatedData := testGeneratedData(state)
+	buildInfo := extractBuildInfo("foo", state, &generatedData)
+
+	if buildInfo.SourceAMISSMParameter != "/golden/base" || buildInfo.SourceAMISSMParameterVersion != "3" {
+		t.Fatalf("Unexpected BuildInfoTemplate: %#v\n", *buildInfo)
+	}
+	generatedDataState := state.Get("generated_data").(map[string]interface{})
+	if generatedDataState["SourceAMISSMParameterVersion"] != "3" {
+		t.Fatalf("Unexpected state SourceAMISSMParameterVersion: expected %#v got %#v\n", "3", generatedDataState["SourceAMISSMParameterVersion"])
+	}
+}
@@ -397,12 +397,30 @@ type RunConfig struct {
 	//   -   `most_recent` (boolean) - Selects the newest created image when true.
 	//       This is most useful for selecting a daily distro build.
 	//
+	//   -   `version_regex`, `version_tag`, `version_constraint`,
+	//       `exclude_name_regex` and `exclude_tags` - Select the image with the
+	//       highest version rather than the newest one, and ignore images by
+	//       name or tag, like the
+	//       [amazon-ami](/packer/integrations/hashicorp/amazon/latest/components/data-source/ami)
+	//       data source.
+	//
 	//   You may set this in place of `source_ami` or in conjunction with it. If you
 	//   set this in conjunction with `source_ami`, the `source_ami` will be added
 	//   to the filter. The provided `source_ami` must meet all of the filtering
 	//   criteria provided in `source_ami_filter`; this pins the AMI returned by the
 	//   filter, but will cause Packer to fail if the `source_ami` does not exist.
 	SourceAmiFilter AmiFilterOptions `mapstructure:"source_ami_filter" required:"false"`
+	// The name or ARN of an SSM parameter holding the ID of the source AMI,
+	// such as the public
+	// `/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64`.
+	// The parameter is resolved in the build region when the build starts,
+	// and may select a version or a label, like `/golden/base:3` or
+	// `/golden/base:prod`. You may set this in place of `source_ami`; if you
+	// also set `source_ami_filter`, the AMI of the parameter must meet all of
+	// its criteria. The parameter name and version are available as the
+	// `SourceAMISSMParameter` and `SourceAMISSMParameterVersion` build
+	// variables.
+	SourceAmiSSMParameter string `mapstructure:"source_ami_ssm_parameter" required:"false"`
 	// One of  `price-capacity-optimized`, `capacity-optimized`, `diversified` or `lowest-price`.
 	// The strategy that determines how to allocate the target Spot Instance capacity
 	// across the Spot Instance pools specified by the EC2 Fleet launch configuration.
@@ -811,14 +829,22 @@ func (c *RunConfig) Prepare(ctx *interpolate.Context) []error {
 		}
 	}
 
-	if c.SourceAmi == "" && c.SourceAmiFilter.Empty() {
-		errs = append(errs, fmt.Errorf("A source_ami or source_ami_filter must be specified"))
+	if c.SourceAmi != "" && c.SourceAmiSSMParameter != "" {
+		errs = append(errs, fmt.Errorf("Only one of source_ami and source_ami_ssm_parameter can be specified"))
+	}
+
+	if c.SourceAmi == "" && c.SourceAmiSSMParameter == "" && c.SourceAmiFilter.Empty() {
+		errs = append(errs, fmt.Errorf("A source_ami, source_ami_filter or source_ami_ssm_parameter must be specified"))
 	}
 
-	if c.SourceAmi == "" && c.SourceAmiFilter.NoOwner() {
+	if c.SourceAmi == "" && c.SourceAmiSSMParameter == "" && c.SourceAmiFilter.NoOwner() {
 		errs = append(errs, fmt.Errorf("For security reasons, your source AMI filter must declare an owner."))
 	}
 
+	if c.SourceAmi == "" {
+		errs = append(errs, c.SourceAmiFilter.Prepare()...)
+	}
+
 	if c.InstanceType == "" && len(c.SpotInstanceTypes) == 0 {
 		errs = append(errs, fmt.Errorf("either instance_type or "+
 			"spot_instance_types must be specified"))
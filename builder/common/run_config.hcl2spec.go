@@ -15,6 +15,11 @@ type FlatAmiFilterOptions struct {
 	Owners            []string          `mapstructure:"owners" cty:"owners" hcl:"owners"`
 	MostRecent        *bool             `mapstructure:"most_recent" cty:"most_recent" hcl:"most_recent"`
 	IncludeDeprecated *bool             `mapstructure:"include_deprecated" cty:"include_deprecated" hcl:"include_deprecated"`
+	VersionRegex      *string           `mapstructure:"version_regex" cty:"version_regex" hcl:"version_regex"`
+	VersionTag        *string           `mapstructure:"version_tag" cty:"version_tag" hcl:"version_tag"`
+	VersionConstraint *string           `mapstructure:"version_constraint" cty:"version_constraint" hcl:"version_constraint"`
+	ExcludeNameRegex  *string           `mapstructure:"exclude_name_regex" cty:"exclude_name_regex" hcl:"exclude_name_regex"`
+	ExcludeTags       map[string]string `mapstructure:"exclude_tags" cty:"exclude_tags" hcl:"exclude_tags"`
 }
 
 // FlatMapstructure returns a new FlatAmiFilterOptions.
@@ -33,6 +38,11 @@ func (*FlatAmiFilterOptions) HCL2Spec() map[string]hcldec.Spec {
 		"owners":             &hcldec.AttrSpec{Name: "owners", Type: cty.List(cty.String), Required: false},
 		"most_recent":        &hcldec.AttrSpec{Name: "most_recent", Type: cty.Bool, Required: false},
 		"include_deprecated": &hcldec.AttrSpec{Name: "include_deprecated", Type: cty.Bool, Required: false},
+		"version_regex":      &hcldec.AttrSpec{Name: "version_regex", Type: cty.String, Required: false},
+		"version_tag":        &hcldec.AttrSpec{Name: "version_tag", Type: cty.String, Required: false},
+		"version_constraint": &hcldec.AttrSpec{Name: "version_constraint", Type: cty.String, Required: false},
+		"exclude_name_regex": &hcldec.AttrSpec{Name: "exclude_name_regex", Type: cty.String, Required: false},
+		"exclude_tags":       &hcldec.AttrSpec{Name: "exclude_tags", Type: cty.Map(cty.String), Required: false},
 	}
 	return s
 }
@@ -97,6 +97,24 @@ func TestRunConfigPrepare_SourceAmiFilterGood(t *testing.T) {
 	}
 }
 
+func TestRunConfigPrepare_SourceAmiSSMParameter(t *testing.T) {
+	c := testConfigFilter()
+	c.SourceAmiSSMParameter = "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64"
+	if err := c.Prepare(nil); len(err) != 0 {
+		t.Fatalf("Should not error if source_ami_ssm_parameter is specified: %v", err)
+	}
+
+	c.SourceAmiFilter = AmiFilterOptions{Filters: map[string]string{"architecture": "x86_64"}}
+	if err := c.Prepare(nil); len(err) != 0 {
+		t.Fatalf("Should not require an owner to check the AMI of a parameter: %v", err)
+	}
+
+	c.SourceAmi = "abcd"
+	if err := c.Prepare(nil); len(err) != 1 {
+		t.Fatalf("Should error if both source_ami and source_ami_ssm_parameter are specified")
+	}
+}
+
 func TestRunConfigPrepare_EnableT2UnlimitedGood(t *testing.T) {
 	c := testConfig()
 	// Must have a T2 instance type if T2 Unlimited is enabled
@@ -7,9 +7,15 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
@@ -21,8 +27,11 @@ import (
 // Produces:
 //
 //	source_image *ec2.Image - the source AMI info
+//	source_ami_ssm_parameter string - the SSM parameter the source AMI was read from
+//	source_ami_ssm_parameter_version string - the version of that parameter
 type StepSourceAMIInfo struct {
 	SourceAmi                string
+	SourceAmiSSMParameter    string
 	EnableAMISriovNetSupport bool
 	EnableAMIENASupport      config.Trilean
 	AMIVirtType              string
@@ -59,7 +68,22 @@ func (s *StepSourceAMIInfo) Run(ctx context.Context, state multistep.StateBag) m
 		params.ImageIds = []*string{&s.SourceAmi}
 	}
 
+	if s.SourceAmiSSMParameter != "" {
+		session := state.Get("awsSession").(*session.Session)
+		ui.Message(fmt.Sprintf("Reading source AMI from SSM parameter %s...", s.SourceAmiSSMParameter))
+		imageId, err := s.resolveSSMParameter(ssm.New(session), state)
+		if err != nil {
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		params.ImageIds = []*string{aws.String(imageId)}
+	}
+
 	image, err := s.AmiFilters.GetFilteredImage(params, ec2conn)
+	if err != nil && s.SourceAmiSSMParameter != "" {
+		err = fmt.Errorf("AMI %s of SSM parameter %s: %s", aws.StringValue(params.ImageIds[0]), s.SourceAmiSSMParameter, err)
+	}
 	if err != nil {
 		state.Put("error", err)
 		ui.Error(err.Error())
@@ -85,6 +109,26 @@ func (s *StepSourceAMIInfo) Run(ctx context.Context, state multistep.StateBag) m
 
 func (s *StepSourceAMIInfo) Cleanup(multistep.StateBag) {}
 
+// resolveSSMParameter returns the AMI ID held by source_ami_ssm_parameter
+// and records the parameter name and version.
+func (s *StepSourceAMIInfo) resolveSSMParameter(client ssmiface.SSMAPI, state multistep.StateBag) (string, error) {
+	resp, err := client.GetParameter(&ssm.GetParameterInput{
+		Name: aws.String(s.SourceAmiSSMParameter),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error reading SSM parameter %s: %s", s.SourceAmiSSMParameter, err)
+	}
+
+	imageId := aws.StringValue(resp.Parameter.Value)
+	if !strings.HasPrefix(imageId, "ami-") {
+		return "", fmt.Errorf("SSM parameter %s does not hold an AMI ID: %q", s.SourceAmiSSMParameter, imageId)
+	}
+
+	state.Put("source_ami_ssm_parameter", aws.StringValue(resp.Parameter.Name))
+	state.Put("source_ami_ssm_parameter_version", strconv.FormatInt(aws.Int64Value(resp.Parameter.Version), 10))
+	return imageId, nil
+}
+
 func (s *StepSourceAMIInfo) canEnableEnhancedNetworking(image *ec2.Image) error {
 	if s.AMIVirtType == "hvm" {
 		return nil
@@ -93,7 +137,7 @@ func (s *StepSourceAMIInfo) canEnableEnhancedNetworking(image *ec2.Image) error
 		return fmt.Errorf("Cannot enable enhanced networking, AMIVirtType '%s' is not HVM", s.AMIVirtType)
 	}
 	if *image.VirtualizationType != "hvm" {
-		return fmt.Errorf("Cannot enable enhanced networking, source AMI '%s' is not HVM", s.SourceAmi)
+		return fmt.Errorf("Cannot enable enhanced networking, source AMI '%s' is not HVM", aws.StringValue(image.ImageId))
 	}
 	return nil
 }
@@ -4,10 +4,13 @@
 package common
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,3 +47,38 @@ func TestStepSourceAmiInfo_PVImageWithAMIVirtHVM(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+type mockSSMParameter struct {
+	ssmiface.SSMAPI
+
+	value string
+	err   error
+}
+
+func (m *mockSSMParameter) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{
+		Name:    input.Name,
+		Value:   aws.String(m.value),
+		Version: aws.Int64(3),
+	}}, nil
+}
+
+func TestStepSourceAmiInfo_resolveSSMParameter(t *testing.T) {
+	step := StepSourceAMIInfo{SourceAmiSSMParameter: "/golden/base"}
+	state := testState()
+
+	imageId, err := step.resolveSSMParameter(&mockSSMParameter{value: "ami-0123456789abcdef0"}, state)
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-0123456789abcdef0", imageId)
+	assert.Equal(t, "/golden/base", state.Get("source_ami_ssm_parameter"))
+	assert.Equal(t, "3", state.Get("source_ami_ssm_parameter_version"))
+
+	_, err = step.resolveSSMParameter(&mockSSMParameter{value: "latest"}, testState())
+	assert.ErrorContains(t, err, "does not hold an AMI ID")
+
+	_, err = step.resolveSSMParameter(&mockSSMParameter{err: errors.New("ParameterNotFound")}, testState())
+	assert.ErrorContains(t, err, "ParameterNotFound")
+}
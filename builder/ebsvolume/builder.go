@@ -284,6 +284,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	steps := []multistep.Step{
 		&awscommon.StepSourceAMIInfo{
 			SourceAmi:                b.config.SourceAmi,
+			SourceAmiSSMParameter:    b.config.SourceAmiSSMParameter,
 			EnableAMISriovNetSupport: b.config.AMISriovNetSupport,
 			EnableAMIENASupport:      b.config.AMIENASupport,
 			AmiFilters:               b.config.SourceAmiFilter,
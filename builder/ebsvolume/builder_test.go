@@ -125,6 +125,12 @@ func TestBuilderPrepare_ReturnGeneratedData(t *testing.T) {
 	if generatedData[5] != "SourceAMIOwnerName" {
 		t.Fatalf("Generated data should contain SourceAMIOwnerName")
 	}
+	if generatedData[6] != "SourceAMISSMParameter" {
+		t.Fatalf("Generated data should contain SourceAMISSMParameter")
+	}
+	if generatedData[7] != "SourceAMISSMParameterVersion" {
+		t.Fatalf("Generated data should contain SourceAMISSMParameterVersion")
+	}
 }
 
 func TestBuidler_ConfigBlockdevicemapping(t *testing.T) {
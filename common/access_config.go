@@ -462,6 +462,17 @@ func (c *AccessConfig) Prepare(packerConfig *common.PackerConfig) []error {
 	return errs
 }
 
+// WithAssumeRole returns a copy of c that assumes role instead of the
+// assume_role of c, for components reaching into several accounts. The copy
+// resolves its own aws.Config.
+func (c *AccessConfig) WithAssumeRole(role AssumeRoleConfig) *AccessConfig {
+	assumed := *c
+	assumed.AssumeRole = role
+	assumed.config = nil
+	assumed.getEC2Client = nil
+	return &assumed
+}
+
 func (c *AccessConfig) NewNoValidCredentialSourcesError(err error) error {
 	return fmt.Errorf("No valid credential sources found for AWS Builder. "+
 		"Please see https://www.packer.io/docs/builders/amazon#authentication "+
@@ -115,3 +115,20 @@ func TestAccessConfig_SkipCredsValidationDoesNotDisableIMDS(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessConfig_WithAssumeRole(t *testing.T) {
+	c := FakeAccessConfig()
+	c.config = mustLoadConfig(config.WithRegion("us-east-1"))
+	c.AssumeRole.AssumeRoleARN = "arn:aws:iam::111111111111:role/build"
+
+	assumed := c.WithAssumeRole(AssumeRoleConfig{AssumeRoleARN: "arn:aws:iam::222222222222:role/prod"})
+	if assumed.AssumeRole.AssumeRoleARN != "arn:aws:iam::222222222222:role/prod" {
+		t.Errorf("expected the new role, got %q", assumed.AssumeRole.AssumeRoleARN)
+	}
+	if assumed.config != nil {
+		t.Errorf("expected the copy not to reuse the resolved aws.Config")
+	}
+	if c.AssumeRole.AssumeRoleARN != "arn:aws:iam::111111111111:role/build" || c.config == nil {
+		t.Errorf("expected the original access config to be left alone")
+	}
+}
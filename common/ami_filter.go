@@ -9,10 +9,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/packer-plugin-amazon/common/clients"
 )
 
@@ -34,6 +37,32 @@ type AmiFilterOptions struct {
 	// If you are the AMI owner, deprecated AMIs appear in the response
 	// regardless of what is specified for `include_deprecated`.
 	IncludeDeprecated bool `mapstructure:"include_deprecated"`
+	// A regular expression matched against the names of the images, whose
+	// first capture group is the version of the image, such as
+	// `-server-(\\d{8}(?:\\.\\d+)?)$`. The image with the highest version is
+	// selected, even if it was created before others, which `most_recent`
+	// would pick. Images whose name does not match are ignored. Versions are
+	// compared segment by segment, so `22.04.10` is higher than `22.04.9`.
+	// Images with the same version are ordered by creation date. Cannot be
+	// set with `version_tag`.
+	VersionRegex string `mapstructure:"version_regex"`
+	// The key of a tag holding the version of the images, such as
+	// `Version`. Selects the image with the highest version like
+	// `version_regex`. Images without the tag, or with a value that is not a
+	// version, are ignored.
+	VersionTag string `mapstructure:"version_tag"`
+	// A constraint the version of the selected image must satisfy, such as
+	// `~> 22.04.3` or `>= 1.2, < 2.0`, in the syntax of
+	// [version constraints](/packer/docs/templates/hcl_templates/blocks/packer#version-constraint-syntax).
+	// Requires `version_regex` or `version_tag`.
+	VersionConstraint string `mapstructure:"version_constraint"`
+	// A regular expression matched against the names of the images. The
+	// matching images are ignored, such as `-rc\\d*$` for release candidates.
+	ExcludeNameRegex string `mapstructure:"exclude_name_regex"`
+	// Tag keys and regular expressions matched against their values. The
+	// images with a matching tag are ignored, such as `{ Status =
+	// "^(broken|testing)$" }`.
+	ExcludeTags map[string]string `mapstructure:"exclude_tags"`
 }
 
 func (d *AmiFilterOptions) GetOwners() []string {
@@ -53,6 +82,150 @@ func (d *AmiFilterOptions) NoOwner() bool {
 	return len(d.Owners) == 0
 }
 
+// Prepare validates the version selection and the exclusions.
+func (d *AmiFilterOptions) Prepare() []error {
+	var errs []error
+
+	if d.VersionRegex != "" && d.VersionTag != "" {
+		errs = append(errs, fmt.Errorf("only one of version_regex and version_tag can be set"))
+	}
+	if d.VersionRegex != "" {
+		re, err := regexp.Compile(d.VersionRegex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid version_regex: %s", err))
+		} else if re.NumSubexp() == 0 {
+			errs = append(errs, fmt.Errorf("version_regex must have a capture group for the version"))
+		}
+	}
+	if d.VersionConstraint != "" {
+		if d.VersionRegex == "" && d.VersionTag == "" {
+			errs = append(errs, fmt.Errorf("version_constraint requires version_regex or version_tag"))
+		}
+		if _, err := version.NewConstraint(d.VersionConstraint); err != nil {
+			errs = append(errs, fmt.Errorf("invalid version_constraint: %s", err))
+		}
+	}
+	if d.ExcludeNameRegex != "" {
+		if _, err := regexp.Compile(d.ExcludeNameRegex); err != nil {
+			errs = append(errs, fmt.Errorf("invalid exclude_name_regex: %s", err))
+		}
+	}
+	for key, pattern := range d.ExcludeTags {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("invalid exclude_tags pattern for %s: %s", key, err))
+		}
+	}
+
+	return errs
+}
+
+// versioned returns whether the image is selected by version rather than
+// by creation date.
+func (d *AmiFilterOptions) versioned() bool {
+	return d.VersionRegex != "" || d.VersionTag != ""
+}
+
+// exclude returns the images that neither exclude_name_regex nor
+// exclude_tags match.
+func (d *AmiFilterOptions) exclude(images []types.Image) ([]types.Image, error) {
+	if d.ExcludeNameRegex == "" && len(d.ExcludeTags) == 0 {
+		return images, nil
+	}
+
+	var nameRe *regexp.Regexp
+	if d.ExcludeNameRegex != "" {
+		re, err := regexp.Compile(d.ExcludeNameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_name_regex: %s", err)
+		}
+		nameRe = re
+	}
+	tagRes := make(map[string]*regexp.Regexp, len(d.ExcludeTags))
+	for key, pattern := range d.ExcludeTags {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_tags pattern for %s: %s", key, err)
+		}
+		tagRes[key] = re
+	}
+
+	kept := make([]types.Image, 0, len(images))
+	for _, image := range images {
+		excluded := nameRe != nil && nameRe.MatchString(aws.ToString(image.Name))
+		for _, tag := range image.Tags {
+			if re, ok := tagRes[aws.ToString(tag.Key)]; ok && re.MatchString(aws.ToString(tag.Value)) {
+				excluded = true
+			}
+		}
+		if excluded {
+			log.Printf("Excluding AMI %s (%s)", aws.ToString(image.ImageId), aws.ToString(image.Name))
+			continue
+		}
+		kept = append(kept, image)
+	}
+	return kept, nil
+}
+
+// highestVersion returns the image with the highest version satisfying
+// version_constraint, the most recent one among those with that version.
+func (d *AmiFilterOptions) highestVersion(images []types.Image) (*types.Image, error) {
+	var versionRe *regexp.Regexp
+	if d.VersionRegex != "" {
+		re, err := regexp.Compile(d.VersionRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version_regex: %s", err)
+		}
+		versionRe = re
+	}
+	var constraint version.Constraints
+	if d.VersionConstraint != "" {
+		c, err := version.NewConstraint(d.VersionConstraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version_constraint: %s", err)
+		}
+		constraint = c
+	}
+
+	var best *types.Image
+	var bestVersion *version.Version
+	for i := range images {
+		image := &images[i]
+		raw := ""
+		if versionRe != nil {
+			if m := versionRe.FindStringSubmatch(aws.ToString(image.Name)); m != nil {
+				raw = m[1]
+			}
+		} else {
+			for _, tag := range image.Tags {
+				if aws.ToString(tag.Key) == d.VersionTag {
+					raw = aws.ToString(tag.Value)
+				}
+			}
+		}
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			log.Printf("Ignoring AMI %s (%s), without a version", aws.ToString(image.ImageId), aws.ToString(image.Name))
+			continue
+		}
+		if constraint != nil && !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(bestVersion) ||
+			(v.Equal(bestVersion) && imageSort([]types.Image{*best, *image}).Less(0, 1)) {
+			best, bestVersion = image, v
+		}
+	}
+
+	if best == nil {
+		if d.VersionConstraint != "" {
+			return nil, fmt.Errorf("No AMI matching filters has a version satisfying %q", d.VersionConstraint)
+		}
+		return nil, fmt.Errorf("No AMI matching filters has a version")
+	}
+	log.Printf("Selected AMI %s (%s), of version %s", aws.ToString(best.ImageId), aws.ToString(best.Name), bestVersion)
+	return best, nil
+}
+
 func prettyFilters(params *ec2.DescribeImagesInput) string {
 	b, err := json.MarshalIndent(params, "", "  ")
 	if err != nil {
@@ -61,13 +234,13 @@ func prettyFilters(params *ec2.DescribeImagesInput) string {
 	return string(b)
 }
 
-func (d *AmiFilterOptions) GetFilteredImage(ctx context.Context, params *ec2.DescribeImagesInput, client clients.Ec2Client) (*types.Image, error) {
+// applyTo sets the filters, the owners and include_deprecated on params.
+func (d *AmiFilterOptions) applyTo(params *ec2.DescribeImagesInput) error {
 	// We have filters to apply
 	if len(d.Filters) > 0 {
 		amiFilters, err := buildEc2Filters(d.Filters)
 		if err != nil {
-			err := fmt.Errorf("Couldn't parse ami filters: %s", err)
-			return nil, err
+			return fmt.Errorf("Couldn't parse ami filters: %s", err)
 		}
 		params.Filters = amiFilters
 	}
@@ -78,6 +251,13 @@ func (d *AmiFilterOptions) GetFilteredImage(ctx context.Context, params *ec2.Des
 	params.IncludeDeprecated = &d.IncludeDeprecated
 
 	log.Printf("Using AMI Filters %s", prettyFilters(params))
+	return nil
+}
+
+func (d *AmiFilterOptions) GetFilteredImage(ctx context.Context, params *ec2.DescribeImagesInput, client clients.Ec2Client) (*types.Image, error) {
+	if err := d.applyTo(params); err != nil {
+		return nil, err
+	}
 	imageResp, err := client.DescribeImages(ctx, params, func(o *ec2.Options) {
 		o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
 			so.MaxAttempts = 11
@@ -88,21 +268,50 @@ func (d *AmiFilterOptions) GetFilteredImage(ctx context.Context, params *ec2.Des
 		return nil, err
 	}
 
-	if len(imageResp.Images) == 0 {
+	images, err := d.exclude(imageResp.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images) == 0 {
 		err := fmt.Errorf("No AMI was found matching filters: %v", params)
 		return nil, err
 	}
 
-	if len(imageResp.Images) > 1 && !d.MostRecent {
+	if d.versioned() {
+		return d.highestVersion(images)
+	}
+
+	if len(images) > 1 && !d.MostRecent {
 		err := fmt.Errorf("Your query returned more than one result. Please try a more specific search, or set most_recent to true.")
 		return nil, err
 	}
 
 	var image types.Image
 	if d.MostRecent {
-		image = mostRecentAmi(imageResp.Images)
+		image = mostRecentAmi(images)
 	} else {
-		image = imageResp.Images[0]
+		image = images[0]
 	}
 	return &image, nil
 }
+
+// GetFilteredImages returns every image matching the filters, unlike
+// GetFilteredImage, which expects a single one. No matching image is not an
+// error.
+func (d *AmiFilterOptions) GetFilteredImages(ctx context.Context, params *ec2.DescribeImagesInput, client clients.Ec2Client) ([]types.Image, error) {
+	if err := d.applyTo(params); err != nil {
+		return nil, err
+	}
+
+	var images []types.Image
+	paginator := ec2.NewDescribeImagesPaginator(client, params)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Error querying AMIs: %s", err)
+		}
+		images = append(images, page.Images...)
+	}
+	return images, nil
+}
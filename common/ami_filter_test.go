@@ -0,0 +1,134 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+)
+
+// mockImagesEC2 describes a patch line re-published after newer ones.
+type mockImagesEC2 struct {
+	clients.Ec2Client
+}
+
+func (m *mockImagesEC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	image := func(id, name, created, version, status string) types.Image {
+		image := types.Image{
+			ImageId:      aws.String(id),
+			Name:         aws.String(name),
+			CreationDate: aws.String(created),
+			Tags:         []types.Tag{{Key: aws.String("Version"), Value: aws.String(version)}},
+		}
+		if status != "" {
+			image.Tags = append(image.Tags, types.Tag{Key: aws.String("Status"), Value: aws.String(status)})
+		}
+		return image
+	}
+	return &ec2.DescribeImagesOutput{Images: []types.Image{
+		image("ami-1", "base-22.04.9", "2025-01-01T00:00:00.000Z", "22.04.9", ""),
+		image("ami-2", "base-22.04.10", "2025-01-02T00:00:00.000Z", "22.04.10", ""),
+		image("ami-3", "base-22.04.11-rc1", "2025-01-03T00:00:00.000Z", "22.04.11-rc1", "testing"),
+		image("ami-4", "base-20.04.6", "2025-01-04T00:00:00.000Z", "20.04.6", ""),
+		image("ami-5", "base-22.04.10", "2025-01-05T00:00:00.000Z", "22.04.10", ""),
+		image("ami-6", "base-latest", "2025-01-06T00:00:00.000Z", "latest", ""),
+	}}, nil
+}
+
+func TestAmiFilterOptions_Prepare(t *testing.T) {
+	tests := map[string]struct {
+		options AmiFilterOptions
+		err     string
+	}{
+		"valid": {
+			options: AmiFilterOptions{VersionRegex: `-(\d+\.\d+\.\d+)$`, VersionConstraint: "~> 22.04.3", ExcludeNameRegex: "-rc", ExcludeTags: map[string]string{"Status": "^testing$"}},
+		},
+		"regex and tag":          {AmiFilterOptions{VersionRegex: `(\d+)`, VersionTag: "Version"}, "only one of"},
+		"invalid regex":          {AmiFilterOptions{VersionRegex: `(\d+`}, "invalid version_regex"},
+		"no capture group":       {AmiFilterOptions{VersionRegex: `\d+`}, "capture group"},
+		"constraint alone":       {AmiFilterOptions{VersionConstraint: ">= 1.0"}, "requires version_regex or version_tag"},
+		"invalid constraint":     {AmiFilterOptions{VersionTag: "Version", VersionConstraint: "newest"}, "invalid version_constraint"},
+		"invalid exclude regex":  {AmiFilterOptions{ExcludeNameRegex: "("}, "invalid exclude_name_regex"},
+		"invalid exclude tag re": {AmiFilterOptions{ExcludeTags: map[string]string{"Status": "("}}, "invalid exclude_tags"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := tt.options.Prepare()
+			if tt.err == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, errs)
+			}
+		})
+	}
+}
+
+func TestAmiFilterOptions_GetFilteredImage(t *testing.T) {
+	tests := map[string]struct {
+		options AmiFilterOptions
+		want    string
+		err     string
+	}{
+		"most recent": {
+			options: AmiFilterOptions{MostRecent: true},
+			want:    "ami-6",
+		},
+		"highest version from name": {
+			options: AmiFilterOptions{VersionRegex: `-(\d+\.\d+\.\d+(?:-\w+)?)$`},
+			want:    "ami-3",
+		},
+		"highest version from tag, excluding by name": {
+			options: AmiFilterOptions{VersionTag: "Version", ExcludeNameRegex: `-rc\d*$`},
+			want:    "ami-5",
+		},
+		"excluding by tag": {
+			options: AmiFilterOptions{VersionTag: "Version", ExcludeTags: map[string]string{"Status": "^(broken|testing)$"}},
+			want:    "ami-5",
+		},
+		"constraint": {
+			options: AmiFilterOptions{VersionTag: "Version", VersionConstraint: "~> 20.04.0"},
+			want:    "ami-4",
+		},
+		"prereleases only satisfy prerelease constraints": {
+			options: AmiFilterOptions{VersionTag: "Version", VersionConstraint: ">= 22.04"},
+			want:    "ami-5",
+		},
+		"no version satisfies the constraint": {
+			options: AmiFilterOptions{VersionTag: "Version", VersionConstraint: ">= 24.04"},
+			err:     `No AMI matching filters has a version satisfying ">= 24.04"`,
+		},
+		"everything excluded": {
+			options: AmiFilterOptions{MostRecent: true, ExcludeNameRegex: "^base-"},
+			err:     "No AMI was found",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.options.Owners = []string{"self"}
+			image, err := tt.options.GetFilteredImage(context.Background(), &ec2.DescribeImagesInput{}, &mockImagesEC2{})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFilteredImage() failed: %s", err)
+			}
+			if aws.ToString(image.ImageId) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, aws.ToString(image.ImageId))
+			}
+		})
+	}
+}
@@ -177,3 +177,19 @@ func (a *Artifact) stateHCPPackerRegistryMetadata() interface{} {
 
 	return images
 }
+
+// ArtifactAmis returns the AMIs of an artifact by region. Artifacts reach
+// post-processors through RPC, so the AMIs are read back from the
+// "region:ami-id,..." Id of the AMI artifacts rather than from Amis.
+func ArtifactAmis(artifact packersdk.Artifact) (map[string]string, error) {
+	amis := make(map[string]string)
+	for _, part := range strings.Split(artifact.Id(), ",") {
+		region, ami, ok := strings.Cut(part, ":")
+		if !ok || region == "" || !strings.HasPrefix(ami, "ami-") {
+			return nil, fmt.Errorf("Unexpected artifact ID %q from builder %s, expected a list of region:ami-id",
+				artifact.Id(), artifact.BuilderId())
+		}
+		amis[region] = ami
+	}
+	return amis, nil
+}
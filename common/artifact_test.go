@@ -0,0 +1,35 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestArtifactAmis(t *testing.T) {
+	artifact := &Artifact{Amis: map[string]string{
+		"us-east-1": "ami-1234",
+		"eu-west-1": "ami-5678",
+	}}
+
+	amis, err := ArtifactAmis(artifact)
+	if err != nil {
+		t.Fatalf("ArtifactAmis() failed: %s", err)
+	}
+	if !reflect.DeepEqual(amis, artifact.Amis) {
+		t.Errorf("expected %v, got %v", artifact.Amis, amis)
+	}
+}
+
+func TestArtifactAmis_NotAnAMIArtifact(t *testing.T) {
+	for _, id := range []string{"", "vol-1234", "us-east-1:vol-1234", "us-east-1:ami-1234,eu-west-1"} {
+		artifact := &packersdk.MockArtifact{IdValue: id}
+		if _, err := ArtifactAmis(artifact); err == nil {
+			t.Errorf("expected an error for artifact ID %q", id)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// AMIShareGrantOperations are the operations EC2 needs on the key of an
+// encrypted snapshot to launch an instance from it, or to copy it, in another
+// account.
+var AMIShareGrantOperations = []kmstypes.GrantOperation{
+	kmstypes.GrantOperationDecrypt,
+	kmstypes.GrantOperationDescribeKey,
+	kmstypes.GrantOperationCreateGrant,
+	kmstypes.GrantOperationGenerateDataKeyWithoutPlaintext,
+	kmstypes.GrantOperationReEncryptFrom,
+	kmstypes.GrantOperationReEncryptTo,
+}
+
+// AMIShareGrantName names the grants letting other accounts use the key of
+// the snapshots of ami, so that unsharing it only revokes these.
+func AMIShareGrantName(ami string) string {
+	return "packer-ami-share-" + ami
+}
@@ -0,0 +1,119 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+)
+
+// GetFilteredVpc returns the VPC matching the filters, which must match
+// exactly one available VPC.
+func (d *VpcFilterOptions) GetFilteredVpc(ctx context.Context, client clients.Ec2Client) (*ec2types.Vpc, error) {
+	filters := map[string]string{}
+	maps.Copy(filters, d.Filters)
+	filters["state"] = "available"
+	params := &ec2.DescribeVpcsInput{}
+	vpcFilters, err := buildEc2Filters(filters)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse vpc filters: %s", err)
+	}
+	params.Filters = vpcFilters
+
+	vpcResp, err := client.DescribeVpcs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying VPCs: %s", err)
+	}
+	if len(vpcResp.Vpcs) != 1 {
+		return nil, fmt.Errorf("Exactly one VPC should match the filter, but %d VPC's was found matching filters: %s", len(vpcResp.Vpcs), prettyFilter(params.Filters))
+	}
+	return &vpcResp.Vpcs[0], nil
+}
+
+// GetFilteredSubnets returns the available subnets matching the filters, of
+// vpcId when set, and in the availability zones offering machineType when
+// set. They are sorted from the one with the most free IP addresses. With
+// most_free or random, only the subnet selected is returned.
+func (d *SubnetFilterOptions) GetFilteredSubnets(ctx context.Context, client clients.Ec2Client, vpcId, machineType string) ([]ec2types.Subnet, error) {
+	filters := map[string]string{}
+	maps.Copy(filters, d.Filters)
+	filters["state"] = "available"
+	if vpcId != "" {
+		filters["vpc-id"] = vpcId
+	}
+	subnetFilters, err := buildEc2Filters(filters)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse subnet filters: %s", err)
+	}
+
+	var subnets []ec2types.Subnet
+	paginator := ec2.NewDescribeSubnetsPaginator(client, &ec2.DescribeSubnetsInput{Filters: subnetFilters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Error querying Subnets: %s", err)
+		}
+		subnets = append(subnets, page.Subnets...)
+	}
+	if len(subnets) == 0 {
+		return nil, fmt.Errorf("No Subnets was found matching filters: %s", prettyFilter(subnetFilters))
+	}
+
+	if machineType != "" {
+		azs, err := filterAZByMachineType(ctx, getAZFromSubnets(subnets), machineType, client)
+		if err != nil {
+			return nil, err
+		}
+		subnets = filterSubnetsByAZ(subnets, azs)
+	}
+
+	switch {
+	case d.MostFree:
+		return []ec2types.Subnet{mostFreeSubnet(subnets)}, nil
+	case d.Random:
+		return []ec2types.Subnet{subnets[rand.Intn(len(subnets))]}, nil
+	}
+
+	sort.SliceStable(subnets, func(i, j int) bool {
+		fi, fj := aws.ToInt32(subnets[i].AvailableIpAddressCount), aws.ToInt32(subnets[j].AvailableIpAddressCount)
+		if fi != fj {
+			return fi > fj
+		}
+		return aws.ToString(subnets[i].SubnetId) < aws.ToString(subnets[j].SubnetId)
+	})
+	return subnets, nil
+}
+
+// GetFilteredSecurityGroups returns the security groups matching the
+// filters, of vpcId when set.
+func (d *SecurityGroupFilterOptions) GetFilteredSecurityGroups(ctx context.Context, client clients.Ec2Client, vpcId string) ([]ec2types.SecurityGroup, error) {
+	filters := map[string]string{}
+	maps.Copy(filters, d.Filters)
+	if vpcId != "" {
+		filters["vpc-id"] = vpcId
+	}
+	securityGroupFilters, err := buildEc2Filters(filters)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse security groups filters: %s", err)
+	}
+
+	var groups []ec2types.SecurityGroup
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(client, &ec2.DescribeSecurityGroupsInput{Filters: securityGroupFilters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't find security groups for filter: %s", err)
+		}
+		groups = append(groups, page.SecurityGroups...)
+	}
+	return groups, nil
+}
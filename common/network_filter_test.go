@@ -0,0 +1,172 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+)
+
+// mockNetworkEC2 describes one VPC with three subnets over two pages, where
+// only us-east-1a and us-east-1c offer c7g.large.
+type mockNetworkEC2 struct {
+	clients.Ec2Client
+
+	vpcs          []types.Vpc
+	subnetsInput  *ec2.DescribeSubnetsInput
+	groupsInput   *ec2.DescribeSecurityGroupsInput
+	offeringCalls int
+}
+
+func (m *mockNetworkEC2) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{Vpcs: m.vpcs}, nil
+}
+
+func (m *mockNetworkEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	m.subnetsInput = params
+	subnet := func(id, az string, free int32) types.Subnet {
+		return types.Subnet{
+			SubnetId:                aws.String(id),
+			AvailabilityZone:        aws.String(az),
+			AvailableIpAddressCount: aws.Int32(free),
+		}
+	}
+	if params.NextToken == nil {
+		return &ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{
+				subnet("subnet-1", "us-east-1a", 10),
+				subnet("subnet-2", "us-east-1b", 200),
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	return &ec2.DescribeSubnetsOutput{
+		Subnets: []types.Subnet{subnet("subnet-3", "us-east-1c", 50)},
+	}, nil
+}
+
+func (m *mockNetworkEC2) DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	m.offeringCalls++
+	output := &ec2.DescribeInstanceTypeOfferingsOutput{}
+	if az := params.Filters[0].Values[0]; az != "us-east-1b" {
+		output.InstanceTypeOfferings = []types.InstanceTypeOffering{{InstanceType: "c7g.large", Location: aws.String(az)}}
+	}
+	return output, nil
+}
+
+func (m *mockNetworkEC2) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	m.groupsInput = params
+	return &ec2.DescribeSecurityGroupsOutput{
+		SecurityGroups: []types.SecurityGroup{{GroupId: aws.String("sg-1")}},
+	}, nil
+}
+
+func subnetIds(subnets []types.Subnet) []string {
+	ids := []string{}
+	for _, subnet := range subnets {
+		ids = append(ids, aws.ToString(subnet.SubnetId))
+	}
+	return ids
+}
+
+func TestVpcFilterOptions_GetFilteredVpc(t *testing.T) {
+	options := VpcFilterOptions{}
+	options.Filters = map[string]string{"tag:Class": "build"}
+
+	client := &mockNetworkEC2{vpcs: []types.Vpc{{VpcId: aws.String("vpc-1")}}}
+	vpc, err := options.GetFilteredVpc(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if aws.ToString(vpc.VpcId) != "vpc-1" {
+		t.Errorf("unexpected VPC %q", aws.ToString(vpc.VpcId))
+	}
+	if _, ok := options.Filters["state"]; ok {
+		t.Errorf("the filters of the options should not be modified: %v", options.Filters)
+	}
+
+	client.vpcs = append(client.vpcs, types.Vpc{VpcId: aws.String("vpc-2")})
+	if _, err := options.GetFilteredVpc(context.TODO(), client); err == nil || !strings.Contains(err.Error(), "Exactly one VPC") {
+		t.Errorf("expected an error for two matching VPCs, got %v", err)
+	}
+}
+
+func TestSubnetFilterOptions_GetFilteredSubnets(t *testing.T) {
+	tests := map[string]struct {
+		options     SubnetFilterOptions
+		machineType string
+		want        []string
+	}{
+		"all":               {SubnetFilterOptions{}, "", []string{"subnet-2", "subnet-3", "subnet-1"}},
+		"most free":         {SubnetFilterOptions{MostFree: true}, "", []string{"subnet-2"}},
+		"machine type":      {SubnetFilterOptions{}, "c7g.large", []string{"subnet-3", "subnet-1"}},
+		"most free offered": {SubnetFilterOptions{MostFree: true}, "c7g.large", []string{"subnet-3"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &mockNetworkEC2{}
+			subnets, err := tt.options.GetFilteredSubnets(context.TODO(), client, "vpc-1", tt.machineType)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got := subnetIds(subnets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got subnets %v, want %v", got, tt.want)
+			}
+			if tt.machineType == "" && client.offeringCalls != 0 {
+				t.Errorf("instance type offerings should not be described without a machine type")
+			}
+
+			filters := map[string]string{}
+			for _, filter := range client.subnetsInput.Filters {
+				filters[aws.ToString(filter.Name)] = filter.Values[0]
+			}
+			if filters["vpc-id"] != "vpc-1" || filters["state"] != "available" {
+				t.Errorf("unexpected subnet filters %v", filters)
+			}
+		})
+	}
+}
+
+func TestSubnetFilterOptions_GetFilteredSubnets_Random(t *testing.T) {
+	options := SubnetFilterOptions{Random: true}
+	subnets, err := options.GetFilteredSubnets(context.TODO(), &mockNetworkEC2{}, "", "")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(subnets) != 1 {
+		t.Errorf("expected one random subnet, got %v", subnetIds(subnets))
+	}
+}
+
+func TestSubnetFilterOptions_GetFilteredSubnets_NotOffered(t *testing.T) {
+	options := SubnetFilterOptions{}
+	_, err := options.GetFilteredSubnets(context.TODO(), &mockNetworkEC2{}, "", "p5.48xlarge")
+	if err == nil || !strings.Contains(err.Error(), `"p5.48xlarge"`) {
+		t.Errorf("expected an error for an instance type offered nowhere, got %v", err)
+	}
+}
+
+func TestSecurityGroupFilterOptions_GetFilteredSecurityGroups(t *testing.T) {
+	options := SecurityGroupFilterOptions{}
+	options.Filters = map[string]string{"group-name": "build"}
+
+	client := &mockNetworkEC2{}
+	groups, err := options.GetFilteredSecurityGroups(context.TODO(), client, "vpc-1")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(groups) != 1 || aws.ToString(groups[0].GroupId) != "sg-1" {
+		t.Errorf("unexpected security groups %v", groups)
+	}
+	if len(client.groupsInput.Filters) != 2 {
+		t.Errorf("expected the group-name and vpc-id filters, got %v", prettyFilter(client.groupsInput.Filters))
+	}
+}
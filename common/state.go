@@ -19,6 +19,7 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/hashicorp/packer-plugin-amazon/common/clients"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
 // StateRefreshFunc is a function type used for StateChangeConf that is
@@ -235,7 +236,35 @@ func (w *AWSPollingConfig) getWaiterOptions() *PollingOptions {
 	return applyEnvOverrides(envOverrides)
 }
 
-func (w *AWSPollingConfig) WaitUntilImageImported(ctx context.Context, conn clients.Ec2Client, taskID string) error {
+// TaskStatus is the state of an import or export task as reported by EC2
+// while waiting for it.
+type TaskStatus struct {
+	TaskID        string
+	Progress      string
+	Status        string
+	StatusMessage string
+}
+
+// TaskProgressFunc is called by the import and export waiters whenever the
+// status of the task changes. It may be nil.
+type TaskProgressFunc func(TaskStatus)
+
+// ReportTaskProgress returns the TaskProgressFunc reporting the status of a
+// task to ui, one message per change.
+func ReportTaskProgress(ui packersdk.Ui) TaskProgressFunc {
+	return func(status TaskStatus) {
+		msg := fmt.Sprintf("%s: %s", status.TaskID, status.Status)
+		if status.Progress != "" {
+			msg += fmt.Sprintf(", %s%%", status.Progress)
+		}
+		if status.StatusMessage != "" {
+			msg += fmt.Sprintf(" (%s)", status.StatusMessage)
+		}
+		ui.Message(msg)
+	}
+}
+
+func (w *AWSPollingConfig) WaitUntilImageImported(ctx context.Context, conn clients.Ec2Client, taskID string, onProgress TaskProgressFunc) error {
 	importInput := ec2.DescribeImportImageTasksInput{
 		ImportTaskIds: []string{taskID},
 	}
@@ -243,12 +272,13 @@ func (w *AWSPollingConfig) WaitUntilImageImported(ctx context.Context, conn clie
 	err := WaitForImageToBeImported(conn,
 		ctx,
 		&importInput,
-		w.getWaiterOptions())
+		w.getWaiterOptions(),
+		onProgress)
 	return err
 }
 
 func WaitForImageToBeImported(client clients.Ec2Client, ctx context.Context, input *ec2.DescribeImportImageTasksInput,
-	opts *PollingOptions) error {
+	opts *PollingOptions, onProgress TaskProgressFunc) error {
 	// we have tried to simulate here a behaviour that's similar to what we have in v1.
 	// aws sdk go v2 does not provide a builtin waiter for Import Image Tasks.
 
@@ -266,6 +296,7 @@ func WaitForImageToBeImported(client clients.Ec2Client, ctx context.Context, inp
 
 	}
 
+	var last TaskStatus
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		output, err := client.DescribeImportImageTasks(ctx, input)
 		if err != nil {
@@ -277,13 +308,24 @@ func WaitForImageToBeImported(client clients.Ec2Client, ctx context.Context, inp
 		}
 
 		for _, task := range output.ImportImageTasks {
+			status := TaskStatus{
+				TaskID:        aws.ToString(task.ImportTaskId),
+				Progress:      aws.ToString(task.Progress),
+				Status:        aws.ToString(task.Status),
+				StatusMessage: aws.ToString(task.StatusMessage),
+			}
+			if onProgress != nil && status != last {
+				onProgress(status)
+			}
+			last = status
+
 			// Check for failure states
-			if *task.Status == "deleted" {
+			if status.Status == "deleting" || status.Status == "deleted" {
 				return fmt.Errorf("import task was deleted")
 			}
 
 			// Check for success state
-			if *task.Status == "completed" {
+			if status.Status == "completed" {
 				return nil
 			}
 		}
@@ -299,6 +341,243 @@ func WaitForImageToBeImported(client clients.Ec2Client, ctx context.Context, inp
 
 	return fmt.Errorf("timeout waiting for image import to complete after %d attempts", maxAttempts)
 }
+
+func (w *AWSPollingConfig) WaitUntilSnapshotImported(ctx context.Context, conn clients.Ec2Client, taskID string, onProgress TaskProgressFunc) error {
+	importInput := ec2.DescribeImportSnapshotTasksInput{
+		ImportTaskIds: []string{taskID},
+	}
+
+	err := WaitForSnapshotToBeImported(conn,
+		ctx,
+		&importInput,
+		w.getWaiterOptions(),
+		onProgress)
+	return err
+}
+
+func WaitForSnapshotToBeImported(client clients.Ec2Client, ctx context.Context, input *ec2.DescribeImportSnapshotTasksInput,
+	opts *PollingOptions, onProgress TaskProgressFunc) error {
+	// Same defaults as for image imports, snapshot imports of large disks
+	// can take just as long.
+	maxAttempts := 720
+	delay := 5 * time.Second
+
+	if opts != nil {
+		if opts.MinDelay != nil {
+			delay = aws.ToDuration(opts.MinDelay)
+		}
+
+		if opts.MaxWaitTime != nil {
+			maxAttempts = int(opts.MaxWaitTime.Seconds() / delay.Seconds())
+		}
+
+	}
+
+	var last TaskStatus
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		output, err := client.DescribeImportSnapshotTasks(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		if len(output.ImportSnapshotTasks) == 0 {
+			return fmt.Errorf("import snapshot task not found")
+		}
+
+		for _, task := range output.ImportSnapshotTasks {
+			if task.SnapshotTaskDetail == nil {
+				continue
+			}
+			current := TaskStatus{
+				TaskID:        aws.ToString(task.ImportTaskId),
+				Progress:      aws.ToString(task.SnapshotTaskDetail.Progress),
+				Status:        aws.ToString(task.SnapshotTaskDetail.Status),
+				StatusMessage: aws.ToString(task.SnapshotTaskDetail.StatusMessage),
+			}
+			if onProgress != nil && current != last {
+				onProgress(current)
+			}
+			last = current
+			status := current.Status
+
+			// Check for failure states
+			if status == "deleting" || status == "deleted" {
+				return fmt.Errorf("import snapshot task was deleted")
+			}
+
+			// Check for success state
+			if status == "completed" {
+				return nil
+			}
+		}
+
+		// Wait before next attempt
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+	}
+
+	return fmt.Errorf("timeout waiting for snapshot import to complete after %d attempts", maxAttempts)
+}
+
+func (w *AWSPollingConfig) WaitUntilImageExported(ctx context.Context, conn clients.Ec2Client, taskID string, onProgress TaskProgressFunc) error {
+	exportInput := ec2.DescribeExportImageTasksInput{
+		ExportImageTaskIds: []string{taskID},
+	}
+
+	err := WaitForImageToBeExported(conn,
+		ctx,
+		&exportInput,
+		w.getWaiterOptions(),
+		onProgress)
+	return err
+}
+
+func WaitForImageToBeExported(client clients.Ec2Client, ctx context.Context, input *ec2.DescribeExportImageTasksInput,
+	opts *PollingOptions, onProgress TaskProgressFunc) error {
+	// Exports go through the same conversion as imports, the same defaults
+	// apply.
+	maxAttempts := 720
+	delay := 5 * time.Second
+
+	if opts != nil {
+		if opts.MinDelay != nil {
+			delay = aws.ToDuration(opts.MinDelay)
+		}
+
+		if opts.MaxWaitTime != nil {
+			maxAttempts = int(opts.MaxWaitTime.Seconds() / delay.Seconds())
+		}
+
+	}
+
+	var last TaskStatus
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		output, err := client.DescribeExportImageTasks(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		if len(output.ExportImageTasks) == 0 {
+			return fmt.Errorf("export image task not found")
+		}
+
+		for _, task := range output.ExportImageTasks {
+			current := TaskStatus{
+				TaskID:        aws.ToString(task.ExportImageTaskId),
+				Progress:      aws.ToString(task.Progress),
+				Status:        aws.ToString(task.Status),
+				StatusMessage: aws.ToString(task.StatusMessage),
+			}
+			if onProgress != nil && current != last {
+				onProgress(current)
+			}
+			last = current
+
+			// Check for failure states
+			if current.Status == "deleting" || current.Status == "deleted" {
+				return fmt.Errorf("export image task was deleted")
+			}
+
+			// Check for success state
+			if current.Status == "completed" {
+				return nil
+			}
+		}
+
+		// Wait before next attempt
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+	}
+
+	return fmt.Errorf("timeout waiting for image export to complete after %d attempts", maxAttempts)
+}
+
+func (w *AWSPollingConfig) WaitUntilImageStored(ctx context.Context, conn clients.Ec2Client, imageId string, onProgress TaskProgressFunc) error {
+	storeInput := ec2.DescribeStoreImageTasksInput{
+		ImageIds: []string{imageId},
+	}
+
+	err := WaitForImageToBeStored(conn,
+		ctx,
+		&storeInput,
+		w.getWaiterOptions(),
+		onProgress)
+	return err
+}
+
+func WaitForImageToBeStored(client clients.Ec2Client, ctx context.Context, input *ec2.DescribeStoreImageTasksInput,
+	opts *PollingOptions, onProgress TaskProgressFunc) error {
+	// Store tasks copy every snapshot of the AMI, like exports.
+	maxAttempts := 720
+	delay := 5 * time.Second
+
+	if opts != nil {
+		if opts.MinDelay != nil {
+			delay = aws.ToDuration(opts.MinDelay)
+		}
+
+		if opts.MaxWaitTime != nil {
+			maxAttempts = int(opts.MaxWaitTime.Seconds() / delay.Seconds())
+		}
+
+	}
+
+	var last TaskStatus
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		output, err := client.DescribeStoreImageTasks(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		if len(output.StoreImageTaskResults) == 0 {
+			return fmt.Errorf("store image task not found")
+		}
+
+		for _, task := range output.StoreImageTaskResults {
+			current := TaskStatus{
+				TaskID:        aws.ToString(task.S3objectKey),
+				Status:        aws.ToString(task.StoreTaskState),
+				StatusMessage: aws.ToString(task.StoreTaskFailureReason),
+			}
+			if task.ProgressPercentage != nil {
+				current.Progress = strconv.Itoa(int(*task.ProgressPercentage))
+			}
+			if onProgress != nil && current != last {
+				onProgress(current)
+			}
+			last = current
+
+			// Check for failure states
+			if current.Status == "Failed" {
+				return fmt.Errorf("store image task failed: %s", current.StatusMessage)
+			}
+
+			// Check for success state
+			if current.Status == "Completed" {
+				return nil
+			}
+		}
+
+		// Wait before next attempt
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+	}
+
+	return fmt.Errorf("timeout waiting for image store to complete after %d attempts", maxAttempts)
+}
+
 func WaitForVolumeToBeAttached(client clients.Ec2Client, ctx context.Context, input *ec2.DescribeVolumesInput,
 	opts *PollingOptions) error {
 	maxAttempts := 40
@@ -515,6 +794,29 @@ func (w *AWSPollingConfig) WaitUntilInstanceRunning(ctx context.Context, ec2Clie
 	return err
 }
 
+// WaitUntilInstanceStatusOk waits for the system and instance status checks
+// of the instance to pass.
+func (w *AWSPollingConfig) WaitUntilInstanceStatusOk(ctx context.Context, ec2Client clients.Ec2Client, instanceId string) error {
+	statusInput := ec2.DescribeInstanceStatusInput{
+		InstanceIds: []string{instanceId},
+	}
+
+	pollingOptions := w.getWaiterOptions()
+	var optFns []func(*ec2.InstanceStatusOkWaiterOptions)
+
+	if pollingOptions.MaxWaitTime == nil {
+		pollingOptions.MaxWaitTime = aws.Duration(AwsDefaultMaxWaitTimeDuration)
+	}
+	if pollingOptions.MinDelay != nil {
+		optFns = append(optFns, func(o *ec2.InstanceStatusOkWaiterOptions) {
+			o.MinDelay = *pollingOptions.MinDelay
+		})
+	}
+
+	err := ec2.NewInstanceStatusOkWaiter(ec2Client).Wait(ctx, &statusInput, *pollingOptions.MaxWaitTime, optFns...)
+	return err
+}
+
 func (w *AWSPollingConfig) WaitUntilInstanceTerminated(ctx context.Context, ec2Client clients.Ec2Client, instanceId string) error {
 	instanceInput := ec2.DescribeInstancesInput{
 		InstanceIds: []string{instanceId},
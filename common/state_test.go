@@ -0,0 +1,27 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestReportTaskProgress(t *testing.T) {
+	ui := &packersdk.MockUi{}
+	report := ReportTaskProgress(ui)
+	report(TaskStatus{TaskID: "import-ami-1", Status: "active", Progress: "42", StatusMessage: "converting"})
+	report(TaskStatus{TaskID: "import-ami-1", Status: "completed"})
+
+	want := []string{"import-ami-1: active, 42% (converting)", "import-ami-1: completed"}
+	if len(ui.SayMessages) != len(want) {
+		t.Fatalf("expected %d messages, got %v", len(want), ui.SayMessages)
+	}
+	for i, message := range ui.SayMessages {
+		if message.Message != want[i] {
+			t.Errorf("message %d: got %q, want %q", i, message.Message, want[i])
+		}
+	}
+}
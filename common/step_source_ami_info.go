@@ -7,10 +7,14 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/hashicorp/packer-plugin-amazon/common/clients"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -23,8 +27,11 @@ import (
 // Produces:
 //
 //	source_image *ec2.Image - the source AMI info
+//	source_ami_ssm_parameter string - the SSM parameter the source AMI was read from
+//	source_ami_ssm_parameter_version string - the version of that parameter
 type StepSourceAMIInfo struct {
 	SourceAmi                string
+	SourceAmiSSMParameter    string
 	EnableAMISriovNetSupport bool
 	EnableAMIENASupport      config.Trilean
 	AMIVirtType              string
@@ -32,6 +39,12 @@ type StepSourceAMIInfo struct {
 	IncludeDeprecated        bool
 }
 
+// ssmParameterAPI is the part of the SSM API used to resolve
+// source_ami_ssm_parameter.
+type ssmParameterAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
 type imageSort []types.Image
 
 func (a imageSort) Len() int      { return len(a) }
@@ -61,7 +74,22 @@ func (s *StepSourceAMIInfo) Run(ctx context.Context, state multistep.StateBag) m
 		params.ImageIds = []string{s.SourceAmi}
 	}
 
+	if s.SourceAmiSSMParameter != "" {
+		awsConfig := state.Get("aws_config").(*aws.Config)
+		ui.Say(fmt.Sprintf("Reading source AMI from SSM parameter %s...", s.SourceAmiSSMParameter))
+		imageId, err := s.resolveSSMParameter(ctx, ssm.NewFromConfig(*awsConfig), state)
+		if err != nil {
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		params.ImageIds = []string{imageId}
+	}
+
 	image, err := s.AmiFilters.GetFilteredImage(ctx, params, client)
+	if err != nil && s.SourceAmiSSMParameter != "" {
+		err = fmt.Errorf("AMI %s of SSM parameter %s: %s", params.ImageIds[0], s.SourceAmiSSMParameter, err)
+	}
 	if err != nil {
 		state.Put("error", err)
 		ui.Error(err.Error())
@@ -87,6 +115,26 @@ func (s *StepSourceAMIInfo) Run(ctx context.Context, state multistep.StateBag) m
 
 func (s *StepSourceAMIInfo) Cleanup(multistep.StateBag) {}
 
+// resolveSSMParameter returns the AMI ID held by source_ami_ssm_parameter
+// and records the parameter name and version.
+func (s *StepSourceAMIInfo) resolveSSMParameter(ctx context.Context, client ssmParameterAPI, state multistep.StateBag) (string, error) {
+	resp, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name: aws.String(s.SourceAmiSSMParameter),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error reading SSM parameter %s: %s", s.SourceAmiSSMParameter, err)
+	}
+
+	imageId := aws.ToString(resp.Parameter.Value)
+	if !strings.HasPrefix(imageId, "ami-") {
+		return "", fmt.Errorf("SSM parameter %s does not hold an AMI ID: %q", s.SourceAmiSSMParameter, imageId)
+	}
+
+	state.Put("source_ami_ssm_parameter", aws.ToString(resp.Parameter.Name))
+	state.Put("source_ami_ssm_parameter_version", strconv.FormatInt(resp.Parameter.Version, 10))
+	return imageId, nil
+}
+
 func (s *StepSourceAMIInfo) canEnableEnhancedNetworking(image *types.Image) error {
 	if s.AMIVirtType == "hvm" {
 		return nil
@@ -95,7 +143,7 @@ func (s *StepSourceAMIInfo) canEnableEnhancedNetworking(image *types.Image) erro
 		return fmt.Errorf("Cannot enable enhanced networking, AMIVirtType '%s' is not HVM", s.AMIVirtType)
 	}
 	if image.VirtualizationType != types.VirtualizationTypeHvm {
-		return fmt.Errorf("Cannot enable enhanced networking, source AMI '%s' is not HVM", s.SourceAmi)
+		return fmt.Errorf("Cannot enable enhanced networking, source AMI '%s' is not HVM", aws.ToString(image.ImageId))
 	}
 	return nil
 }
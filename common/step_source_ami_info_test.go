@@ -4,9 +4,14 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,3 +48,44 @@ func TestStepSourceAmiInfo_PVImageWithAMIVirtHVM(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+type mockSSMParameter struct {
+	value string
+	err   error
+	input *ssm.GetParameterInput
+}
+
+func (m *mockSSMParameter) GetParameter(ctx context.Context, input *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	m.input = input
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{
+		Name:    aws.String("/golden/base"),
+		Value:   aws.String(m.value),
+		Version: 3,
+	}}, nil
+}
+
+func TestStepSourceAmiInfo_resolveSSMParameter(t *testing.T) {
+	step := StepSourceAMIInfo{SourceAmiSSMParameter: "/golden/base:prod"}
+	state := testState()
+	client := &mockSSMParameter{value: "ami-0123456789abcdef0"}
+
+	imageId, err := step.resolveSSMParameter(context.Background(), client, state)
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-0123456789abcdef0", imageId)
+	assert.Equal(t, "/golden/base:prod", aws.ToString(client.input.Name))
+	assert.Equal(t, "/golden/base", state.Get("source_ami_ssm_parameter"))
+	assert.Equal(t, "3", state.Get("source_ami_ssm_parameter_version"))
+}
+
+func TestStepSourceAmiInfo_resolveSSMParameterErrors(t *testing.T) {
+	step := StepSourceAMIInfo{SourceAmiSSMParameter: "/golden/base"}
+
+	_, err := step.resolveSSMParameter(context.Background(), &mockSSMParameter{value: "latest"}, testState())
+	assert.ErrorContains(t, err, "does not hold an AMI ID")
+
+	_, err = step.resolveSSMParameter(context.Background(), &mockSSMParameter{err: errors.New("ParameterNotFound")}, testState())
+	assert.ErrorContains(t, err, "ParameterNotFound")
+}
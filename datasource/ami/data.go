@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Config
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,BlockDeviceMapping,Config
 package ami
 
 import (
@@ -11,6 +11,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
 	"github.com/hashicorp/packer-plugin-sdk/common"
@@ -49,6 +50,7 @@ func (d *Datasource) Configure(raws ...any) error {
 	if d.config.NoOwner() {
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("For security reasons, you must declare an owner."))
 	}
+	errs = packersdk.MultiErrorAppend(errs, d.config.AmiFilterOptions.Prepare()...)
 
 	if errs != nil && len(errs.Errors) > 0 {
 		return errs
@@ -69,6 +71,55 @@ type DatasourceOutput struct {
 	OwnerName string `mapstructure:"owner_name"`
 	// The key/value combination of the tags assigned to the AMI.
 	Tags map[string]string `mapstructure:"tags"`
+	// The architecture of the AMI, such as `x86_64` or `arm64`.
+	Architecture string `mapstructure:"architecture"`
+	// The boot mode of the AMI, `legacy-bios`, `uefi` or
+	// `uefi-preferred`. Empty when the AMI has no boot mode set.
+	BootMode string `mapstructure:"boot_mode"`
+	// The device name of the root device volume, such as `/dev/sda1`.
+	RootDeviceName string `mapstructure:"root_device_name"`
+	// The type of the root device, `ebs` or `instance-store`.
+	RootDeviceType string `mapstructure:"root_device_type"`
+	// The virtualization type of the AMI, `hvm` or `paravirtual`.
+	VirtualizationType string `mapstructure:"virtualization_type"`
+	// Whether enhanced networking with ENA is enabled.
+	ENASupport bool `mapstructure:"ena_support"`
+	// `simple` when enhanced networking with the Intel 82599 Virtual
+	// Function interface is enabled.
+	SriovNetSupport string `mapstructure:"sriov_net_support"`
+	// `v2.0` when the instances launched from the AMI have NitroTPM
+	// enabled.
+	TpmSupport string `mapstructure:"tpm_support"`
+	// `v2.0` when the instances launched from the AMI require IMDSv2.
+	ImdsSupport string `mapstructure:"imds_support"`
+	// The platform details of the AMI, such as `Linux/UNIX` or `Windows`,
+	// which billing depends on.
+	PlatformDetails string `mapstructure:"platform_details"`
+	// The date and time the AMI is deprecated at, in RFC3339 format.
+	// Empty when the AMI has no deprecation time.
+	DeprecationTime string `mapstructure:"deprecation_time"`
+	// The state of the AMI, such as `available`, `deregistered` or
+	// `disabled`.
+	State string `mapstructure:"state"`
+	// The block device mappings of the AMI.
+	BlockDeviceMappings []BlockDeviceMapping `mapstructure:"block_device_mappings"`
+}
+
+// BlockDeviceMapping is a block device mapping of an AMI. Instance store
+// and suppressed devices only have a device name.
+type BlockDeviceMapping struct {
+	// The device name, such as `/dev/sda1`.
+	DeviceName string `mapstructure:"device_name"`
+	// The ID of the snapshot of the EBS volume.
+	SnapshotID string `mapstructure:"snapshot_id"`
+	// The size of the EBS volume, in GiB.
+	VolumeSize int64 `mapstructure:"volume_size"`
+	// The type of the EBS volume, such as `gp3`.
+	VolumeType string `mapstructure:"volume_type"`
+	// Whether the EBS volume is encrypted.
+	Encrypted bool `mapstructure:"encrypted"`
+	// The ARN of the KMS key the EBS volume is encrypted with.
+	KmsKeyID string `mapstructure:"kms_key_id"`
 }
 
 func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
@@ -87,18 +138,52 @@ func (d *Datasource) Execute() (cty.Value, error) {
 		return cty.NullVal(cty.EmptyObject), err
 	}
 
+	output := ImageOutput(image)
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// ImageOutput returns the output of the datasource for image. The
+// amazon-amis datasource returns the same output for each of its images.
+func ImageOutput(image *types.Image) DatasourceOutput {
 	imageTags := make(map[string]string, len(image.Tags))
 	for _, tag := range image.Tags {
 		imageTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
 	}
 
-	output := DatasourceOutput{
-		ID:           aws.ToString(image.ImageId),
-		Name:         aws.ToString(image.Name),
-		CreationDate: aws.ToString(image.CreationDate),
-		Owner:        aws.ToString(image.OwnerId),
-		OwnerName:    aws.ToString(image.ImageOwnerAlias),
-		Tags:         imageTags,
+	mappings := make([]BlockDeviceMapping, 0, len(image.BlockDeviceMappings))
+	for _, m := range image.BlockDeviceMappings {
+		mapping := BlockDeviceMapping{
+			DeviceName: aws.ToString(m.DeviceName),
+		}
+		if m.Ebs != nil {
+			mapping.SnapshotID = aws.ToString(m.Ebs.SnapshotId)
+			mapping.VolumeSize = int64(aws.ToInt32(m.Ebs.VolumeSize))
+			mapping.VolumeType = string(m.Ebs.VolumeType)
+			mapping.Encrypted = aws.ToBool(m.Ebs.Encrypted)
+			mapping.KmsKeyID = aws.ToString(m.Ebs.KmsKeyId)
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return DatasourceOutput{
+		ID:                  aws.ToString(image.ImageId),
+		Name:                aws.ToString(image.Name),
+		CreationDate:        aws.ToString(image.CreationDate),
+		Owner:               aws.ToString(image.OwnerId),
+		OwnerName:           aws.ToString(image.ImageOwnerAlias),
+		Tags:                imageTags,
+		Architecture:        string(image.Architecture),
+		BootMode:            string(image.BootMode),
+		RootDeviceName:      aws.ToString(image.RootDeviceName),
+		RootDeviceType:      string(image.RootDeviceType),
+		VirtualizationType:  string(image.VirtualizationType),
+		ENASupport:          aws.ToBool(image.EnaSupport),
+		SriovNetSupport:     aws.ToString(image.SriovNetSupport),
+		TpmSupport:          string(image.TpmSupport),
+		ImdsSupport:         string(image.ImdsSupport),
+		PlatformDetails:     aws.ToString(image.PlatformDetails),
+		DeprecationTime:     aws.ToString(image.DeprecationTime),
+		State:               string(image.State),
+		BlockDeviceMappings: mappings,
 	}
-	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
 }
@@ -8,6 +8,39 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+// FlatBlockDeviceMapping is an auto-generated flat version of BlockDeviceMapping.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatBlockDeviceMapping struct {
+	DeviceName *string `mapstructure:"device_name" cty:"device_name" hcl:"device_name"`
+	SnapshotID *string `mapstructure:"snapshot_id" cty:"snapshot_id" hcl:"snapshot_id"`
+	VolumeSize *int64  `mapstructure:"volume_size" cty:"volume_size" hcl:"volume_size"`
+	VolumeType *string `mapstructure:"volume_type" cty:"volume_type" hcl:"volume_type"`
+	Encrypted  *bool   `mapstructure:"encrypted" cty:"encrypted" hcl:"encrypted"`
+	KmsKeyID   *string `mapstructure:"kms_key_id" cty:"kms_key_id" hcl:"kms_key_id"`
+}
+
+// FlatMapstructure returns a new FlatBlockDeviceMapping.
+// FlatBlockDeviceMapping is an auto-generated flat version of BlockDeviceMapping.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*BlockDeviceMapping) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatBlockDeviceMapping)
+}
+
+// HCL2Spec returns the hcl spec of a BlockDeviceMapping.
+// This spec is used by HCL to read the fields of BlockDeviceMapping.
+// The decoded values from this spec will then be applied to a FlatBlockDeviceMapping.
+func (*FlatBlockDeviceMapping) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"device_name": &hcldec.AttrSpec{Name: "device_name", Type: cty.String, Required: false},
+		"snapshot_id": &hcldec.AttrSpec{Name: "snapshot_id", Type: cty.String, Required: false},
+		"volume_size": &hcldec.AttrSpec{Name: "volume_size", Type: cty.Number, Required: false},
+		"volume_type": &hcldec.AttrSpec{Name: "volume_type", Type: cty.String, Required: false},
+		"encrypted":   &hcldec.AttrSpec{Name: "encrypted", Type: cty.Bool, Required: false},
+		"kms_key_id":  &hcldec.AttrSpec{Name: "kms_key_id", Type: cty.String, Required: false},
+	}
+	return s
+}
+
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
@@ -39,6 +72,11 @@ type FlatConfig struct {
 	Owners                []string                          `mapstructure:"owners" cty:"owners" hcl:"owners"`
 	MostRecent            *bool                             `mapstructure:"most_recent" cty:"most_recent" hcl:"most_recent"`
 	IncludeDeprecated     *bool                             `mapstructure:"include_deprecated" cty:"include_deprecated" hcl:"include_deprecated"`
+	VersionRegex          *string                           `mapstructure:"version_regex" cty:"version_regex" hcl:"version_regex"`
+	VersionTag            *string                           `mapstructure:"version_tag" cty:"version_tag" hcl:"version_tag"`
+	VersionConstraint     *string                           `mapstructure:"version_constraint" cty:"version_constraint" hcl:"version_constraint"`
+	ExcludeNameRegex      *string                           `mapstructure:"exclude_name_regex" cty:"exclude_name_regex" hcl:"exclude_name_regex"`
+	ExcludeTags           map[string]string                 `mapstructure:"exclude_tags" cty:"exclude_tags" hcl:"exclude_tags"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -81,6 +119,11 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"owners":                        &hcldec.AttrSpec{Name: "owners", Type: cty.List(cty.String), Required: false},
 		"most_recent":                   &hcldec.AttrSpec{Name: "most_recent", Type: cty.Bool, Required: false},
 		"include_deprecated":            &hcldec.AttrSpec{Name: "include_deprecated", Type: cty.Bool, Required: false},
+		"version_regex":                 &hcldec.AttrSpec{Name: "version_regex", Type: cty.String, Required: false},
+		"version_tag":                   &hcldec.AttrSpec{Name: "version_tag", Type: cty.String, Required: false},
+		"version_constraint":            &hcldec.AttrSpec{Name: "version_constraint", Type: cty.String, Required: false},
+		"exclude_name_regex":            &hcldec.AttrSpec{Name: "exclude_name_regex", Type: cty.String, Required: false},
+		"exclude_tags":                  &hcldec.AttrSpec{Name: "exclude_tags", Type: cty.Map(cty.String), Required: false},
 	}
 	return s
 }
@@ -88,12 +131,25 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatDatasourceOutput struct {
-	ID           *string           `mapstructure:"id" cty:"id" hcl:"id"`
-	Name         *string           `mapstructure:"name" cty:"name" hcl:"name"`
-	CreationDate *string           `mapstructure:"creation_date" cty:"creation_date" hcl:"creation_date"`
-	Owner        *string           `mapstructure:"owner" cty:"owner" hcl:"owner"`
-	OwnerName    *string           `mapstructure:"owner_name" cty:"owner_name" hcl:"owner_name"`
-	Tags         map[string]string `mapstructure:"tags" cty:"tags" hcl:"tags"`
+	ID                  *string                  `mapstructure:"id" cty:"id" hcl:"id"`
+	Name                *string                  `mapstructure:"name" cty:"name" hcl:"name"`
+	CreationDate        *string                  `mapstructure:"creation_date" cty:"creation_date" hcl:"creation_date"`
+	Owner               *string                  `mapstructure:"owner" cty:"owner" hcl:"owner"`
+	OwnerName           *string                  `mapstructure:"owner_name" cty:"owner_name" hcl:"owner_name"`
+	Tags                map[string]string        `mapstructure:"tags" cty:"tags" hcl:"tags"`
+	Architecture        *string                  `mapstructure:"architecture" cty:"architecture" hcl:"architecture"`
+	BootMode            *string                  `mapstructure:"boot_mode" cty:"boot_mode" hcl:"boot_mode"`
+	RootDeviceName      *string                  `mapstructure:"root_device_name" cty:"root_device_name" hcl:"root_device_name"`
+	RootDeviceType      *string                  `mapstructure:"root_device_type" cty:"root_device_type" hcl:"root_device_type"`
+	VirtualizationType  *string                  `mapstructure:"virtualization_type" cty:"virtualization_type" hcl:"virtualization_type"`
+	ENASupport          *bool                    `mapstructure:"ena_support" cty:"ena_support" hcl:"ena_support"`
+	SriovNetSupport     *string                  `mapstructure:"sriov_net_support" cty:"sriov_net_support" hcl:"sriov_net_support"`
+	TpmSupport          *string                  `mapstructure:"tpm_support" cty:"tpm_support" hcl:"tpm_support"`
+	ImdsSupport         *string                  `mapstructure:"imds_support" cty:"imds_support" hcl:"imds_support"`
+	PlatformDetails     *string                  `mapstructure:"platform_details" cty:"platform_details" hcl:"platform_details"`
+	DeprecationTime     *string                  `mapstructure:"deprecation_time" cty:"deprecation_time" hcl:"deprecation_time"`
+	State               *string                  `mapstructure:"state" cty:"state" hcl:"state"`
+	BlockDeviceMappings []FlatBlockDeviceMapping `mapstructure:"block_device_mappings" cty:"block_device_mappings" hcl:"block_device_mappings"`
 }
 
 // FlatMapstructure returns a new FlatDatasourceOutput.
@@ -108,12 +164,25 @@ func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcl
 // The decoded values from this spec will then be applied to a FlatDatasourceOutput.
 func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"id":            &hcldec.AttrSpec{Name: "id", Type: cty.String, Required: false},
-		"name":          &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
-		"creation_date": &hcldec.AttrSpec{Name: "creation_date", Type: cty.String, Required: false},
-		"owner":         &hcldec.AttrSpec{Name: "owner", Type: cty.String, Required: false},
-		"owner_name":    &hcldec.AttrSpec{Name: "owner_name", Type: cty.String, Required: false},
-		"tags":          &hcldec.AttrSpec{Name: "tags", Type: cty.Map(cty.String), Required: false},
+		"id":                    &hcldec.AttrSpec{Name: "id", Type: cty.String, Required: false},
+		"name":                  &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"creation_date":         &hcldec.AttrSpec{Name: "creation_date", Type: cty.String, Required: false},
+		"owner":                 &hcldec.AttrSpec{Name: "owner", Type: cty.String, Required: false},
+		"owner_name":            &hcldec.AttrSpec{Name: "owner_name", Type: cty.String, Required: false},
+		"tags":                  &hcldec.AttrSpec{Name: "tags", Type: cty.Map(cty.String), Required: false},
+		"architecture":          &hcldec.AttrSpec{Name: "architecture", Type: cty.String, Required: false},
+		"boot_mode":             &hcldec.AttrSpec{Name: "boot_mode", Type: cty.String, Required: false},
+		"root_device_name":      &hcldec.AttrSpec{Name: "root_device_name", Type: cty.String, Required: false},
+		"root_device_type":      &hcldec.AttrSpec{Name: "root_device_type", Type: cty.String, Required: false},
+		"virtualization_type":   &hcldec.AttrSpec{Name: "virtualization_type", Type: cty.String, Required: false},
+		"ena_support":           &hcldec.AttrSpec{Name: "ena_support", Type: cty.Bool, Required: false},
+		"sriov_net_support":     &hcldec.AttrSpec{Name: "sriov_net_support", Type: cty.String, Required: false},
+		"tpm_support":           &hcldec.AttrSpec{Name: "tpm_support", Type: cty.String, Required: false},
+		"imds_support":          &hcldec.AttrSpec{Name: "imds_support", Type: cty.String, Required: false},
+		"platform_details":      &hcldec.AttrSpec{Name: "platform_details", Type: cty.String, Required: false},
+		"deprecation_time":      &hcldec.AttrSpec{Name: "deprecation_time", Type: cty.String, Required: false},
+		"state":                 &hcldec.AttrSpec{Name: "state", Type: cty.String, Required: false},
+		"block_device_mappings": &hcldec.BlockListSpec{TypeName: "block_device_mappings", Nested: hcldec.ObjectSpec((*FlatBlockDeviceMapping)(nil).HCL2Spec())},
 	}
 	return s
 }
@@ -6,6 +6,9 @@ package ami
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/google/go-cmp/cmp"
 	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
 )
 
@@ -46,3 +49,72 @@ func TestRunConfigPrepare_SourceAmiFilterGood(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 }
+
+func TestImageOutput(t *testing.T) {
+	image := &types.Image{
+		ImageId:            aws.String("ami-1"),
+		Name:               aws.String("web"),
+		CreationDate:       aws.String("2025-01-01T00:00:00.000Z"),
+		OwnerId:            aws.String("123456789012"),
+		Tags:               []types.Tag{{Key: aws.String("Version"), Value: aws.String("1.2.3")}},
+		Architecture:       types.ArchitectureValuesArm64,
+		BootMode:           types.BootModeValuesUefi,
+		RootDeviceName:     aws.String("/dev/xvda"),
+		RootDeviceType:     types.DeviceTypeEbs,
+		VirtualizationType: types.VirtualizationTypeHvm,
+		EnaSupport:         aws.Bool(true),
+		SriovNetSupport:    aws.String("simple"),
+		TpmSupport:         types.TpmSupportValuesV20,
+		ImdsSupport:        types.ImdsSupportValuesV20,
+		PlatformDetails:    aws.String("Linux/UNIX"),
+		DeprecationTime:    aws.String("2027-01-01T00:00:00.000Z"),
+		State:              types.ImageStateAvailable,
+		BlockDeviceMappings: []types.BlockDeviceMapping{
+			{
+				DeviceName: aws.String("/dev/xvda"),
+				Ebs: &types.EbsBlockDevice{
+					SnapshotId: aws.String("snap-1"),
+					VolumeSize: aws.Int32(8),
+					VolumeType: types.VolumeTypeGp3,
+					Encrypted:  aws.Bool(true),
+					KmsKeyId:   aws.String("arn:aws:kms:us-east-1:123456789012:key/1"),
+				},
+			},
+			{DeviceName: aws.String("/dev/sdb"), VirtualName: aws.String("ephemeral0")},
+		},
+	}
+
+	want := DatasourceOutput{
+		ID:                 "ami-1",
+		Name:               "web",
+		CreationDate:       "2025-01-01T00:00:00.000Z",
+		Owner:              "123456789012",
+		Tags:               map[string]string{"Version": "1.2.3"},
+		Architecture:       "arm64",
+		BootMode:           "uefi",
+		RootDeviceName:     "/dev/xvda",
+		RootDeviceType:     "ebs",
+		VirtualizationType: "hvm",
+		ENASupport:         true,
+		SriovNetSupport:    "simple",
+		TpmSupport:         "v2.0",
+		ImdsSupport:        "v2.0",
+		PlatformDetails:    "Linux/UNIX",
+		DeprecationTime:    "2027-01-01T00:00:00.000Z",
+		State:              "available",
+		BlockDeviceMappings: []BlockDeviceMapping{
+			{
+				DeviceName: "/dev/xvda",
+				SnapshotID: "snap-1",
+				VolumeSize: 8,
+				VolumeType: "gp3",
+				Encrypted:  true,
+				KmsKeyID:   "arn:aws:kms:us-east-1:123456789012:key/1",
+			},
+			{DeviceName: "/dev/sdb"},
+		},
+	}
+	if diff := cmp.Diff(want, ImageOutput(image)); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
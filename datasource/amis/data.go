@@ -0,0 +1,203 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Config
+package amis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-amazon/datasource/ami"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	sortByCreationDate = "creation_date"
+	sortByName         = "name"
+	sortByTagPrefix    = "tag:"
+
+	sortOrderAsc  = "asc"
+	sortOrderDesc = "desc"
+)
+
+type Datasource struct {
+	config Config
+}
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// Filters used to select the AMIs. Any filter described in the docs for
+	// [DescribeImages](http://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeImages.html)
+	// is valid.
+	Filters map[string]string `mapstructure:"filters"`
+	// Filters the images by their owner. You may specify one or more AWS
+	// account IDs, "self" (which will use the account whose credentials you
+	// are using to run Packer), or an AWS owner alias: for example, `amazon`,
+	// `aws-marketplace`, or `microsoft`. This option is required for
+	// security reasons.
+	Owners []string `mapstructure:"owners" required:"true"`
+	// Include deprecated AMIs in the filtered response. Defaults to false.
+	// If you are the AMI owner, deprecated AMIs appear in the response
+	// regardless of what is specified for `include_deprecated`.
+	IncludeDeprecated bool `mapstructure:"include_deprecated"`
+	// What to sort the images by: `creation_date`, `name`, or
+	// `tag:<key>` for the value of the tag `<key>`, such as `tag:Version`.
+	// Images without the tag come last. Defaults to `creation_date`.
+	SortBy string `mapstructure:"sort_by"`
+	// `desc` to sort the images from the newest, or the greatest name or
+	// tag value, `asc` otherwise. Defaults to `desc`.
+	SortOrder string `mapstructure:"sort_order"`
+	// The maximum number of images to return, the first ones once sorted.
+	// Defaults to 0, every image.
+	Limit int `mapstructure:"limit"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...any) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	if d.config.SortBy == "" {
+		d.config.SortBy = sortByCreationDate
+	}
+	if d.config.SortOrder == "" {
+		d.config.SortOrder = sortOrderDesc
+	}
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.AccessConfig.Prepare(&d.config.PackerConfig)...)
+
+	if len(d.config.Owners) == 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("For security reasons, you must declare an owner."))
+	}
+	switch {
+	case d.config.SortBy == sortByCreationDate, d.config.SortBy == sortByName:
+	case strings.HasPrefix(d.config.SortBy, sortByTagPrefix) && len(d.config.SortBy) > len(sortByTagPrefix):
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid sort_by %q, must be creation_date, name or tag:<key>", d.config.SortBy))
+	}
+	if d.config.SortOrder != sortOrderAsc && d.config.SortOrder != sortOrderDesc {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid sort_order %q, must be asc or desc", d.config.SortOrder))
+	}
+	if d.config.Limit < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("limit must be positive"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type DatasourceOutput struct {
+	// The IDs of the AMIs, sorted.
+	IDs []string `mapstructure:"ids"`
+	// The AMIs, sorted, with the same attributes as the output of the
+	// [amazon-ami](/packer/integrations/hashicorp/amazon/latest/components/data-source/ami)
+	// data source, such as `id`, `name`, `architecture` or
+	// `block_device_mappings`.
+	Images []ami.DatasourceOutput `mapstructure:"images"`
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx := context.TODO()
+	client, err := d.config.NewEC2Client(ctx)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := d.images(ctx, client)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// images returns the sorted and limited images matching the filters.
+func (d *Datasource) images(ctx context.Context, client clients.Ec2Client) (DatasourceOutput, error) {
+	filters := awscommon.AmiFilterOptions{
+		Filters:           d.config.Filters,
+		Owners:            d.config.Owners,
+		IncludeDeprecated: d.config.IncludeDeprecated,
+	}
+	images, err := filters.GetFilteredImages(ctx, &ec2.DescribeImagesInput{}, client)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+
+	d.sort(images)
+	if d.config.Limit > 0 && len(images) > d.config.Limit {
+		images = images[:d.config.Limit]
+	}
+
+	output := DatasourceOutput{
+		IDs:    make([]string, 0, len(images)),
+		Images: make([]ami.DatasourceOutput, 0, len(images)),
+	}
+	for i := range images {
+		output.IDs = append(output.IDs, aws.ToString(images[i].ImageId))
+		output.Images = append(output.Images, ami.ImageOutput(&images[i]))
+	}
+	return output, nil
+}
+
+// sort sorts images by sort_by in sort_order. Images with the same key are
+// sorted by ID, so that the order is stable from one build to the next.
+func (d *Datasource) sort(images []types.Image) {
+	tag := strings.TrimPrefix(d.config.SortBy, sortByTagPrefix)
+	key := func(image types.Image) (string, bool) {
+		switch d.config.SortBy {
+		case sortByCreationDate:
+			// RFC 3339 timestamps in UTC sort chronologically as strings.
+			return aws.ToString(image.CreationDate), true
+		case sortByName:
+			return aws.ToString(image.Name), true
+		}
+		for _, t := range image.Tags {
+			if aws.ToString(t.Key) == tag {
+				return aws.ToString(t.Value), true
+			}
+		}
+		return "", false
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		ki, oki := key(images[i])
+		kj, okj := key(images[j])
+		if oki != okj {
+			return oki
+		}
+		if ki != kj {
+			if d.config.SortOrder == sortOrderAsc {
+				return ki < kj
+			}
+			return ki > kj
+		}
+		return aws.ToString(images[i].ImageId) < aws.ToString(images[j].ImageId)
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amis
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/acctest"
+)
+
+//go:embed test-fixtures/template.pkr.hcl
+var testDatasourceBasic string
+
+func TestAccDatasource_AmazonAmis(t *testing.T) {
+	t.Parallel()
+	testCase := &acctest.PluginTestCase{
+		Name:     "amazon_amis_datasource_basic_test",
+		Template: testDatasourceBasic,
+		Check: func(buildCommand *exec.Cmd, logfile string) error {
+			if buildCommand.ProcessState != nil {
+				if buildCommand.ProcessState.ExitCode() != 0 {
+					return fmt.Errorf("Bad exit code. Logfile: %s", logfile)
+				}
+			}
+
+			logs, err := os.ReadFile(logfile)
+			if err != nil {
+				return fmt.Errorf("Unable to read %s", logfile)
+			}
+			if !regexp.MustCompile(`3 ami-[0-9a-f]+ ubuntu/images/`).Match(logs) {
+				return fmt.Errorf("expected the 3 newest AMIs in logs, logfile: %s", logfile)
+			}
+			return nil
+		},
+	}
+	acctest.TestPlugin(t, testCase)
+}
@@ -0,0 +1,127 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+)
+
+// mockImagesEC2 serves four images over two pages.
+type mockImagesEC2 struct {
+	clients.Ec2Client
+
+	input *ec2.DescribeImagesInput
+}
+
+func testImage(id, name, created string, tags map[string]string) types.Image {
+	image := types.Image{
+		ImageId:      aws.String(id),
+		Name:         aws.String(name),
+		CreationDate: aws.String(created),
+		OwnerId:      aws.String("123456789012"),
+	}
+	for k, v := range tags {
+		image.Tags = append(image.Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return image
+}
+
+func (m *mockImagesEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	m.input = input
+	if input.NextToken == nil {
+		return &ec2.DescribeImagesOutput{
+			Images: []types.Image{
+				testImage("ami-1", "web-b", "2025-01-02T00:00:00.000Z", map[string]string{"Version": "1.1.0"}),
+				testImage("ami-2", "web-a", "2025-01-04T00:00:00.000Z", nil),
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	return &ec2.DescribeImagesOutput{
+		Images: []types.Image{
+			testImage("ami-3", "web-d", "2025-01-01T00:00:00.000Z", map[string]string{"Version": "1.0.0"}),
+			testImage("ami-4", "web-c", "2025-01-03T00:00:00.000Z", map[string]string{"Version": "1.2.0"}),
+		},
+	}, nil
+}
+
+func TestDatasourceConfigure_Defaults(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			Owners: []string{"self"},
+		},
+	}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if datasource.config.SortBy != "creation_date" || datasource.config.SortOrder != "desc" {
+		t.Errorf("unexpected defaults %q %q", datasource.config.SortBy, datasource.config.SortOrder)
+	}
+}
+
+func TestDatasourceConfigure_Errors(t *testing.T) {
+	tests := map[string]Config{
+		"no owner":          {Filters: map[string]string{"name": "web-*"}},
+		"invalid sort_by":   {Owners: []string{"self"}, SortBy: "size"},
+		"empty tag":         {Owners: []string{"self"}, SortBy: "tag:"},
+		"invalid order":     {Owners: []string{"self"}, SortOrder: "newest"},
+		"negative limit":    {Owners: []string{"self"}, Limit: -1},
+		"tag without colon": {Owners: []string{"self"}, SortBy: "tag"},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: config}
+			if err := datasource.Configure(nil); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDatasource_images(t *testing.T) {
+	tests := map[string]struct {
+		config Config
+		want   []string
+	}{
+		"newest first":   {Config{}, []string{"ami-2", "ami-4", "ami-1", "ami-3"}},
+		"oldest first":   {Config{SortOrder: "asc"}, []string{"ami-3", "ami-1", "ami-4", "ami-2"}},
+		"by name":        {Config{SortBy: "name", SortOrder: "asc"}, []string{"ami-2", "ami-1", "ami-4", "ami-3"}},
+		"by tag":         {Config{SortBy: "tag:Version"}, []string{"ami-4", "ami-1", "ami-3", "ami-2"}},
+		"by tag, asc":    {Config{SortBy: "tag:Version", SortOrder: "asc"}, []string{"ami-3", "ami-1", "ami-4", "ami-2"}},
+		"limited":        {Config{Limit: 2}, []string{"ami-2", "ami-4"}},
+		"limit too high": {Config{Limit: 10}, []string{"ami-2", "ami-4", "ami-1", "ami-3"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.config.Owners = []string{"self"}
+			tt.config.Filters = map[string]string{"name": "web-*"}
+			datasource := Datasource{config: tt.config}
+			if err := datasource.Configure(nil); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			client := &mockImagesEC2{}
+
+			output, err := datasource.images(context.Background(), client)
+			if err != nil {
+				t.Fatalf("images() failed: %s", err)
+			}
+			if !reflect.DeepEqual(output.IDs, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, output.IDs)
+			}
+			if len(output.Images) != len(tt.want) || output.Images[0].ID != tt.want[0] {
+				t.Errorf("expected the images in the same order as the IDs, got %+v", output.Images)
+			}
+			if len(client.input.Owners) != 1 || len(client.input.Filters) != 1 {
+				t.Errorf("expected the owners and filters to be used, got %+v", client.input)
+			}
+		})
+	}
+}
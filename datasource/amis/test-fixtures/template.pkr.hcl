@@ -0,0 +1,33 @@
+# Copyright IBM Corp. 2013, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+data "amazon-amis" "test" {
+  filters = {
+    name                = "ubuntu/images/*ubuntu-jammy-22.04-amd64-server-*"
+    root-device-type    = "ebs"
+    virtualization-type = "hvm"
+  }
+  owners = ["099720109477"]
+  region = "us-west-2"
+  limit  = 3
+}
+
+locals {
+  amis = data.amazon-amis.test.images
+}
+
+source "null" "basic-example" {
+  communicator = "none"
+}
+
+build {
+  sources = [
+    "source.null.basic-example"
+  ]
+
+  provisioner "shell-local" {
+    inline = [
+      "echo ${length(local.amis)} ${local.amis[0].id} ${local.amis[0].name}",
+    ]
+  }
+}
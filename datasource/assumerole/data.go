@@ -0,0 +1,182 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Config
+package assumerole
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	minDurationSeconds     = 900
+	maxDurationSeconds     = 43200
+	defaultDurationSeconds = 3600
+)
+
+type Datasource struct {
+	config Config
+}
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The ARN of the IAM role to assume. The credentials of the data source,
+	// including its own `assume_role`, are used to assume it.
+	RoleARN string `mapstructure:"role_arn" required:"true"`
+	// The name of the session, found in CloudTrail logs and in the ARN of
+	// the assumed role. Defaults to `packer-` followed by the current Unix
+	// time.
+	SessionName string `mapstructure:"session_name"`
+	// The external ID required by the trust policy of the role, if any.
+	ExternalID string `mapstructure:"external_id"`
+	// The number of seconds the credentials are valid for, between 900 and
+	// the maximum session duration of the role. Defaults to 3600.
+	DurationSeconds int `mapstructure:"duration_seconds"`
+	// An IAM policy JSON further restricting the permissions of the session.
+	Policy string `mapstructure:"policy"`
+	// The ARNs of managed IAM policies further restricting the permissions
+	// of the session.
+	PolicyARNs []string `mapstructure:"policy_arns"`
+	// Session tags, passed to the role as principal tags.
+	Tags map[string]string `mapstructure:"tags"`
+	// The keys of the session tags to pass to sessions chained from this one.
+	TransitiveTagKeys []string `mapstructure:"transitive_tag_keys"`
+}
+
+// stsAPI is the part of the STS API used by the data source.
+type stsAPI interface {
+	AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...any) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	if d.config.SessionName == "" {
+		d.config.SessionName = fmt.Sprintf("packer-%d", time.Now().Unix())
+	}
+	if d.config.DurationSeconds == 0 {
+		d.config.DurationSeconds = defaultDurationSeconds
+	}
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.AccessConfig.Prepare(&d.config.PackerConfig)...)
+
+	if d.config.RoleARN == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("a 'role_arn' must be provided"))
+	}
+	if d.config.DurationSeconds < minDurationSeconds || d.config.DurationSeconds > maxDurationSeconds {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("duration_seconds must be between %d and %d", minDurationSeconds, maxDurationSeconds))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type DatasourceOutput struct {
+	// The access key ID of the temporary credentials.
+	AccessKeyID string `mapstructure:"access_key_id"`
+	// The secret access key of the temporary credentials. It is not marked
+	// sensitive, use it through a `sensitive` local.
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	// The session token of the temporary credentials. It is not marked
+	// sensitive, use it through a `sensitive` local.
+	SessionToken string `mapstructure:"session_token"`
+	// The date the credentials expire at, in RFC 3339 format.
+	Expiration string `mapstructure:"expiration"`
+	// The ARN of the assumed role session, such as
+	// `arn:aws:sts::123456789012:assumed-role/deploy/packer-1700000000`.
+	ARN string `mapstructure:"arn"`
+	// The unique identifier of the assumed role session.
+	AssumedRoleID string `mapstructure:"assumed_role_id"`
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx := context.TODO()
+	cfg, err := d.config.Config(ctx)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := d.assumeRole(ctx, sts.NewFromConfig(*cfg))
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// assumeRole assumes the role and returns its temporary credentials, which
+// are filtered from the logs of the plugin. Packer itself only hides them
+// when the template uses them through sensitive locals.
+func (d *Datasource) assumeRole(ctx context.Context, client stsAPI) (DatasourceOutput, error) {
+	input := &sts.AssumeRoleInput{
+		RoleArn:           aws.String(d.config.RoleARN),
+		RoleSessionName:   aws.String(d.config.SessionName),
+		DurationSeconds:   aws.Int32(int32(d.config.DurationSeconds)),
+		TransitiveTagKeys: d.config.TransitiveTagKeys,
+	}
+	if d.config.ExternalID != "" {
+		input.ExternalId = aws.String(d.config.ExternalID)
+	}
+	if d.config.Policy != "" {
+		input.Policy = aws.String(d.config.Policy)
+	}
+	for _, policyARN := range d.config.PolicyARNs {
+		input.PolicyArns = append(input.PolicyArns, types.PolicyDescriptorType{Arn: aws.String(policyARN)})
+	}
+	keys := make([]string, 0, len(d.config.Tags))
+	for k := range d.config.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(k), Value: aws.String(d.config.Tags[k])})
+	}
+
+	resp, err := client.AssumeRole(ctx, input)
+	if err != nil {
+		return DatasourceOutput{}, fmt.Errorf("error assuming role %q: %s", d.config.RoleARN, err)
+	}
+
+	output := DatasourceOutput{
+		AccessKeyID:     aws.ToString(resp.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(resp.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(resp.Credentials.SessionToken),
+		ARN:             aws.ToString(resp.AssumedRoleUser.Arn),
+		AssumedRoleID:   aws.ToString(resp.AssumedRoleUser.AssumedRoleId),
+	}
+	if resp.Credentials.Expiration != nil {
+		output.Expiration = resp.Credentials.Expiration.UTC().Format(time.RFC3339)
+	}
+	packersdk.LogSecretFilter.Set(output.SecretAccessKey, output.SessionToken)
+	return output, nil
+}
@@ -0,0 +1,49 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package assumerole
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/acctest"
+)
+
+//go:embed test-fixtures/template.pkr.hcl
+var testDatasourceBasic string
+
+func TestAccDatasource_AmazonAssumeRole(t *testing.T) {
+	roleARN := os.Getenv("TESTACC_AWS_ASSUME_ROLE_ARN")
+	if roleARN == "" {
+		t.Skip("TESTACC_AWS_ASSUME_ROLE_ARN must be set to a role the test can assume")
+	}
+
+	t.Parallel()
+	testCase := &acctest.PluginTestCase{
+		Name:           "amazon_assume_role_datasource_basic_test",
+		Template:       testDatasourceBasic,
+		BuildExtraArgs: []string{"-var", "role_arn=" + roleARN},
+		Check: func(buildCommand *exec.Cmd, logfile string) error {
+			if buildCommand.ProcessState != nil {
+				if buildCommand.ProcessState.ExitCode() != 0 {
+					return fmt.Errorf("Bad exit code. Logfile: %s", logfile)
+				}
+			}
+
+			logs, err := os.ReadFile(logfile)
+			if err != nil {
+				return fmt.Errorf("Unable to read %s", logfile)
+			}
+			if !regexp.MustCompile(`assumed arn:aws:sts::\d{12}:assumed-role/.+/packer-acc-test`).Match(logs) {
+				return fmt.Errorf("expected the assumed role session in logs, logfile: %s", logfile)
+			}
+			return nil
+		},
+	}
+	acctest.TestPlugin(t, testCase)
+}
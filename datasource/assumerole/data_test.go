@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package assumerole
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+type mockSTS struct {
+	input *sts.AssumeRoleInput
+}
+
+func (m *mockSTS) AssumeRole(ctx context.Context, input *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.input = input
+	return &sts.AssumeRoleOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("ASIAEXAMPLE"),
+			SecretAccessKey: aws.String("assumed-secret-access-key"),
+			SessionToken:    aws.String("assumed-session-token"),
+			Expiration:      aws.Time(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+		},
+		AssumedRoleUser: &types.AssumedRoleUser{
+			Arn:           aws.String("arn:aws:sts::123456789012:assumed-role/deploy/packer"),
+			AssumedRoleId: aws.String("AROAEXAMPLE:packer"),
+		},
+	}, nil
+}
+
+func TestDatasourceConfigure_Defaults(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			RoleARN: "arn:aws:iam::123456789012:role/deploy",
+		},
+	}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.HasPrefix(datasource.config.SessionName, "packer-") || datasource.config.DurationSeconds != 3600 {
+		t.Errorf("unexpected defaults %q %d", datasource.config.SessionName, datasource.config.DurationSeconds)
+	}
+}
+
+func TestDatasourceConfigure_Errors(t *testing.T) {
+	tests := map[string]Config{
+		"no role":        {},
+		"short duration": {RoleARN: "arn:aws:iam::123456789012:role/deploy", DurationSeconds: 60},
+		"long duration":  {RoleARN: "arn:aws:iam::123456789012:role/deploy", DurationSeconds: 86400},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: config}
+			if err := datasource.Configure(nil); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDatasource_assumeRole(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			RoleARN:           "arn:aws:iam::123456789012:role/deploy",
+			SessionName:       "packer",
+			ExternalID:        "external",
+			DurationSeconds:   900,
+			PolicyARNs:        []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+			Tags:              map[string]string{"Team": "build", "Env": "prod"},
+			TransitiveTagKeys: []string{"Team"},
+		},
+	}
+	client := &mockSTS{}
+	output, err := datasource.assumeRole(context.Background(), client)
+	if err != nil {
+		t.Fatalf("assumeRole() failed: %s", err)
+	}
+
+	want := DatasourceOutput{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "assumed-secret-access-key",
+		SessionToken:    "assumed-session-token",
+		Expiration:      "2025-01-02T03:04:05Z",
+		ARN:             "arn:aws:sts::123456789012:assumed-role/deploy/packer",
+		AssumedRoleID:   "AROAEXAMPLE:packer",
+	}
+	if output != want {
+		t.Errorf("expected %+v, got %+v", want, output)
+	}
+
+	input := client.input
+	if aws.ToString(input.ExternalId) != "external" || aws.ToInt32(input.DurationSeconds) != 900 || input.Policy != nil {
+		t.Errorf("unexpected input %+v", input)
+	}
+	if len(input.Tags) != 2 || aws.ToString(input.Tags[0].Key) != "Env" || len(input.PolicyArns) != 1 {
+		t.Errorf("expected sorted tags and the policy ARNs, got %+v", input)
+	}
+
+	filtered := packersdk.LogSecretFilter.FilterString("token: assumed-session-token")
+	if strings.Contains(filtered, "assumed-session-token") {
+		t.Errorf("expected the session token to be filtered from the logs, got %q", filtered)
+	}
+}
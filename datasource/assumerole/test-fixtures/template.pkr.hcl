@@ -0,0 +1,33 @@
+# Copyright IBM Corp. 2013, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+variable "role_arn" {
+  type = string
+}
+
+data "amazon-assume-role" "test" {
+  role_arn         = var.role_arn
+  session_name     = "packer-acc-test"
+  duration_seconds = 900
+}
+
+source "null" "basic-example" {
+  communicator = "none"
+}
+
+build {
+  sources = [
+    "source.null.basic-example"
+  ]
+
+  provisioner "shell-local" {
+    environment_vars = [
+      "AWS_ACCESS_KEY_ID=${data.amazon-assume-role.test.access_key_id}",
+      "AWS_SECRET_ACCESS_KEY=${data.amazon-assume-role.test.secret_access_key}",
+      "AWS_SESSION_TOKEN=${data.amazon-assume-role.test.session_token}",
+    ]
+    inline = [
+      "echo assumed ${data.amazon-assume-role.test.arn}",
+    ]
+  }
+}
@@ -0,0 +1,131 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Config
+package calleridentity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/endpoints"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type Datasource struct {
+	config Config
+}
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+}
+
+// stsAPI is the part of the STS API used by the data source.
+type stsAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...any) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.AccessConfig.Prepare(&d.config.PackerConfig)...)
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type DatasourceOutput struct {
+	// The ID of the AWS account of the credentials.
+	AccountID string `mapstructure:"account_id"`
+	// The ARN of the identity of the credentials, such as
+	// `arn:aws:sts::123456789012:assumed-role/packer/session`.
+	ARN string `mapstructure:"arn"`
+	// The unique identifier of the identity of the credentials.
+	UserID string `mapstructure:"user_id"`
+	// The partition of the account, such as `aws`, `aws-cn` or `aws-us-gov`,
+	// to build ARNs like `arn:${partition}:kms:...`.
+	Partition string `mapstructure:"partition"`
+	// The region of the data source.
+	Region string `mapstructure:"region"`
+	// The DNS suffix of the partition, such as `amazonaws.com` or
+	// `amazonaws.com.cn`, to build endpoints or bucket domain names.
+	DNSSuffix string `mapstructure:"dns_suffix"`
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx := context.TODO()
+	cfg, err := d.config.Config(ctx)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := callerIdentity(ctx, sts.NewFromConfig(*cfg), cfg.Region)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// callerIdentity returns the identity of the credentials of client, and the
+// partition they belong to.
+func callerIdentity(ctx context.Context, client stsAPI, region string) (DatasourceOutput, error) {
+	identity, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return DatasourceOutput{}, fmt.Errorf("error getting caller identity: %s", err)
+	}
+
+	output := DatasourceOutput{
+		AccountID: aws.ToString(identity.Account),
+		ARN:       aws.ToString(identity.Arn),
+		UserID:    aws.ToString(identity.UserId),
+		Region:    region,
+	}
+
+	identityARN, err := arn.Parse(output.ARN)
+	if err != nil {
+		return output, fmt.Errorf("error parsing caller identity ARN %q: %s", output.ARN, err)
+	}
+	output.Partition = identityARN.Partition
+	output.DNSSuffix = dnsSuffix(output.Partition, region)
+	return output, nil
+}
+
+// dnsSuffix returns the DNS suffix of partition, or of the partition of
+// region when partition is unknown.
+func dnsSuffix(partition, region string) string {
+	partitions := endpoints.DefaultPartitions()
+	for _, p := range partitions {
+		if p.ID() == partition {
+			return p.DNSSuffix()
+		}
+	}
+	if p, ok := endpoints.PartitionForRegion(partitions, region); ok {
+		return p.DNSSuffix()
+	}
+	return "amazonaws.com"
+}
@@ -0,0 +1,43 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package calleridentity
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/acctest"
+)
+
+//go:embed test-fixtures/template.pkr.hcl
+var testDatasourceBasic string
+
+func TestAccDatasource_AmazonCallerIdentity(t *testing.T) {
+	t.Parallel()
+	testCase := &acctest.PluginTestCase{
+		Name:     "amazon_caller_identity_datasource_basic_test",
+		Template: testDatasourceBasic,
+		Check: func(buildCommand *exec.Cmd, logfile string) error {
+			if buildCommand.ProcessState != nil {
+				if buildCommand.ProcessState.ExitCode() != 0 {
+					return fmt.Errorf("Bad exit code. Logfile: %s", logfile)
+				}
+			}
+
+			logs, err := os.ReadFile(logfile)
+			if err != nil {
+				return fmt.Errorf("Unable to read %s", logfile)
+			}
+			if !regexp.MustCompile(`account \d{12} in aws us-west-2 amazonaws.com`).Match(logs) {
+				return fmt.Errorf("expected the caller identity in logs, logfile: %s", logfile)
+			}
+			return nil
+		},
+	}
+	acctest.TestPlugin(t, testCase)
+}
@@ -0,0 +1,63 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package calleridentity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+type mockSTS struct {
+	arn string
+}
+
+func (m *mockSTS) GetCallerIdentity(ctx context.Context, input *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String("123456789012"),
+		Arn:     aws.String(m.arn),
+		UserId:  aws.String("AROAEXAMPLE:packer"),
+	}, nil
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	datasource := Datasource{}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestCallerIdentity(t *testing.T) {
+	tests := map[string]struct {
+		arn       string
+		region    string
+		partition string
+		dnsSuffix string
+	}{
+		"commercial": {"arn:aws:sts::123456789012:assumed-role/packer/packer", "us-east-1", "aws", "amazonaws.com"},
+		"china":      {"arn:aws-cn:iam::123456789012:user/packer", "cn-north-1", "aws-cn", "amazonaws.com.cn"},
+		"govcloud":   {"arn:aws-us-gov:iam::123456789012:user/packer", "us-gov-west-1", "aws-us-gov", "amazonaws.com"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := callerIdentity(context.Background(), &mockSTS{arn: tt.arn}, tt.region)
+			if err != nil {
+				t.Fatalf("callerIdentity() failed: %s", err)
+			}
+			want := DatasourceOutput{
+				AccountID: "123456789012",
+				ARN:       tt.arn,
+				UserID:    "AROAEXAMPLE:packer",
+				Partition: tt.partition,
+				Region:    tt.region,
+				DNSSuffix: tt.dnsSuffix,
+			}
+			if output != want {
+				t.Errorf("expected %+v, got %+v", want, output)
+			}
+		})
+	}
+}
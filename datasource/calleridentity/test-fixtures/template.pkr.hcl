@@ -0,0 +1,26 @@
+# Copyright IBM Corp. 2013, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+data "amazon-caller-identity" "test" {
+  region = "us-west-2"
+}
+
+locals {
+  identity = data.amazon-caller-identity.test
+}
+
+source "null" "basic-example" {
+  communicator = "none"
+}
+
+build {
+  sources = [
+    "source.null.basic-example"
+  ]
+
+  provisioner "shell-local" {
+    inline = [
+      "echo account ${local.identity.account_id} in ${local.identity.partition} ${local.identity.region} ${local.identity.dns_suffix}",
+    ]
+  }
+}
@@ -0,0 +1,398 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,InstanceType,Config
+package instancetypes
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	sortByVCPUs  = "vcpus"
+	sortByMemory = "memory"
+	sortByName   = "name"
+
+	sortOrderAsc  = "asc"
+	sortOrderDesc = "desc"
+
+	// offeringsBatchSize is the number of instance types whose offerings
+	// are described at once.
+	offeringsBatchSize = 100
+)
+
+type Datasource struct {
+	config Config
+}
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The instance types to choose from, by name or wildcard, such as
+	// `c7g.*` or `m*.large`. Defaults to every instance type of the region.
+	InstanceTypes []string `mapstructure:"instance_types"`
+	// The instance types to exclude, by name or wildcard, such as `*.metal`
+	// or `t*`.
+	ExcludedInstanceTypes []string `mapstructure:"excluded_instance_types"`
+	// The processor architectures, one of which must be supported, such as
+	// `x86_64` or `arm64`.
+	Architectures []string `mapstructure:"architectures"`
+	// The minimum number of vCPUs.
+	MinVCPUs int `mapstructure:"min_vcpus"`
+	// The maximum number of vCPUs. Defaults to 0, no maximum.
+	MaxVCPUs int `mapstructure:"max_vcpus"`
+	// The minimum memory, in MiB, such as `8192` for 8 GiB.
+	MinMemoryMiB int64 `mapstructure:"min_memory_mib"`
+	// The maximum memory, in MiB. Defaults to 0, no maximum.
+	MaxMemoryMiB int64 `mapstructure:"max_memory_mib"`
+	// Only return current generation instance types. Defaults to false.
+	CurrentGeneration bool `mapstructure:"current_generation"`
+	// Only return instance types supporting ENA, as required by the
+	// `ena_support` option of the builders. Defaults to false.
+	EnaSupport bool `mapstructure:"ena_support"`
+	// Only return instance types exposing EBS volumes as NVMe devices.
+	// Defaults to false.
+	NvmeSupport bool `mapstructure:"nvme_support"`
+	// Only return instance types supporting this boot mode, `uefi` or
+	// `legacy-bios`, to match the `boot_mode` of the AMI.
+	BootMode string `mapstructure:"boot_mode"`
+	// Only return instance types offered in every one of these availability
+	// zones, such as `us-east-1a`. Defaults to instance types offered in at
+	// least one availability zone of the region.
+	AvailabilityZones []string `mapstructure:"availability_zones"`
+	// What to sort the instance types by: `vcpus` for the number of vCPUs,
+	// then the memory, `memory` for the memory, then the number of vCPUs,
+	// or `name`. Instance types with the same key are sorted by name.
+	// Defaults to `vcpus`.
+	SortBy string `mapstructure:"sort_by"`
+	// `asc` to sort the instance types from the smallest, or the first
+	// name, `desc` otherwise. Defaults to `asc`.
+	SortOrder string `mapstructure:"sort_order"`
+	// The maximum number of instance types to return, the first ones once
+	// sorted. Defaults to 0, every instance type.
+	Limit int `mapstructure:"limit"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...any) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	if d.config.SortBy == "" {
+		d.config.SortBy = sortByVCPUs
+	}
+	if d.config.SortOrder == "" {
+		d.config.SortOrder = sortOrderAsc
+	}
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.AccessConfig.Prepare(&d.config.PackerConfig)...)
+
+	if d.config.MinVCPUs < 0 || d.config.MaxVCPUs < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("min_vcpus and max_vcpus must be positive"))
+	}
+	if d.config.MaxVCPUs > 0 && d.config.MaxVCPUs < d.config.MinVCPUs {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("max_vcpus must be greater than min_vcpus"))
+	}
+	if d.config.MinMemoryMiB < 0 || d.config.MaxMemoryMiB < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("min_memory_mib and max_memory_mib must be positive"))
+	}
+	if d.config.MaxMemoryMiB > 0 && d.config.MaxMemoryMiB < d.config.MinMemoryMiB {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("max_memory_mib must be greater than min_memory_mib"))
+	}
+	for _, pattern := range d.config.ExcludedInstanceTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid excluded_instance_types pattern %q: %s", pattern, err))
+		}
+	}
+	switch d.config.BootMode {
+	case "", string(types.BootModeTypeUefi), string(types.BootModeTypeLegacyBios):
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid boot_mode %q, must be uefi or legacy-bios", d.config.BootMode))
+	}
+	switch d.config.SortBy {
+	case sortByVCPUs, sortByMemory, sortByName:
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid sort_by %q, must be vcpus, memory or name", d.config.SortBy))
+	}
+	if d.config.SortOrder != sortOrderAsc && d.config.SortOrder != sortOrderDesc {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid sort_order %q, must be asc or desc", d.config.SortOrder))
+	}
+	if d.config.Limit < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("limit must be positive"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type InstanceType struct {
+	// The name of the instance type, such as `c7g.large`.
+	Name string `mapstructure:"name"`
+	// The processor architectures supported, such as `x86_64` or `arm64`.
+	Architectures []string `mapstructure:"architectures"`
+	// The boot modes supported, `uefi` and/or `legacy-bios`.
+	BootModes []string `mapstructure:"boot_modes"`
+	// The default number of vCPUs.
+	VCPUs int64 `mapstructure:"vcpus"`
+	// The memory, in MiB.
+	MemoryMiB int64 `mapstructure:"memory_mib"`
+	// The network performance, such as `Up to 12.5 Gigabit`.
+	NetworkPerformance string `mapstructure:"network_performance"`
+	// Whether ENA is `supported`, `required` or `unsupported`.
+	EnaSupport string `mapstructure:"ena_support"`
+	// Whether EBS volumes are exposed as NVMe devices: `supported`,
+	// `required` or `unsupported`.
+	NvmeSupport string `mapstructure:"nvme_support"`
+	// Whether the instance type is of the current generation.
+	CurrentGeneration bool `mapstructure:"current_generation"`
+	// The availability zones offering the instance type, sorted.
+	AvailabilityZones []string `mapstructure:"availability_zones"`
+}
+
+type DatasourceOutput struct {
+	// The names of the instance types, sorted.
+	Names []string `mapstructure:"names"`
+	// The instance types, sorted. See [Instance Types](#instance-types).
+	InstanceTypes []InstanceType `mapstructure:"instance_types"`
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx := context.TODO()
+	client, err := d.config.NewEC2Client(ctx)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := d.instanceTypes(ctx, client)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// instanceTypes returns the sorted and limited instance types matching the
+// requirements, offered in the availability zones.
+func (d *Datasource) instanceTypes(ctx context.Context, client clients.Ec2Client) (DatasourceOutput, error) {
+	var candidates []InstanceType
+	paginator := ec2.NewDescribeInstanceTypesPaginator(client, &ec2.DescribeInstanceTypesInput{Filters: d.filters()})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return DatasourceOutput{}, fmt.Errorf("error describing instance types: %s", err)
+		}
+		for _, info := range page.InstanceTypes {
+			if d.matches(info) {
+				candidates = append(candidates, instanceTypeOutput(info))
+			}
+		}
+	}
+
+	offerings, err := d.offerings(ctx, client, candidates)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+	instanceTypes := make([]InstanceType, 0, len(candidates))
+	for _, instanceType := range candidates {
+		azs := offerings[instanceType.Name]
+		if len(azs) == 0 || len(azs) < len(d.config.AvailabilityZones) {
+			continue
+		}
+		sort.Strings(azs)
+		instanceType.AvailabilityZones = azs
+		instanceTypes = append(instanceTypes, instanceType)
+	}
+
+	d.sort(instanceTypes)
+	if d.config.Limit > 0 && len(instanceTypes) > d.config.Limit {
+		instanceTypes = instanceTypes[:d.config.Limit]
+	}
+
+	output := DatasourceOutput{
+		Names:         make([]string, 0, len(instanceTypes)),
+		InstanceTypes: instanceTypes,
+	}
+	for _, instanceType := range instanceTypes {
+		output.Names = append(output.Names, instanceType.Name)
+	}
+	return output, nil
+}
+
+// filters returns the DescribeInstanceTypes filters of the requirements
+// EC2 can filter on.
+func (d *Datasource) filters() []types.Filter {
+	var filters []types.Filter
+	add := func(name string, values ...string) {
+		filters = append(filters, types.Filter{Name: aws.String(name), Values: values})
+	}
+	if len(d.config.InstanceTypes) > 0 {
+		add("instance-type", d.config.InstanceTypes...)
+	}
+	if len(d.config.Architectures) > 0 {
+		add("processor-info.supported-architecture", d.config.Architectures...)
+	}
+	if d.config.CurrentGeneration {
+		add("current-generation", "true")
+	}
+	if d.config.EnaSupport {
+		add("network-info.ena-support", string(types.EnaSupportSupported), string(types.EnaSupportRequired))
+	}
+	if d.config.NvmeSupport {
+		add("ebs-info.nvme-support", string(types.EbsNvmeSupportSupported), string(types.EbsNvmeSupportRequired))
+	}
+	if d.config.BootMode != "" {
+		add("supported-boot-mode", d.config.BootMode)
+	}
+	return filters
+}
+
+// matches reports whether info is within the vCPU and memory ranges, and is
+// not excluded.
+func (d *Datasource) matches(info types.InstanceTypeInfo) bool {
+	var vcpus int32
+	if info.VCpuInfo != nil {
+		vcpus = aws.ToInt32(info.VCpuInfo.DefaultVCpus)
+	}
+	if int(vcpus) < d.config.MinVCPUs || (d.config.MaxVCPUs > 0 && int(vcpus) > d.config.MaxVCPUs) {
+		return false
+	}
+	var memory int64
+	if info.MemoryInfo != nil {
+		memory = aws.ToInt64(info.MemoryInfo.SizeInMiB)
+	}
+	if memory < d.config.MinMemoryMiB || (d.config.MaxMemoryMiB > 0 && memory > d.config.MaxMemoryMiB) {
+		return false
+	}
+	for _, pattern := range d.config.ExcludedInstanceTypes {
+		if excluded, _ := path.Match(pattern, string(info.InstanceType)); excluded {
+			return false
+		}
+	}
+	return true
+}
+
+// offerings returns the availability zones offering each instance type, of
+// availability_zones when set.
+func (d *Datasource) offerings(ctx context.Context, client clients.Ec2Client, instanceTypes []InstanceType) (map[string][]string, error) {
+	offerings := map[string][]string{}
+	for start := 0; start < len(instanceTypes); start += offeringsBatchSize {
+		end := min(start+offeringsBatchSize, len(instanceTypes))
+		names := make([]string, 0, end-start)
+		for _, instanceType := range instanceTypes[start:end] {
+			names = append(names, instanceType.Name)
+		}
+
+		input := &ec2.DescribeInstanceTypeOfferingsInput{
+			LocationType: types.LocationTypeAvailabilityZone,
+			Filters:      []types.Filter{{Name: aws.String("instance-type"), Values: names}},
+		}
+		if len(d.config.AvailabilityZones) > 0 {
+			input.Filters = append(input.Filters, types.Filter{Name: aws.String("location"), Values: d.config.AvailabilityZones})
+		}
+		paginator := ec2.NewDescribeInstanceTypeOfferingsPaginator(client, input)
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error describing instance type offerings: %s", err)
+			}
+			for _, offering := range page.InstanceTypeOfferings {
+				name := string(offering.InstanceType)
+				offerings[name] = append(offerings[name], aws.ToString(offering.Location))
+			}
+		}
+	}
+	return offerings, nil
+}
+
+// sort sorts instance types by sort_by in sort_order. Instance types with
+// the same key are sorted by name, so that the order is stable from one
+// build to the next.
+func (d *Datasource) sort(instanceTypes []InstanceType) {
+	compare := func(a, b InstanceType) int {
+		switch d.config.SortBy {
+		case sortByVCPUs:
+			if a.VCPUs != b.VCPUs {
+				return cmp.Compare(a.VCPUs, b.VCPUs)
+			}
+			return cmp.Compare(a.MemoryMiB, b.MemoryMiB)
+		case sortByMemory:
+			if a.MemoryMiB != b.MemoryMiB {
+				return cmp.Compare(a.MemoryMiB, b.MemoryMiB)
+			}
+			return cmp.Compare(a.VCPUs, b.VCPUs)
+		}
+		return 0
+	}
+
+	sort.SliceStable(instanceTypes, func(i, j int) bool {
+		c := compare(instanceTypes[i], instanceTypes[j])
+		if c == 0 {
+			c = cmp.Compare(instanceTypes[i].Name, instanceTypes[j].Name)
+		}
+		if d.config.SortOrder == sortOrderDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func instanceTypeOutput(info types.InstanceTypeInfo) InstanceType {
+	instanceType := InstanceType{
+		Name:              string(info.InstanceType),
+		Architectures:     []string{},
+		BootModes:         []string{},
+		CurrentGeneration: aws.ToBool(info.CurrentGeneration),
+		AvailabilityZones: []string{},
+	}
+	if info.ProcessorInfo != nil {
+		for _, architecture := range info.ProcessorInfo.SupportedArchitectures {
+			instanceType.Architectures = append(instanceType.Architectures, string(architecture))
+		}
+	}
+	for _, bootMode := range info.SupportedBootModes {
+		instanceType.BootModes = append(instanceType.BootModes, string(bootMode))
+	}
+	if info.VCpuInfo != nil {
+		instanceType.VCPUs = int64(aws.ToInt32(info.VCpuInfo.DefaultVCpus))
+	}
+	if info.MemoryInfo != nil {
+		instanceType.MemoryMiB = aws.ToInt64(info.MemoryInfo.SizeInMiB)
+	}
+	if info.NetworkInfo != nil {
+		instanceType.NetworkPerformance = aws.ToString(info.NetworkInfo.NetworkPerformance)
+		instanceType.EnaSupport = string(info.NetworkInfo.EnaSupport)
+	}
+	if info.EbsInfo != nil {
+		instanceType.NvmeSupport = string(info.EbsInfo.NvmeSupport)
+	}
+	return instanceType
+}
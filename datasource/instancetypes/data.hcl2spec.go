@@ -0,0 +1,174 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package instancetypes
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	PackerBuildName       *string                           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType     *string                           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion     *string                           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug           *bool                             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce           *bool                             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError         *string                           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars        map[string]string                 `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars   []string                          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	AccessKey             *string                           `mapstructure:"access_key" required:"true" cty:"access_key" hcl:"access_key"`
+	AssumeRole            *common.FlatAssumeRoleConfig      `mapstructure:"assume_role" required:"false" cty:"assume_role" hcl:"assume_role"`
+	CustomEndpointEc2     *string                           `mapstructure:"custom_endpoint_ec2" required:"false" cty:"custom_endpoint_ec2" hcl:"custom_endpoint_ec2"`
+	CredsFilename         *string                           `mapstructure:"shared_credentials_file" required:"false" cty:"shared_credentials_file" hcl:"shared_credentials_file"`
+	DecodeAuthZMessages   *bool                             `mapstructure:"decode_authorization_messages" required:"false" cty:"decode_authorization_messages" hcl:"decode_authorization_messages"`
+	InsecureSkipTLSVerify *bool                             `mapstructure:"insecure_skip_tls_verify" required:"false" cty:"insecure_skip_tls_verify" hcl:"insecure_skip_tls_verify"`
+	MaxRetries            *int                              `mapstructure:"max_retries" required:"false" cty:"max_retries" hcl:"max_retries"`
+	MFACode               *string                           `mapstructure:"mfa_code" required:"false" cty:"mfa_code" hcl:"mfa_code"`
+	ProfileName           *string                           `mapstructure:"profile" required:"false" cty:"profile" hcl:"profile"`
+	RawRegion             *string                           `mapstructure:"region" required:"true" cty:"region" hcl:"region"`
+	SecretKey             *string                           `mapstructure:"secret_key" required:"true" cty:"secret_key" hcl:"secret_key"`
+	SkipMetadataApiCheck  *bool                             `mapstructure:"skip_metadata_api_check" cty:"skip_metadata_api_check" hcl:"skip_metadata_api_check"`
+	SkipCredsValidation   *bool                             `mapstructure:"skip_credential_validation" cty:"skip_credential_validation" hcl:"skip_credential_validation"`
+	Token                 *string                           `mapstructure:"token" required:"false" cty:"token" hcl:"token"`
+	VaultAWSEngine        *common.FlatVaultAWSEngineOptions `mapstructure:"vault_aws_engine" required:"false" cty:"vault_aws_engine" hcl:"vault_aws_engine"`
+	PollingConfig         *common.FlatAWSPollingConfig      `mapstructure:"aws_polling" required:"false" cty:"aws_polling" hcl:"aws_polling"`
+	InstanceTypes         []string                          `mapstructure:"instance_types" cty:"instance_types" hcl:"instance_types"`
+	ExcludedInstanceTypes []string                          `mapstructure:"excluded_instance_types" cty:"excluded_instance_types" hcl:"excluded_instance_types"`
+	Architectures         []string                          `mapstructure:"architectures" cty:"architectures" hcl:"architectures"`
+	MinVCPUs              *int                              `mapstructure:"min_vcpus" cty:"min_vcpus" hcl:"min_vcpus"`
+	MaxVCPUs              *int                              `mapstructure:"max_vcpus" cty:"max_vcpus" hcl:"max_vcpus"`
+	MinMemoryMiB          *int64                            `mapstructure:"min_memory_mib" cty:"min_memory_mib" hcl:"min_memory_mib"`
+	MaxMemoryMiB          *int64                            `mapstructure:"max_memory_mib" cty:"max_memory_mib" hcl:"max_memory_mib"`
+	CurrentGeneration     *bool                             `mapstructure:"current_generation" cty:"current_generation" hcl:"current_generation"`
+	EnaSupport            *bool                             `mapstructure:"ena_support" cty:"ena_support" hcl:"ena_support"`
+	NvmeSupport           *bool                             `mapstructure:"nvme_support" cty:"nvme_support" hcl:"nvme_support"`
+	BootMode              *string                           `mapstructure:"boot_mode" cty:"boot_mode" hcl:"boot_mode"`
+	AvailabilityZones     []string                          `mapstructure:"availability_zones" cty:"availability_zones" hcl:"availability_zones"`
+	SortBy                *string                           `mapstructure:"sort_by" cty:"sort_by" hcl:"sort_by"`
+	SortOrder             *string                           `mapstructure:"sort_order" cty:"sort_order" hcl:"sort_order"`
+	Limit                 *int                              `mapstructure:"limit" cty:"limit" hcl:"limit"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"packer_build_name":             &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":           &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":           &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                  &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                  &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":               &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":         &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":    &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"access_key":                    &hcldec.AttrSpec{Name: "access_key", Type: cty.String, Required: false},
+		"assume_role":                   &hcldec.BlockSpec{TypeName: "assume_role", Nested: hcldec.ObjectSpec((*common.FlatAssumeRoleConfig)(nil).HCL2Spec())},
+		"custom_endpoint_ec2":           &hcldec.AttrSpec{Name: "custom_endpoint_ec2", Type: cty.String, Required: false},
+		"shared_credentials_file":       &hcldec.AttrSpec{Name: "shared_credentials_file", Type: cty.String, Required: false},
+		"decode_authorization_messages": &hcldec.AttrSpec{Name: "decode_authorization_messages", Type: cty.Bool, Required: false},
+		"insecure_skip_tls_verify":      &hcldec.AttrSpec{Name: "insecure_skip_tls_verify", Type: cty.Bool, Required: false},
+		"max_retries":                   &hcldec.AttrSpec{Name: "max_retries", Type: cty.Number, Required: false},
+		"mfa_code":                      &hcldec.AttrSpec{Name: "mfa_code", Type: cty.String, Required: false},
+		"profile":                       &hcldec.AttrSpec{Name: "profile", Type: cty.String, Required: false},
+		"region":                        &hcldec.AttrSpec{Name: "region", Type: cty.String, Required: false},
+		"secret_key":                    &hcldec.AttrSpec{Name: "secret_key", Type: cty.String, Required: false},
+		"skip_metadata_api_check":       &hcldec.AttrSpec{Name: "skip_metadata_api_check", Type: cty.Bool, Required: false},
+		"skip_credential_validation":    &hcldec.AttrSpec{Name: "skip_credential_validation", Type: cty.Bool, Required: false},
+		"token":                         &hcldec.AttrSpec{Name: "token", Type: cty.String, Required: false},
+		"vault_aws_engine":              &hcldec.BlockSpec{TypeName: "vault_aws_engine", Nested: hcldec.ObjectSpec((*common.FlatVaultAWSEngineOptions)(nil).HCL2Spec())},
+		"aws_polling":                   &hcldec.BlockSpec{TypeName: "aws_polling", Nested: hcldec.ObjectSpec((*common.FlatAWSPollingConfig)(nil).HCL2Spec())},
+		"instance_types":                &hcldec.AttrSpec{Name: "instance_types", Type: cty.List(cty.String), Required: false},
+		"excluded_instance_types":       &hcldec.AttrSpec{Name: "excluded_instance_types", Type: cty.List(cty.String), Required: false},
+		"architectures":                 &hcldec.AttrSpec{Name: "architectures", Type: cty.List(cty.String), Required: false},
+		"min_vcpus":                     &hcldec.AttrSpec{Name: "min_vcpus", Type: cty.Number, Required: false},
+		"max_vcpus":                     &hcldec.AttrSpec{Name: "max_vcpus", Type: cty.Number, Required: false},
+		"min_memory_mib":                &hcldec.AttrSpec{Name: "min_memory_mib", Type: cty.Number, Required: false},
+		"max_memory_mib":                &hcldec.AttrSpec{Name: "max_memory_mib", Type: cty.Number, Required: false},
+		"current_generation":            &hcldec.AttrSpec{Name: "current_generation", Type: cty.Bool, Required: false},
+		"ena_support":                   &hcldec.AttrSpec{Name: "ena_support", Type: cty.Bool, Required: false},
+		"nvme_support":                  &hcldec.AttrSpec{Name: "nvme_support", Type: cty.Bool, Required: false},
+		"boot_mode":                     &hcldec.AttrSpec{Name: "boot_mode", Type: cty.String, Required: false},
+		"availability_zones":            &hcldec.AttrSpec{Name: "availability_zones", Type: cty.List(cty.String), Required: false},
+		"sort_by":                       &hcldec.AttrSpec{Name: "sort_by", Type: cty.String, Required: false},
+		"sort_order":                    &hcldec.AttrSpec{Name: "sort_order", Type: cty.String, Required: false},
+		"limit":                         &hcldec.AttrSpec{Name: "limit", Type: cty.Number, Required: false},
+	}
+	return s
+}
+
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDatasourceOutput struct {
+	Names         []string           `mapstructure:"names" cty:"names" hcl:"names"`
+	InstanceTypes []FlatInstanceType `mapstructure:"instance_types" cty:"instance_types" hcl:"instance_types"`
+}
+
+// FlatMapstructure returns a new FlatDatasourceOutput.
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDatasourceOutput)
+}
+
+// HCL2Spec returns the hcl spec of a DatasourceOutput.
+// This spec is used by HCL to read the fields of DatasourceOutput.
+// The decoded values from this spec will then be applied to a FlatDatasourceOutput.
+func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"names":          &hcldec.AttrSpec{Name: "names", Type: cty.List(cty.String), Required: false},
+		"instance_types": &hcldec.BlockListSpec{TypeName: "instance_types", Nested: hcldec.ObjectSpec((*FlatInstanceType)(nil).HCL2Spec())},
+	}
+	return s
+}
+
+// FlatInstanceType is an auto-generated flat version of InstanceType.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatInstanceType struct {
+	Name               *string  `mapstructure:"name" cty:"name" hcl:"name"`
+	Architectures      []string `mapstructure:"architectures" cty:"architectures" hcl:"architectures"`
+	BootModes          []string `mapstructure:"boot_modes" cty:"boot_modes" hcl:"boot_modes"`
+	VCPUs              *int64   `mapstructure:"vcpus" cty:"vcpus" hcl:"vcpus"`
+	MemoryMiB          *int64   `mapstructure:"memory_mib" cty:"memory_mib" hcl:"memory_mib"`
+	NetworkPerformance *string  `mapstructure:"network_performance" cty:"network_performance" hcl:"network_performance"`
+	EnaSupport         *string  `mapstructure:"ena_support" cty:"ena_support" hcl:"ena_support"`
+	NvmeSupport        *string  `mapstructure:"nvme_support" cty:"nvme_support" hcl:"nvme_support"`
+	CurrentGeneration  *bool    `mapstructure:"current_generation" cty:"current_generation" hcl:"current_generation"`
+	AvailabilityZones  []string `mapstructure:"availability_zones" cty:"availability_zones" hcl:"availability_zones"`
+}
+
+// FlatMapstructure returns a new FlatInstanceType.
+// FlatInstanceType is an auto-generated flat version of InstanceType.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*InstanceType) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatInstanceType)
+}
+
+// HCL2Spec returns the hcl spec of a InstanceType.
+// This spec is used by HCL to read the fields of InstanceType.
+// The decoded values from this spec will then be applied to a FlatInstanceType.
+func (*FlatInstanceType) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":                &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"architectures":       &hcldec.AttrSpec{Name: "architectures", Type: cty.List(cty.String), Required: false},
+		"boot_modes":          &hcldec.AttrSpec{Name: "boot_modes", Type: cty.List(cty.String), Required: false},
+		"vcpus":               &hcldec.AttrSpec{Name: "vcpus", Type: cty.Number, Required: false},
+		"memory_mib":          &hcldec.AttrSpec{Name: "memory_mib", Type: cty.Number, Required: false},
+		"network_performance": &hcldec.AttrSpec{Name: "network_performance", Type: cty.String, Required: false},
+		"ena_support":         &hcldec.AttrSpec{Name: "ena_support", Type: cty.String, Required: false},
+		"nvme_support":        &hcldec.AttrSpec{Name: "nvme_support", Type: cty.String, Required: false},
+		"current_generation":  &hcldec.AttrSpec{Name: "current_generation", Type: cty.Bool, Required: false},
+		"availability_zones":  &hcldec.AttrSpec{Name: "availability_zones", Type: cty.List(cty.String), Required: false},
+	}
+	return s
+}
@@ -0,0 +1,240 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package instancetypes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+)
+
+// mockInstanceTypesEC2 describes five instance types over two pages, offered
+// in two availability zones but m7g.large offered only in us-east-1a and
+// c5.24xlarge nowhere.
+type mockInstanceTypesEC2 struct {
+	clients.Ec2Client
+
+	typesInput     *ec2.DescribeInstanceTypesInput
+	offeringsInput *ec2.DescribeInstanceTypeOfferingsInput
+}
+
+func testInstanceType(name, architecture string, vcpus int32, memory int64) types.InstanceTypeInfo {
+	return types.InstanceTypeInfo{
+		InstanceType:       types.InstanceType(name),
+		CurrentGeneration:  aws.Bool(true),
+		ProcessorInfo:      &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{types.ArchitectureType(architecture)}},
+		SupportedBootModes: []types.BootModeType{types.BootModeTypeUefi},
+		VCpuInfo:           &types.VCpuInfo{DefaultVCpus: aws.Int32(vcpus)},
+		MemoryInfo:         &types.MemoryInfo{SizeInMiB: aws.Int64(memory)},
+		NetworkInfo:        &types.NetworkInfo{NetworkPerformance: aws.String("Up to 12.5 Gigabit"), EnaSupport: types.EnaSupportRequired},
+		EbsInfo:            &types.EbsInfo{NvmeSupport: types.EbsNvmeSupportRequired},
+	}
+}
+
+func (m *mockInstanceTypesEC2) DescribeInstanceTypes(ctx context.Context, input *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	m.typesInput = input
+	if input.NextToken == nil {
+		return &ec2.DescribeInstanceTypesOutput{
+			InstanceTypes: []types.InstanceTypeInfo{
+				testInstanceType("c7g.xlarge", "arm64", 4, 8192),
+				testInstanceType("m7g.large", "arm64", 2, 8192),
+				testInstanceType("c7g.large", "arm64", 2, 4096),
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	return &ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: []types.InstanceTypeInfo{
+			testInstanceType("m7i.xlarge", "x86_64", 4, 16384),
+			testInstanceType("c5.24xlarge", "x86_64", 96, 196608),
+		},
+	}, nil
+}
+
+func (m *mockInstanceTypesEC2) DescribeInstanceTypeOfferings(ctx context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	m.offeringsInput = input
+	locations := map[string]bool{"us-east-1a": true, "us-east-1b": true}
+	for _, filter := range input.Filters {
+		if aws.ToString(filter.Name) == "location" {
+			locations = map[string]bool{}
+			for _, location := range filter.Values {
+				locations[location] = true
+			}
+		}
+	}
+
+	output := &ec2.DescribeInstanceTypeOfferingsOutput{}
+	for _, name := range input.Filters[0].Values {
+		for _, az := range []string{"us-east-1b", "us-east-1a"} {
+			if !locations[az] || name == "c5.24xlarge" || (name == "m7g.large" && az != "us-east-1a") {
+				continue
+			}
+			output.InstanceTypeOfferings = append(output.InstanceTypeOfferings, types.InstanceTypeOffering{
+				InstanceType: types.InstanceType(name),
+				Location:     aws.String(az),
+				LocationType: types.LocationTypeAvailabilityZone,
+			})
+		}
+	}
+	return output, nil
+}
+
+func TestDatasourceConfigure_Defaults(t *testing.T) {
+	datasource := Datasource{}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if datasource.config.SortBy != "vcpus" || datasource.config.SortOrder != "asc" {
+		t.Errorf("unexpected defaults %q %q", datasource.config.SortBy, datasource.config.SortOrder)
+	}
+}
+
+func TestDatasourceConfigure_Errors(t *testing.T) {
+	tests := map[string]Config{
+		"negative vcpus":      {MinVCPUs: -1},
+		"vcpus range":         {MinVCPUs: 8, MaxVCPUs: 4},
+		"negative memory":     {MaxMemoryMiB: -1},
+		"memory range":        {MinMemoryMiB: 8192, MaxMemoryMiB: 4096},
+		"invalid exclusion":   {ExcludedInstanceTypes: []string{"[t"}},
+		"invalid boot mode":   {BootMode: "bios"},
+		"invalid sort_by":     {SortBy: "price"},
+		"invalid sort_order":  {SortOrder: "cheapest"},
+		"negative limit":      {Limit: -1},
+		"invalid sort_by tag": {SortBy: "tag:Name"},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: config}
+			if err := datasource.Configure(nil); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDatasource_InstanceTypes(t *testing.T) {
+	tests := map[string]struct {
+		config Config
+		want   []string
+	}{
+		"all by vcpus": {
+			config: Config{},
+			want:   []string{"c7g.large", "m7g.large", "c7g.xlarge", "m7i.xlarge"},
+		},
+		"by memory desc": {
+			config: Config{SortBy: "memory", SortOrder: "desc"},
+			want:   []string{"m7i.xlarge", "c7g.xlarge", "m7g.large", "c7g.large"},
+		},
+		"by name": {
+			config: Config{SortBy: "name"},
+			want:   []string{"c7g.large", "c7g.xlarge", "m7g.large", "m7i.xlarge"},
+		},
+		"ranges": {
+			config: Config{MinVCPUs: 4, MinMemoryMiB: 8192, MaxMemoryMiB: 8192},
+			want:   []string{"c7g.xlarge"},
+		},
+		"excluded": {
+			config: Config{ExcludedInstanceTypes: []string{"c7g.*", "m7i.*"}},
+			want:   []string{"m7g.large"},
+		},
+		"availability zone": {
+			config: Config{AvailabilityZones: []string{"us-east-1b"}},
+			want:   []string{"c7g.large", "c7g.xlarge", "m7i.xlarge"},
+		},
+		"every availability zone": {
+			config: Config{AvailabilityZones: []string{"us-east-1a", "us-east-1b"}, SortBy: "name"},
+			want:   []string{"c7g.large", "c7g.xlarge", "m7i.xlarge"},
+		},
+		"limit": {
+			config: Config{Limit: 1},
+			want:   []string{"c7g.large"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: tt.config}
+			if err := datasource.Configure(nil); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			output, err := datasource.instanceTypes(context.TODO(), &mockInstanceTypesEC2{})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !reflect.DeepEqual(output.Names, tt.want) {
+				t.Errorf("got %v, want %v", output.Names, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatasource_InstanceTypes_Output(t *testing.T) {
+	datasource := Datasource{config: Config{ExcludedInstanceTypes: []string{"c*", "m7i.*"}}}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	output, err := datasource.instanceTypes(context.TODO(), &mockInstanceTypesEC2{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := InstanceType{
+		Name:               "m7g.large",
+		Architectures:      []string{"arm64"},
+		BootModes:          []string{"uefi"},
+		VCPUs:              2,
+		MemoryMiB:          8192,
+		NetworkPerformance: "Up to 12.5 Gigabit",
+		EnaSupport:         "required",
+		NvmeSupport:        "required",
+		CurrentGeneration:  true,
+		AvailabilityZones:  []string{"us-east-1a"},
+	}
+	if len(output.InstanceTypes) != 1 || !reflect.DeepEqual(output.InstanceTypes[0], want) {
+		t.Errorf("got %#v, want %#v", output.InstanceTypes, want)
+	}
+}
+
+func TestDatasource_InstanceTypes_Filters(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			InstanceTypes:     []string{"c7g.*", "m7g.*"},
+			Architectures:     []string{"arm64"},
+			CurrentGeneration: true,
+			EnaSupport:        true,
+			NvmeSupport:       true,
+			BootMode:          "uefi",
+		},
+	}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client := &mockInstanceTypesEC2{}
+	if _, err := datasource.instanceTypes(context.TODO(), client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	filters := map[string][]string{}
+	for _, filter := range client.typesInput.Filters {
+		filters[aws.ToString(filter.Name)] = filter.Values
+	}
+	want := map[string][]string{
+		"instance-type":                         {"c7g.*", "m7g.*"},
+		"processor-info.supported-architecture": {"arm64"},
+		"current-generation":                    {"true"},
+		"network-info.ena-support":              {"supported", "required"},
+		"ebs-info.nvme-support":                 {"supported", "required"},
+		"supported-boot-mode":                   {"uefi"},
+	}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("got filters %v, want %v", filters, want)
+	}
+	if client.offeringsInput.LocationType != types.LocationTypeAvailabilityZone {
+		t.Errorf("offerings should be described by availability zone, got %q", client.offeringsInput.LocationType)
+	}
+}
@@ -0,0 +1,34 @@
+# Copyright IBM Corp. 2013, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+data "amazon-instance-types" "test" {
+  region             = "us-west-2"
+  architectures      = ["arm64"]
+  min_vcpus          = 4
+  min_memory_mib     = 8192
+  current_generation = true
+  ena_support        = true
+  nvme_support       = true
+  boot_mode          = "uefi"
+  limit              = 3
+}
+
+locals {
+  instance_types = data.amazon-instance-types.test.instance_types
+}
+
+source "null" "basic-example" {
+  communicator = "none"
+}
+
+build {
+  sources = [
+    "source.null.basic-example"
+  ]
+
+  provisioner "shell-local" {
+    inline = [
+      "echo ${length(local.instance_types)} ${local.instance_types[0].name} ${local.instance_types[0].vcpus} ${local.instance_types[0].architectures[0]}",
+    ]
+  }
+}
@@ -10,6 +10,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -31,13 +34,33 @@ type Config struct {
 	common.PackerConfig    `mapstructure:",squash"`
 	awscommon.AccessConfig `mapstructure:",squash"`
 
-	// The name of the parameter you want to query.
-	Name string `mapstructure:"name" required:"true"`
+	// The name of the parameter you want to query. A version or a label of
+	// the parameter may be selected with a `name:version` or `name:label`
+	// selector, such as `/packer/ami:3` or `/packer/ami:prod`. One of `name`
+	// or `path` must be set.
+	Name string `mapstructure:"name"`
+	// The version of the parameter to query, rather than its latest version.
+	// Same as a `name:version` selector.
+	Version int64 `mapstructure:"version"`
+	// The label of the version of the parameter to query. Same as a
+	// `name:label` selector.
+	Label string `mapstructure:"label"`
+	// The path of a hierarchy of parameters to query at once, such as
+	// `/packer/team-x/prod`, rather than a single parameter. Every parameter
+	// under the path, recursively, is returned in the `parameters` output.
+	// One of `name` or `path` must be set.
+	Path string `mapstructure:"path"`
 	// Return decrypted values for secure string parameters.
 	// This flag is ignored for String and StringList parameter types.
 	WithDecryption bool `mapstructure:"with_decryption"`
 }
 
+// ssmAPI is the part of the SSM API used by the data source.
+type ssmAPI interface {
+	ssm.GetParametersByPathAPIClient
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
 func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
 	return d.config.FlatMapstructure().HCL2Spec()
 }
@@ -50,8 +73,22 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 	var errs *packersdk.MultiError
 	errs = packersdk.MultiErrorAppend(errs, d.config.AccessConfig.Prepare(&d.config.PackerConfig)...)
 
-	if d.config.Name == "" {
-		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("a 'name' must be provided"))
+	switch {
+	case d.config.Name == "" && d.config.Path == "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("a 'name' or a 'path' must be provided"))
+	case d.config.Name != "" && d.config.Path != "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("only one of 'name' and 'path' can be provided"))
+	case d.config.Path != "" && !strings.HasPrefix(d.config.Path, "/"):
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'path' must start with /"))
+	}
+	if d.config.Version != 0 && d.config.Label != "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("only one of 'version' and 'label' can be provided"))
+	}
+	if d.config.Version < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'version' must be positive"))
+	}
+	if d.config.Path != "" && (d.config.Version != 0 || d.config.Label != "") {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'version' and 'label' cannot be used with 'path'"))
 	}
 	if errs != nil && len(errs.Errors) > 0 {
 		return errs
@@ -60,12 +97,27 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 }
 
 type DatasourceOutput struct {
-	// The parameter value.
+	// The parameter value. The values of a StringList parameter are
+	// separated by commas.
 	Value string `mapstructure:"value"`
+	// The values of a StringList parameter, or the value of a String or
+	// SecureString parameter.
+	List []string `mapstructure:"list"`
 	// The parameter version.
 	Version string `mapstructure:"version"`
 	// The Amazon Resource Name (ARN) of the parameter.
 	ARN string `mapstructure:"arn"`
+	// The parameter type: `String`, `StringList` or `SecureString`.
+	Type string `mapstructure:"type"`
+	// The data type of the parameter, such as `text` or `aws:ec2:image`.
+	DataType string `mapstructure:"data_type"`
+	// The date the parameter was last changed or updated, in RFC 3339
+	// format.
+	LastModifiedDate string `mapstructure:"last_modified_date"`
+	// When `path` is set, the values of the parameters under the path, by
+	// name relative to the path. For `path = "/packer/prod"`, the value of
+	// `/packer/prod/vpc/id` is `parameters["vpc/id"]`.
+	Parameters map[string]string `mapstructure:"parameters"`
 }
 
 func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
@@ -81,29 +133,91 @@ func (d *Datasource) Execute() (cty.Value, error) {
 	}
 	ssmsvc := ssm.NewFromConfig(*cfg)
 
+	var output DatasourceOutput
+	if d.config.Path != "" {
+		output, err = d.parametersByPath(ctx, ssmsvc)
+	} else {
+		output, err = d.parameter(ctx, ssmsvc)
+	}
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// parameter returns the parameter selected by name, version and label.
+func (d *Datasource) parameter(ctx context.Context, client ssmAPI) (DatasourceOutput, error) {
+	name := d.config.Name
+	switch {
+	case d.config.Version != 0:
+		name = fmt.Sprintf("%s:%d", name, d.config.Version)
+	case d.config.Label != "":
+		name = fmt.Sprintf("%s:%s", name, d.config.Label)
+	}
+
 	input := &ssm.GetParameterInput{
-		Name:           aws.String(d.config.Name),
+		Name:           aws.String(name),
 		WithDecryption: aws.Bool(d.config.WithDecryption),
 	}
-	param, err := ssmsvc.GetParameter(ctx, input)
+	param, err := client.GetParameter(ctx, input)
 
 	if err != nil {
 		var notFoundErr *types.ParameterNotFound
 		var versionNotFoundErr *types.ParameterVersionNotFound
 
 		if errors.As(err, &notFoundErr) {
-			return cty.NullVal(cty.EmptyObject), fmt.Errorf("The parameter name %q not found", d.config.Name)
+			return DatasourceOutput{}, fmt.Errorf("The parameter name %q not found", name)
 		}
 		if errors.As(err, &versionNotFoundErr) {
-			return cty.NullVal(cty.EmptyObject), fmt.Errorf("The parameter version %q not found", d.config.Name)
+			return DatasourceOutput{}, fmt.Errorf("The parameter version %q not found", name)
 		}
-		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error to get parameter value: %s", err.Error())
+		return DatasourceOutput{}, fmt.Errorf("error to get parameter value: %s", err.Error())
 	}
 
+	p := param.Parameter
+	value := aws.ToString(p.Value)
 	output := DatasourceOutput{
-		Value:   aws.ToString(param.Parameter.Value),
-		Version: fmt.Sprintf("%d", param.Parameter.Version),
-		ARN:     aws.ToString(param.Parameter.ARN),
+		Value:      value,
+		List:       []string{value},
+		Version:    strconv.FormatInt(p.Version, 10),
+		ARN:        aws.ToString(p.ARN),
+		Type:       string(p.Type),
+		DataType:   aws.ToString(p.DataType),
+		Parameters: map[string]string{},
 	}
-	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+	if p.Type == types.ParameterTypeStringList {
+		output.List = strings.Split(value, ",")
+	}
+	if p.LastModifiedDate != nil {
+		output.LastModifiedDate = p.LastModifiedDate.UTC().Format(time.RFC3339)
+	}
+	return output, nil
+}
+
+// parametersByPath returns every parameter under the path, by relative name.
+func (d *Datasource) parametersByPath(ctx context.Context, client ssmAPI) (DatasourceOutput, error) {
+	path := d.config.Path
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	output := DatasourceOutput{
+		Parameters: map[string]string{},
+	}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(d.config.WithDecryption),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return output, fmt.Errorf("error to get parameters by path %q: %s", path, err)
+		}
+		for _, p := range page.Parameters {
+			name := strings.TrimPrefix(aws.ToString(p.Name), path)
+			output.Parameters[strings.TrimPrefix(name, "/")] = aws.ToString(p.Value)
+		}
+	}
+	return output, nil
 }
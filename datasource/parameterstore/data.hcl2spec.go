@@ -35,7 +35,10 @@ type FlatConfig struct {
 	Token                 *string                           `mapstructure:"token" required:"false" cty:"token" hcl:"token"`
 	VaultAWSEngine        *common.FlatVaultAWSEngineOptions `mapstructure:"vault_aws_engine" required:"false" cty:"vault_aws_engine" hcl:"vault_aws_engine"`
 	PollingConfig         *common.FlatAWSPollingConfig      `mapstructure:"aws_polling" required:"false" cty:"aws_polling" hcl:"aws_polling"`
-	Name                  *string                           `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	Name                  *string                           `mapstructure:"name" cty:"name" hcl:"name"`
+	Version               *int64                            `mapstructure:"version" cty:"version" hcl:"version"`
+	Label                 *string                           `mapstructure:"label" cty:"label" hcl:"label"`
+	Path                  *string                           `mapstructure:"path" cty:"path" hcl:"path"`
 	WithDecryption        *bool                             `mapstructure:"with_decryption" cty:"with_decryption" hcl:"with_decryption"`
 }
 
@@ -76,6 +79,9 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"vault_aws_engine":              &hcldec.BlockSpec{TypeName: "vault_aws_engine", Nested: hcldec.ObjectSpec((*common.FlatVaultAWSEngineOptions)(nil).HCL2Spec())},
 		"aws_polling":                   &hcldec.BlockSpec{TypeName: "aws_polling", Nested: hcldec.ObjectSpec((*common.FlatAWSPollingConfig)(nil).HCL2Spec())},
 		"name":                          &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"version":                       &hcldec.AttrSpec{Name: "version", Type: cty.Number, Required: false},
+		"label":                         &hcldec.AttrSpec{Name: "label", Type: cty.String, Required: false},
+		"path":                          &hcldec.AttrSpec{Name: "path", Type: cty.String, Required: false},
 		"with_decryption":               &hcldec.AttrSpec{Name: "with_decryption", Type: cty.Bool, Required: false},
 	}
 	return s
@@ -84,9 +90,14 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatDatasourceOutput struct {
-	Value   *string `mapstructure:"value" cty:"value" hcl:"value"`
-	Version *string `mapstructure:"version" cty:"version" hcl:"version"`
-	ARN     *string `mapstructure:"arn" cty:"arn" hcl:"arn"`
+	Value            *string           `mapstructure:"value" cty:"value" hcl:"value"`
+	List             []string          `mapstructure:"list" cty:"list" hcl:"list"`
+	Version          *string           `mapstructure:"version" cty:"version" hcl:"version"`
+	ARN              *string           `mapstructure:"arn" cty:"arn" hcl:"arn"`
+	Type             *string           `mapstructure:"type" cty:"type" hcl:"type"`
+	DataType         *string           `mapstructure:"data_type" cty:"data_type" hcl:"data_type"`
+	LastModifiedDate *string           `mapstructure:"last_modified_date" cty:"last_modified_date" hcl:"last_modified_date"`
+	Parameters       map[string]string `mapstructure:"parameters" cty:"parameters" hcl:"parameters"`
 }
 
 // FlatMapstructure returns a new FlatDatasourceOutput.
@@ -101,9 +112,14 @@ func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcl
 // The decoded values from this spec will then be applied to a FlatDatasourceOutput.
 func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"value":   &hcldec.AttrSpec{Name: "value", Type: cty.String, Required: false},
-		"version": &hcldec.AttrSpec{Name: "version", Type: cty.String, Required: false},
-		"arn":     &hcldec.AttrSpec{Name: "arn", Type: cty.String, Required: false},
+		"value":              &hcldec.AttrSpec{Name: "value", Type: cty.String, Required: false},
+		"list":               &hcldec.AttrSpec{Name: "list", Type: cty.List(cty.String), Required: false},
+		"version":            &hcldec.AttrSpec{Name: "version", Type: cty.String, Required: false},
+		"arn":                &hcldec.AttrSpec{Name: "arn", Type: cty.String, Required: false},
+		"type":               &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"data_type":          &hcldec.AttrSpec{Name: "data_type", Type: cty.String, Required: false},
+		"last_modified_date": &hcldec.AttrSpec{Name: "last_modified_date", Type: cty.String, Required: false},
+		"parameters":         &hcldec.AttrSpec{Name: "parameters", Type: cty.Map(cty.String), Required: false},
 	}
 	return s
 }
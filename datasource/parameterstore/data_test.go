@@ -3,7 +3,16 @@
 
 package parameterstore
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/google/go-cmp/cmp"
+)
 
 func TestDatasourceConfigure_EmptyParameterName(t *testing.T) {
 	datasource := Datasource{
@@ -23,3 +32,124 @@ func TestDatasourceConfigure(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 }
+
+func TestDatasourceConfigure_Errors(t *testing.T) {
+	tests := map[string]Config{
+		"name and path":     {Name: "/packer/ami", Path: "/packer"},
+		"relative path":     {Path: "packer/prod"},
+		"version and label": {Name: "/packer/ami", Version: 3, Label: "prod"},
+		"negative version":  {Name: "/packer/ami", Version: -1},
+		"path and label":    {Path: "/packer", Label: "prod"},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: config}
+			if err := datasource.Configure(nil); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+// mockSSM serves /packer/ami as a StringList, and the /packer/prod
+// hierarchy over two pages.
+type mockSSM struct {
+	name  string
+	paths []*ssm.GetParametersByPathInput
+}
+
+func (m *mockSSM) GetParameter(ctx context.Context, input *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	m.name = aws.ToString(input.Name)
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{
+		Name:             aws.String("/packer/ami"),
+		ARN:              aws.String("arn:aws:ssm:us-east-1:123456789012:parameter/packer/ami"),
+		Value:            aws.String("ami-1,ami-2"),
+		Version:          3,
+		Type:             types.ParameterTypeStringList,
+		DataType:         aws.String("text"),
+		LastModifiedDate: aws.Time(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}}, nil
+}
+
+func (m *mockSSM) GetParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	m.paths = append(m.paths, input)
+	if input.NextToken == nil {
+		return &ssm.GetParametersByPathOutput{
+			Parameters: []types.Parameter{
+				{Name: aws.String("/packer/prod/instance_type"), Value: aws.String("t3.small")},
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	return &ssm.GetParametersByPathOutput{
+		Parameters: []types.Parameter{
+			{Name: aws.String("/packer/prod/vpc/id"), Value: aws.String("vpc-1")},
+			{Name: aws.String("/packer/prod/vpc/subnets"), Value: aws.String("subnet-1,subnet-2")},
+		},
+	}, nil
+}
+
+func TestDatasource_parameter(t *testing.T) {
+	tests := map[string]struct {
+		config Config
+		name   string
+	}{
+		"latest":   {Config{Name: "/packer/ami"}, "/packer/ami"},
+		"selector": {Config{Name: "/packer/ami:prod"}, "/packer/ami:prod"},
+		"version":  {Config{Name: "/packer/ami", Version: 3}, "/packer/ami:3"},
+		"label":    {Config{Name: "/packer/ami", Label: "prod"}, "/packer/ami:prod"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &mockSSM{}
+			datasource := Datasource{config: tt.config}
+			output, err := datasource.parameter(context.Background(), client)
+			if err != nil {
+				t.Fatalf("parameter() failed: %s", err)
+			}
+			if client.name != tt.name {
+				t.Errorf("expected %q to be queried, got %q", tt.name, client.name)
+			}
+
+			want := DatasourceOutput{
+				Value:            "ami-1,ami-2",
+				List:             []string{"ami-1", "ami-2"},
+				Version:          "3",
+				ARN:              "arn:aws:ssm:us-east-1:123456789012:parameter/packer/ami",
+				Type:             "StringList",
+				DataType:         "text",
+				LastModifiedDate: "2025-01-02T03:04:05Z",
+				Parameters:       map[string]string{},
+			}
+			if diff := cmp.Diff(want, output); diff != "" {
+				t.Errorf("unexpected output: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDatasource_parametersByPath(t *testing.T) {
+	for _, path := range []string{"/packer/prod", "/packer/prod/"} {
+		t.Run(path, func(t *testing.T) {
+			client := &mockSSM{}
+			datasource := Datasource{config: Config{Path: path, WithDecryption: true}}
+			output, err := datasource.parametersByPath(context.Background(), client)
+			if err != nil {
+				t.Fatalf("parametersByPath() failed: %s", err)
+			}
+
+			want := map[string]string{
+				"instance_type": "t3.small",
+				"vpc/id":        "vpc-1",
+				"vpc/subnets":   "subnet-1,subnet-2",
+			}
+			if diff := cmp.Diff(want, output.Parameters); diff != "" {
+				t.Errorf("unexpected parameters: %s", diff)
+			}
+			input := client.paths[0]
+			if aws.ToString(input.Path) != "/packer/prod" || !aws.ToBool(input.Recursive) || !aws.ToBool(input.WithDecryption) {
+				t.Errorf("unexpected input %+v", input)
+			}
+		})
+	}
+}
@@ -7,10 +7,11 @@ package secretsmanager
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -23,6 +24,7 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
 type Datasource struct {
@@ -35,8 +37,22 @@ type Config struct {
 	// You can specify either the Amazon Resource Name (ARN) or the friendly name of the secret.
 	Name string `mapstructure:"name" required:"true"`
 	// Optional key for JSON secrets that contain more than one value. When set, the `value` output will
-	// contain the value for the provided key.
+	// contain the value for the provided key. Nested values are read with a path of dot separated
+	// keys, where `[n]` selects an element of an array and `["key"]` a key containing dots, such as
+	// `db.primary.password`, `users[0].name` or `tls["ca.pem"]`. A leading `$.` is allowed. Objects
+	// and arrays are returned as JSON, and a key missing from the secret is an error.
 	Key string `mapstructure:"key"`
+	// Keys to read from a JSON secret at once, in the same form as `key`. The values are returned in
+	// the `key_values` output, by key. A key missing from the secret is an error.
+	Keys []string `mapstructure:"keys"`
+	// Write a binary secret to a file, readable by the current user only, rather than only returning
+	// it base64 encoded. The path of the file is returned in the `secret_binary_file` output. Packer
+	// does not remove the file: delete it once the build is done, with a `shell-local`
+	// post-processor for example. Defaults to false.
+	SecretBinaryToFile bool `mapstructure:"secret_binary_to_file"`
+	// The existing directory to write the file of [secret_binary_to_file](#secret_binary_to_file)
+	// in. Defaults to the temporary directory of the system, where the file is not removed either.
+	SecretBinaryDir string `mapstructure:"secret_binary_dir"`
 	// Specifies the unique identifier of the version of the secret that you want to retrieve.
 	// Overrides version_stage.
 	VersionId string `mapstructure:"version_id"`
@@ -63,6 +79,17 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("a 'name' must be provided"))
 	}
 
+	for _, key := range d.config.Keys {
+		if key == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("keys cannot contain an empty key"))
+			break
+		}
+	}
+
+	if d.config.SecretBinaryDir != "" && !d.config.SecretBinaryToFile {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("secret_binary_dir can only be set with secret_binary_to_file"))
+	}
+
 	if d.config.VersionStage == "" {
 		d.config.VersionStage = "AWSCURRENT"
 	}
@@ -75,20 +102,34 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 
 type DatasourceOutput struct {
 	// When a [key](#key) is provided, this will be the value for that key. If a key is not provided,
-	// `value` will contain the first value found in the secret string.
+	// `value` will contain the first value found in the secret string, or the secret string of a
+	// plaintext secret.
 	Value string `mapstructure:"value"`
+	// The value of each of the [keys](#keys), by key.
+	KeyValues map[string]string `mapstructure:"key_values"`
+	// The whole decoded JSON secret, as an object keeping the type of each value: strings, numbers,
+	// booleans and `null`, with objects and arrays as nested objects and lists, such as
+	// `values.db.primary.password` or `values.users[0].name`. Empty when the secret is not a JSON
+	// object.
+	Values map[string]any `mapstructure:"values"`
 	// The decrypted part of the protected secret information that
 	// was originally provided as a string.
 	SecretString string `mapstructure:"secret_string"`
 	// The decrypted part of the protected secret information that
 	// was originally provided as a binary. Base64 encoded.
 	SecretBinary string `mapstructure:"secret_binary"`
+	// The path of the file the binary secret was written to, when
+	// [secret_binary_to_file](#secret_binary_to_file) is set.
+	SecretBinaryFile string `mapstructure:"secret_binary_file"`
 	// The unique identifier of this version of the secret.
 	VersionId string `mapstructure:"version_id"`
 }
 
 func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
-	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+	spec := (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+	// The type of values is the one of the secret, only known once read.
+	spec["values"] = &hcldec.AttrSpec{Name: "values", Type: cty.DynamicPseudoType, Required: false}
+	return spec
 }
 
 func (d *Datasource) Execute() (cty.Value, error) {
@@ -127,55 +168,116 @@ func (d *Datasource) Execute() (cty.Value, error) {
 		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error reading Secrets Manager Secret Version: %s", err)
 	}
 
-	value, err := getSecretValue(aws.ToString(secret.SecretString), d.config.Key)
+	output, err := d.output(secret)
 	if err != nil {
 		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error to get secret value: %q", err.Error())
 	}
+	return outputValue(output)
+}
 
-	versionId := aws.ToString(secret.VersionId)
-	output := DatasourceOutput{
-		Value:        value,
-		SecretString: aws.ToString(secret.SecretString),
-		SecretBinary: string(secret.SecretBinary),
-		VersionId:    versionId,
+// outputValue returns the output as the value of the datasource, with values
+// typed after the JSON of the secret.
+func outputValue(output DatasourceOutput) (cty.Value, error) {
+	values, err := json.Marshal(output.Values)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
 	}
-	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+	valuesType, err := ctyjson.ImpliedType(values)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	typedValues, err := ctyjson.Unmarshal(values, valuesType)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output.Values = nil
+	attrs := hcl2helper.HCL2ValueFromConfig(output, (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()).AsValueMap()
+	attrs["values"] = typedValues
+	return cty.ObjectVal(attrs), nil
 }
 
-func getSecretValue(secretString string, key string) (string, error) {
-	var secretValue map[string]interface{}
-	blob := []byte(secretString)
+// output decodes the secret and reads the requested keys.
+func (d *Datasource) output(secret *secretsmanager.GetSecretValueOutput) (DatasourceOutput, error) {
+	secretString := aws.ToString(secret.SecretString)
+	output := DatasourceOutput{
+		SecretString: secretString,
+		SecretBinary: base64.StdEncoding.EncodeToString(secret.SecretBinary),
+		VersionId:    aws.ToString(secret.VersionId),
+		KeyValues:    map[string]string{},
+		Values:       map[string]any{},
+	}
 
-	//For those plaintext secrets just return the value
-	if json.Valid(blob) != true {
-		return secretString, nil
+	if d.config.SecretBinaryToFile {
+		if secret.SecretBinary == nil {
+			return output, fmt.Errorf("secret_binary_to_file is set but the secret is not binary")
+		}
+		path, err := writeSecretBinary(d.config.SecretBinaryDir, secret.SecretBinary)
+		if err != nil {
+			return output, err
+		}
+		output.SecretBinaryFile = path
 	}
 
-	err := json.Unmarshal(blob, &secretValue)
+	decoded, err := decodeSecret(secretString)
 	if err != nil {
-		return "", err
+		return output, err
+	}
+	if object, ok := decoded.(map[string]any); ok {
+		output.Values = object
 	}
 
-	if key == "" {
-		for _, v := range secretValue {
-			return getStringSecretValue(v)
+	output.Value, err = getSecretValue(secretString, decoded, d.config.Key)
+	if err != nil {
+		return output, err
+	}
+	for _, key := range d.config.Keys {
+		if output.KeyValues[key], err = getSecretValue(secretString, decoded, key); err != nil {
+			return output, err
 		}
 	}
+	return output, nil
+}
 
-	if v, ok := secretValue[key]; ok {
-		return getStringSecretValue(v)
+// getSecretValue returns the value of key in the decoded secret, or the
+// first value of the secret when key is empty.
+func getSecretValue(secretString string, decoded any, key string) (string, error) {
+	if key == "" {
+		// Plaintext secrets and JSON values other than objects are returned
+		// as is.
+		if object, ok := decoded.(map[string]any); ok && len(object) > 0 {
+			first, err := firstKey(secretString)
+			if err != nil {
+				return "", err
+			}
+			return stringValue(object[first])
+		}
+		return secretString, nil
 	}
 
-	return "", nil
+	if decoded == nil {
+		return "", fmt.Errorf("key %q not found in secret: the secret is not JSON", key)
+	}
+	v, err := lookup(decoded, key)
+	if err != nil {
+		return "", err
+	}
+	return stringValue(v)
 }
 
-func getStringSecretValue(v interface{}) (string, error) {
-	switch valueType := v.(type) {
-	case string:
-		return valueType, nil
-	case float64:
-		return strconv.FormatFloat(valueType, 'f', 0, 64), nil
-	default:
-		return "", fmt.Errorf("Unsupported secret value type: %T", valueType)
+// writeSecretBinary writes a binary secret to a new file in dir, or the
+// temporary directory when dir is empty, only readable by the current user,
+// and returns its path. Removing the file is up to the user.
+func writeSecretBinary(dir string, secretBinary []byte) (string, error) {
+	f, err := os.CreateTemp(dir, "packer-secret-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating the secret binary file: %s", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(secretBinary); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("error writing the secret binary file: %s", err)
 	}
+	return f.Name(), nil
 }
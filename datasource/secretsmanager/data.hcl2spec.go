@@ -21,6 +21,9 @@ type FlatConfig struct {
 	PackerSensitiveVars   []string                          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
 	Name                  *string                           `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
 	Key                   *string                           `mapstructure:"key" cty:"key" hcl:"key"`
+	Keys                  []string                          `mapstructure:"keys" cty:"keys" hcl:"keys"`
+	SecretBinaryToFile    *bool                             `mapstructure:"secret_binary_to_file" cty:"secret_binary_to_file" hcl:"secret_binary_to_file"`
+	SecretBinaryDir       *string                           `mapstructure:"secret_binary_dir" cty:"secret_binary_dir" hcl:"secret_binary_dir"`
 	VersionId             *string                           `mapstructure:"version_id" cty:"version_id" hcl:"version_id"`
 	VersionStage          *string                           `mapstructure:"version_stage" cty:"version_stage" hcl:"version_stage"`
 	AccessKey             *string                           `mapstructure:"access_key" required:"true" cty:"access_key" hcl:"access_key"`
@@ -63,6 +66,9 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_sensitive_variables":    &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"name":                          &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
 		"key":                           &hcldec.AttrSpec{Name: "key", Type: cty.String, Required: false},
+		"keys":                          &hcldec.AttrSpec{Name: "keys", Type: cty.List(cty.String), Required: false},
+		"secret_binary_to_file":         &hcldec.AttrSpec{Name: "secret_binary_to_file", Type: cty.Bool, Required: false},
+		"secret_binary_dir":             &hcldec.AttrSpec{Name: "secret_binary_dir", Type: cty.String, Required: false},
 		"version_id":                    &hcldec.AttrSpec{Name: "version_id", Type: cty.String, Required: false},
 		"version_stage":                 &hcldec.AttrSpec{Name: "version_stage", Type: cty.String, Required: false},
 		"access_key":                    &hcldec.AttrSpec{Name: "access_key", Type: cty.String, Required: false},
@@ -88,10 +94,13 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatDatasourceOutput struct {
-	Value        *string `mapstructure:"value" cty:"value" hcl:"value"`
-	SecretString *string `mapstructure:"secret_string" cty:"secret_string" hcl:"secret_string"`
-	SecretBinary *string `mapstructure:"secret_binary" cty:"secret_binary" hcl:"secret_binary"`
-	VersionId    *string `mapstructure:"version_id" cty:"version_id" hcl:"version_id"`
+	Value            *string           `mapstructure:"value" cty:"value" hcl:"value"`
+	KeyValues        map[string]string `mapstructure:"key_values" cty:"key_values" hcl:"key_values"`
+	Values           map[string]any    `mapstructure:"values" cty:"values" hcl:"values"`
+	SecretString     *string           `mapstructure:"secret_string" cty:"secret_string" hcl:"secret_string"`
+	SecretBinary     *string           `mapstructure:"secret_binary" cty:"secret_binary" hcl:"secret_binary"`
+	SecretBinaryFile *string           `mapstructure:"secret_binary_file" cty:"secret_binary_file" hcl:"secret_binary_file"`
+	VersionId        *string           `mapstructure:"version_id" cty:"version_id" hcl:"version_id"`
 }
 
 // FlatMapstructure returns a new FlatDatasourceOutput.
@@ -106,10 +115,13 @@ func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcl
 // The decoded values from this spec will then be applied to a FlatDatasourceOutput.
 func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"value":         &hcldec.AttrSpec{Name: "value", Type: cty.String, Required: false},
-		"secret_string": &hcldec.AttrSpec{Name: "secret_string", Type: cty.String, Required: false},
-		"secret_binary": &hcldec.AttrSpec{Name: "secret_binary", Type: cty.String, Required: false},
-		"version_id":    &hcldec.AttrSpec{Name: "version_id", Type: cty.String, Required: false},
+		"value":              &hcldec.AttrSpec{Name: "value", Type: cty.String, Required: false},
+		"key_values":         &hcldec.AttrSpec{Name: "key_values", Type: cty.Map(cty.String), Required: false},
+		"values":             &hcldec.AttrSpec{Name: "values", Type: cty.Map(cty.String), Required: false},
+		"secret_string":      &hcldec.AttrSpec{Name: "secret_string", Type: cty.String, Required: false},
+		"secret_binary":      &hcldec.AttrSpec{Name: "secret_binary", Type: cty.String, Required: false},
+		"secret_binary_file": &hcldec.AttrSpec{Name: "secret_binary_file", Type: cty.String, Required: false},
+		"version_id":         &hcldec.AttrSpec{Name: "version_id", Type: cty.String, Required: false},
 	}
 	return s
 }
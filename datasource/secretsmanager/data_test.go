@@ -4,7 +4,17 @@
 package secretsmanager
 
 import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestDatasourceConfigure_EmptySecretId(t *testing.T) {
@@ -40,3 +50,193 @@ func TestDatasourceConfigure(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 }
+
+func TestDatasourceConfigure_EmptyKeys(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			Name: "arn:1223",
+			Keys: []string{"user", ""},
+		},
+	}
+	if err := datasource.Configure(nil); err == nil {
+		t.Fatalf("Should error if keys contains an empty key")
+	}
+}
+
+const testSecretString = `{
+	"username": "admin",
+	"port": 5432,
+	"id": 12345678901234567890,
+	"enabled": true,
+	"db": {"primary": {"password": "hunter2"}},
+	"users": [{"name": "alice"}, {"name": "bob"}],
+	"tls.ca": "ca-pem",
+	"tls": {"cert.pem": "cert-pem"}
+}`
+
+func TestDatasource_output(t *testing.T) {
+	tests := map[string]struct {
+		secretString string
+		key          string
+		want         string
+	}{
+		"first key":          {testSecretString, "", "admin"},
+		"first key, ordered": {`{"z": "last", "a": "first"}`, "", "last"},
+		"plaintext":          {"hunter2", "", "hunter2"},
+		"json string":        {`"hunter2"`, "", `"hunter2"`},
+		"top-level key":      {testSecretString, "username", "admin"},
+		"number":             {testSecretString, "port", "5432"},
+		"large number":       {testSecretString, "id", "12345678901234567890"},
+		"boolean":            {testSecretString, "enabled", "true"},
+		"nested key":         {testSecretString, "db.primary.password", "hunter2"},
+		"json path":          {testSecretString, "$.db.primary.password", "hunter2"},
+		"array element":      {testSecretString, "users[1].name", "bob"},
+		"object":             {testSecretString, "db.primary", `{"password":"hunter2"}`},
+		"array":              {testSecretString, "users", `[{"name":"alice"},{"name":"bob"}]`},
+		"dotted key":         {testSecretString, "tls.ca", "ca-pem"},
+		"quoted key":         {testSecretString, `tls["cert.pem"]`, "cert-pem"},
+		"top-level array":    {`["a", "b"]`, "[1]", "b"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: Config{Key: tt.key}}
+			output, err := datasource.output(&secretsmanager.GetSecretValueOutput{
+				SecretString: aws.String(tt.secretString),
+			})
+			if err != nil {
+				t.Fatalf("output() failed: %s", err)
+			}
+			if output.Value != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, output.Value)
+			}
+		})
+	}
+}
+
+func TestDatasourceConfigure_SecretBinaryDirWithoutFile(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			Name:            "arn:1223",
+			SecretBinaryDir: "secrets",
+		},
+	}
+	if err := datasource.Configure(nil); err == nil {
+		t.Fatalf("Should error if secret_binary_dir is set without secret_binary_to_file")
+	}
+}
+
+func TestDatasource_outputMissingKey(t *testing.T) {
+	tests := map[string]struct {
+		secretString string
+		key          string
+	}{
+		"missing key":        {testSecretString, "password"},
+		"missing nested":     {testSecretString, "db.replica.password"},
+		"index out of range": {testSecretString, "users[2].name"},
+		"not an object":      {testSecretString, "username.first"},
+		"plaintext":          {"hunter2", "password"},
+		"invalid path":       {testSecretString, "users[0"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: Config{Keys: []string{tt.key}}}
+			_, err := datasource.output(&secretsmanager.GetSecretValueOutput{
+				SecretString: aws.String(tt.secretString),
+			})
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDatasource_outputValues(t *testing.T) {
+	datasource := Datasource{config: Config{Keys: []string{"username", "db.primary.password"}}}
+	output, err := datasource.output(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(testSecretString),
+	})
+	if err != nil {
+		t.Fatalf("output() failed: %s", err)
+	}
+
+	wantKeyValues := map[string]string{"username": "admin", "db.primary.password": "hunter2"}
+	if diff := cmp.Diff(wantKeyValues, output.KeyValues); diff != "" {
+		t.Errorf("unexpected key_values: %s", diff)
+	}
+
+	value, err := outputValue(output)
+	if err != nil {
+		t.Fatalf("outputValue() failed: %s", err)
+	}
+	if err := value.Type().TestConformance(hcldec.ImpliedType(datasource.OutputSpec())); err != nil {
+		t.Fatalf("output does not conform to the output spec: %s", err)
+	}
+	values := value.GetAttr("values")
+	port, _ := values.GetAttr("port").AsBigFloat().Int64()
+	id, _ := values.GetAttr("id").AsBigFloat().Int(nil)
+	for name, got := range map[string]bool{
+		"username":               values.GetAttr("username").Equals(cty.StringVal("admin")).True(),
+		"port":                   values.GetAttr("port").Type() == cty.Number && port == 5432,
+		"id":                     id.String() == "12345678901234567890",
+		"enabled":                values.GetAttr("enabled").Equals(cty.True).True(),
+		"db.primary.password":    values.GetAttr("db").GetAttr("primary").GetAttr("password").Equals(cty.StringVal("hunter2")).True(),
+		"users[1].name":          values.GetAttr("users").Index(cty.NumberIntVal(1)).GetAttr("name").Equals(cty.StringVal("bob")).True(),
+		"tls.ca":                 values.GetAttr("tls.ca").Equals(cty.StringVal("ca-pem")).True(),
+		`tls["cert.pem"]`:        values.GetAttr("tls").GetAttr("cert.pem").Equals(cty.StringVal("cert-pem")).True(),
+		"value of the first key": value.GetAttr("value").Equals(cty.StringVal("admin")).True(),
+	} {
+		if !got {
+			t.Errorf("unexpected values.%s in %#v", name, values)
+		}
+	}
+}
+
+func TestDatasource_outputValuesPlaintext(t *testing.T) {
+	datasource := Datasource{}
+	output, err := datasource.output(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String("plaintext"),
+	})
+	if err != nil {
+		t.Fatalf("output() failed: %s", err)
+	}
+	value, err := outputValue(output)
+	if err != nil {
+		t.Fatalf("outputValue() failed: %s", err)
+	}
+	if values := value.GetAttr("values"); values.LengthInt() != 0 {
+		t.Errorf("expected empty values for a plaintext secret, got %#v", values)
+	}
+}
+
+func TestDatasource_outputBinary(t *testing.T) {
+	secretBinary := []byte{0x00, 0xff, 'k', 'e', 'y'}
+	dir := t.TempDir()
+	datasource := Datasource{config: Config{SecretBinaryToFile: true, SecretBinaryDir: dir}}
+	output, err := datasource.output(&secretsmanager.GetSecretValueOutput{
+		SecretBinary: secretBinary,
+	})
+	if err != nil {
+		t.Fatalf("output() failed: %s", err)
+	}
+	if filepath.Dir(output.SecretBinaryFile) != dir {
+		t.Errorf("expected the secret binary file in %s, got %s", dir, output.SecretBinaryFile)
+	}
+
+	if output.SecretBinary != base64.StdEncoding.EncodeToString(secretBinary) {
+		t.Errorf("expected the binary secret base64 encoded, got %q", output.SecretBinary)
+	}
+	content, err := os.ReadFile(output.SecretBinaryFile)
+	if err != nil {
+		t.Fatalf("failed to read the secret binary file: %s", err)
+	}
+	if !bytes.Equal(content, secretBinary) {
+		t.Errorf("expected %v in the secret binary file, got %v", secretBinary, content)
+	}
+
+	_, err = datasource.output(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String("hunter2"),
+	})
+	if err == nil {
+		t.Fatalf("expected an error for a secret string written to a file")
+	}
+}
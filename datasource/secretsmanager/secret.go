@@ -0,0 +1,153 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package secretsmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// decodeSecret decodes a JSON secret string. Plaintext secrets decode to nil.
+func decodeSecret(secretString string) (any, error) {
+	blob := []byte(secretString)
+
+	// For those plaintext secrets there is nothing to decode.
+	if !json.Valid(blob) {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(blob))
+	// Keep numbers as written, large integers would otherwise lose precision.
+	decoder.UseNumber()
+	var secret any
+	if err := decoder.Decode(&secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// firstKey returns the first key of the JSON object secretString, in the
+// order of the secret string rather than the random order of a Go map.
+func firstKey(secretString string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(secretString))
+	if _, err := decoder.Token(); err != nil {
+		return "", err
+	}
+	token, err := decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("secret is an empty JSON object")
+	}
+	return key, nil
+}
+
+// lookup returns the value at path in secret. A path is a dot separated list
+// of keys, with an optional `$` root, where `[n]` selects the element n of
+// an array and `["key"]` a key containing dots, such as
+// `db.primary.password`, `users[0].name` or `$.tls["ca.pem"]`.
+func lookup(secret any, path string) (any, error) {
+	// A top-level key containing dots or brackets takes precedence.
+	if object, ok := secret.(map[string]any); ok {
+		if v, ok := object[path]; ok {
+			return v, nil
+		}
+	}
+
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := secret
+	for i, segment := range segments {
+		switch node := v.(type) {
+		case map[string]any:
+			child, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("key %q not found in secret", path)
+			}
+			v = child
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("key %q not found in secret: %q is not an index of an array of %d elements", path, segment, len(node))
+			}
+			v = node[index]
+		default:
+			return nil, fmt.Errorf("key %q not found in secret: %q is not an object or an array", path, strings.Join(segments[:i], "."))
+		}
+	}
+	return v, nil
+}
+
+// parsePath splits a path into its keys and indexes.
+func parsePath(path string) ([]string, error) {
+	rest := strings.TrimPrefix(path, "$")
+	var segments []string
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid key %q: empty key", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid key %q: missing ]", path)
+			}
+			segment := rest[1:end]
+			if unquoted, err := strconv.Unquote(segment); err == nil {
+				segment = unquoted
+			} else if len(segment) >= 2 && segment[0] == '\'' && segment[len(segment)-1] == '\'' {
+				segment = segment[1 : len(segment)-1]
+			}
+			segments = append(segments, segment)
+			rest = rest[end+1:]
+		default:
+			if len(segments) > 0 {
+				return nil, fmt.Errorf("invalid key %q: expected . or [ before %q", path, rest)
+			}
+			// The first key does not need a leading dot.
+			rest = "." + rest
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid key %q: empty key", path)
+	}
+	return segments, nil
+}
+
+// stringValue returns a secret value as a string. Objects and arrays are
+// returned as JSON, to be read with jsondecode().
+func stringValue(v any) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Subnet,Config
+package vpcnetwork
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type Datasource struct {
+	config Config
+}
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The ID of the VPC. When neither `vpc_id` nor `vpc_filter` is set, the
+	// default VPC of the region is used.
+	VpcId string `mapstructure:"vpc_id"`
+	// Filters used to select the VPC, like the `vpc_filter` of the builders.
+	// Exactly one VPC must match.
+	//
+	// ```hcl
+	// vpc_filter {
+	//   filters = {
+	//     "tag:Class" = "build"
+	//   }
+	// }
+	// ```
+	VpcFilter awscommon.VpcFilterOptions `mapstructure:"vpc_filter"`
+	// Filters used to select the subnets of the VPC, like the
+	// `subnet_filter` of the builders. Every available subnet of the VPC is
+	// returned by default, from the one with the most free IP addresses.
+	// With `most_free` or `random`, only the subnet selected is returned.
+	//
+	// ```hcl
+	// subnet_filter {
+	//   filters = {
+	//     "tag:Class" = "build"
+	//   }
+	// }
+	// ```
+	SubnetFilter awscommon.SubnetFilterOptions `mapstructure:"subnet_filter"`
+	// Filters used to select security groups of the VPC, like the
+	// `security_group_filter` of the builders. No security group is returned
+	// when unset.
+	SecurityGroupFilter awscommon.SecurityGroupFilterOptions `mapstructure:"security_group_filter"`
+	// Only return the subnets of the availability zones offering this
+	// instance type, such as `c7g.large`, like the builders do when they
+	// choose a subnet.
+	InstanceType string `mapstructure:"instance_type"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...any) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.AccessConfig.Prepare(&d.config.PackerConfig)...)
+
+	for _, preparer := range []interface{ Prepare() []error }{
+		&d.config.VpcFilter,
+		&d.config.SubnetFilter,
+		&d.config.SecurityGroupFilter,
+	} {
+		errs = packersdk.MultiErrorAppend(errs, preparer.Prepare()...)
+	}
+
+	if d.config.VpcId != "" && !d.config.VpcFilter.Empty() {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("only one of vpc_id and vpc_filter can be set"))
+	}
+	if d.config.SubnetFilter.MostFree && d.config.SubnetFilter.Random {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("only one of subnet_filter.most_free and subnet_filter.random can be set"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type Subnet struct {
+	// The ID of the subnet.
+	ID string `mapstructure:"id"`
+	// The availability zone of the subnet, such as `us-east-1a`.
+	AvailabilityZone string `mapstructure:"availability_zone"`
+	// The ID of the availability zone of the subnet, such as `use1-az1`.
+	AvailabilityZoneID string `mapstructure:"availability_zone_id"`
+	// The IPv4 CIDR block of the subnet.
+	CidrBlock string `mapstructure:"cidr_block"`
+	// The number of free IPv4 addresses of the subnet.
+	AvailableIPAddressCount int64 `mapstructure:"available_ip_address_count"`
+	// Whether instances launched in the subnet get a public IPv4 address.
+	MapPublicIPOnLaunch bool `mapstructure:"map_public_ip_on_launch"`
+	// The tags of the subnet.
+	Tags map[string]string `mapstructure:"tags"`
+}
+
+type DatasourceOutput struct {
+	// The ID of the VPC.
+	VpcId string `mapstructure:"vpc_id"`
+	// The primary IPv4 CIDR block of the VPC.
+	VpcCidrBlock string `mapstructure:"vpc_cidr_block"`
+	// The IDs of the subnets, from the one with the most free IP addresses.
+	SubnetIds []string `mapstructure:"subnet_ids"`
+	// The subnets, in the same order as `subnet_ids`. See
+	// [Subnets](#subnets).
+	Subnets []Subnet `mapstructure:"subnets"`
+	// The availability zones of the subnets, sorted.
+	AvailabilityZones []string `mapstructure:"availability_zones"`
+	// The IDs of the security groups matching `security_group_filter`,
+	// sorted.
+	SecurityGroupIds []string `mapstructure:"security_group_ids"`
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx := context.TODO()
+	client, err := d.config.NewEC2Client(ctx)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := d.network(ctx, client)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// network resolves the VPC, then its subnets and security groups.
+func (d *Datasource) network(ctx context.Context, client clients.Ec2Client) (DatasourceOutput, error) {
+	vpc, err := d.vpc(ctx, client)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+	output := DatasourceOutput{
+		VpcId:             aws.ToString(vpc.VpcId),
+		VpcCidrBlock:      aws.ToString(vpc.CidrBlock),
+		SubnetIds:         []string{},
+		Subnets:           []Subnet{},
+		AvailabilityZones: []string{},
+		SecurityGroupIds:  []string{},
+	}
+
+	subnets, err := d.config.SubnetFilter.GetFilteredSubnets(ctx, client, output.VpcId, d.config.InstanceType)
+	if err != nil {
+		return output, err
+	}
+	azs := map[string]bool{}
+	for _, subnet := range subnets {
+		output.SubnetIds = append(output.SubnetIds, aws.ToString(subnet.SubnetId))
+		output.Subnets = append(output.Subnets, subnetOutput(subnet))
+		azs[aws.ToString(subnet.AvailabilityZone)] = true
+	}
+	for az := range azs {
+		output.AvailabilityZones = append(output.AvailabilityZones, az)
+	}
+	sort.Strings(output.AvailabilityZones)
+
+	if !d.config.SecurityGroupFilter.Empty() {
+		groups, err := d.config.SecurityGroupFilter.GetFilteredSecurityGroups(ctx, client, output.VpcId)
+		if err != nil {
+			return output, err
+		}
+		for _, group := range groups {
+			output.SecurityGroupIds = append(output.SecurityGroupIds, aws.ToString(group.GroupId))
+		}
+		sort.Strings(output.SecurityGroupIds)
+	}
+	return output, nil
+}
+
+// vpc returns the VPC of vpc_id or vpc_filter, or the default VPC.
+func (d *Datasource) vpc(ctx context.Context, client clients.Ec2Client) (*ec2types.Vpc, error) {
+	if !d.config.VpcFilter.Empty() {
+		return d.config.VpcFilter.GetFilteredVpc(ctx, client)
+	}
+
+	params := &ec2.DescribeVpcsInput{}
+	if d.config.VpcId != "" {
+		params.VpcIds = []string{d.config.VpcId}
+	} else {
+		params.Filters = []ec2types.Filter{{Name: aws.String("is-default"), Values: []string{"true"}}}
+	}
+	resp, err := client.DescribeVpcs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying VPCs: %s", err)
+	}
+	if len(resp.Vpcs) != 1 {
+		if d.config.VpcId != "" {
+			return nil, fmt.Errorf("VPC %s not found", d.config.VpcId)
+		}
+		return nil, fmt.Errorf("No default VPC found, set vpc_id or vpc_filter")
+	}
+	return &resp.Vpcs[0], nil
+}
+
+func subnetOutput(subnet ec2types.Subnet) Subnet {
+	tags := make(map[string]string, len(subnet.Tags))
+	for _, tag := range subnet.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return Subnet{
+		ID:                      aws.ToString(subnet.SubnetId),
+		AvailabilityZone:        aws.ToString(subnet.AvailabilityZone),
+		AvailabilityZoneID:      aws.ToString(subnet.AvailabilityZoneId),
+		CidrBlock:               aws.ToString(subnet.CidrBlock),
+		AvailableIPAddressCount: int64(aws.ToInt32(subnet.AvailableIpAddressCount)),
+		MapPublicIPOnLaunch:     aws.ToBool(subnet.MapPublicIpOnLaunch),
+		Tags:                    tags,
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package vpcnetwork
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/acctest"
+)
+
+//go:embed test-fixtures/template.pkr.hcl
+var testDatasourceBasic string
+
+func TestAccDatasource_AmazonVpcNetwork(t *testing.T) {
+	t.Parallel()
+	testCase := &acctest.PluginTestCase{
+		Name:     "amazon_vpc_network_datasource_basic_test",
+		Template: testDatasourceBasic,
+		Check: func(buildCommand *exec.Cmd, logfile string) error {
+			if buildCommand.ProcessState != nil {
+				if buildCommand.ProcessState.ExitCode() != 0 {
+					return fmt.Errorf("Bad exit code. Logfile: %s", logfile)
+				}
+			}
+
+			logs, err := os.ReadFile(logfile)
+			if err != nil {
+				return fmt.Errorf("Unable to read %s", logfile)
+			}
+			if !regexp.MustCompile(`vpc-[0-9a-f]+ [0-9.]+/\d+ subnet-[0-9a-f]+ us-west-2[a-z]`).Match(logs) {
+				return fmt.Errorf("expected the default VPC and one of its subnets in logs, logfile: %s", logfile)
+			}
+			return nil
+		},
+	}
+	acctest.TestPlugin(t, testCase)
+}
@@ -0,0 +1,185 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package vpcnetwork
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+)
+
+// mockNetworkEC2 describes a default VPC with two subnets in two availability
+// zones, and one security group.
+type mockNetworkEC2 struct {
+	clients.Ec2Client
+
+	vpcsInput   *ec2.DescribeVpcsInput
+	groupsInput *ec2.DescribeSecurityGroupsInput
+}
+
+func (m *mockNetworkEC2) DescribeVpcs(ctx context.Context, input *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	m.vpcsInput = input
+	if len(input.VpcIds) > 0 && input.VpcIds[0] != "vpc-1" {
+		return &ec2.DescribeVpcsOutput{}, nil
+	}
+	return &ec2.DescribeVpcsOutput{
+		Vpcs: []types.Vpc{{VpcId: aws.String("vpc-1"), CidrBlock: aws.String("10.0.0.0/16")}},
+	}, nil
+}
+
+func (m *mockNetworkEC2) DescribeSubnets(ctx context.Context, input *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{
+		Subnets: []types.Subnet{
+			{
+				SubnetId:                aws.String("subnet-1"),
+				AvailabilityZone:        aws.String("us-east-1b"),
+				AvailabilityZoneId:      aws.String("use1-az2"),
+				CidrBlock:               aws.String("10.0.1.0/24"),
+				AvailableIpAddressCount: aws.Int32(100),
+				MapPublicIpOnLaunch:     aws.Bool(true),
+				Tags:                    []types.Tag{{Key: aws.String("Name"), Value: aws.String("public")}},
+			},
+			{
+				SubnetId:                aws.String("subnet-2"),
+				AvailabilityZone:        aws.String("us-east-1a"),
+				AvailabilityZoneId:      aws.String("use1-az1"),
+				CidrBlock:               aws.String("10.0.2.0/24"),
+				AvailableIpAddressCount: aws.Int32(200),
+				MapPublicIpOnLaunch:     aws.Bool(false),
+			},
+		},
+	}, nil
+}
+
+func (m *mockNetworkEC2) DescribeSecurityGroups(ctx context.Context, input *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	m.groupsInput = input
+	return &ec2.DescribeSecurityGroupsOutput{
+		SecurityGroups: []types.SecurityGroup{{GroupId: aws.String("sg-2")}, {GroupId: aws.String("sg-1")}},
+	}, nil
+}
+
+func TestDatasourceConfigure_FilterBlocks(t *testing.T) {
+	datasource := Datasource{
+		config: Config{
+			VpcFilter: awscommon.VpcFilterOptions{
+				NameValueFilter: config.NameValueFilter{
+					Filter: config.NameValues{{Name: "tag:Class", Value: "build"}},
+				},
+			},
+		},
+	}
+	if err := datasource.Configure(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if datasource.config.VpcFilter.Filters["tag:Class"] != "build" {
+		t.Errorf("filter blocks should be copied on filters, got %v", datasource.config.VpcFilter.Filters)
+	}
+}
+
+func TestDatasourceConfigure_Errors(t *testing.T) {
+	tests := map[string]Config{
+		"vpc_id and vpc_filter": {
+			VpcId: "vpc-1",
+			VpcFilter: awscommon.VpcFilterOptions{
+				NameValueFilter: config.NameValueFilter{Filters: map[string]string{"tag:Class": "build"}},
+			},
+		},
+		"most_free and random": {
+			SubnetFilter: awscommon.SubnetFilterOptions{MostFree: true, Random: true},
+		},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			datasource := Datasource{config: config}
+			if err := datasource.Configure(nil); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDatasource_Network(t *testing.T) {
+	datasource := Datasource{}
+	datasource.config.SecurityGroupFilter.Filters = map[string]string{"group-name": "build-*"}
+
+	client := &mockNetworkEC2{}
+	output, err := datasource.network(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := DatasourceOutput{
+		VpcId:        "vpc-1",
+		VpcCidrBlock: "10.0.0.0/16",
+		SubnetIds:    []string{"subnet-2", "subnet-1"},
+		Subnets: []Subnet{
+			{
+				ID:                      "subnet-2",
+				AvailabilityZone:        "us-east-1a",
+				AvailabilityZoneID:      "use1-az1",
+				CidrBlock:               "10.0.2.0/24",
+				AvailableIPAddressCount: 200,
+				Tags:                    map[string]string{},
+			},
+			{
+				ID:                      "subnet-1",
+				AvailabilityZone:        "us-east-1b",
+				AvailabilityZoneID:      "use1-az2",
+				CidrBlock:               "10.0.1.0/24",
+				AvailableIPAddressCount: 100,
+				MapPublicIPOnLaunch:     true,
+				Tags:                    map[string]string{"Name": "public"},
+			},
+		},
+		AvailabilityZones: []string{"us-east-1a", "us-east-1b"},
+		SecurityGroupIds:  []string{"sg-1", "sg-2"},
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %#v, want %#v", output, want)
+	}
+
+	if len(client.vpcsInput.Filters) != 1 || aws.ToString(client.vpcsInput.Filters[0].Name) != "is-default" {
+		t.Errorf("the default VPC should be used without vpc_id or vpc_filter")
+	}
+	groupFilters := map[string]string{}
+	for _, filter := range client.groupsInput.Filters {
+		groupFilters[aws.ToString(filter.Name)] = filter.Values[0]
+	}
+	if groupFilters["vpc-id"] != "vpc-1" {
+		t.Errorf("security groups should be of the VPC, got filters %v", groupFilters)
+	}
+}
+
+func TestDatasource_Network_NoSecurityGroupFilter(t *testing.T) {
+	datasource := Datasource{config: Config{VpcId: "vpc-1"}}
+
+	client := &mockNetworkEC2{}
+	output, err := datasource.network(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if client.groupsInput != nil {
+		t.Errorf("security groups should not be described without security_group_filter")
+	}
+	if output.SecurityGroupIds == nil || len(output.SecurityGroupIds) != 0 {
+		t.Errorf("expected no security groups, got %v", output.SecurityGroupIds)
+	}
+}
+
+func TestDatasource_Network_UnknownVpc(t *testing.T) {
+	datasource := Datasource{config: Config{VpcId: "vpc-404"}}
+
+	_, err := datasource.network(context.TODO(), &mockNetworkEC2{})
+	if err == nil || !strings.Contains(err.Error(), "vpc-404") {
+		t.Errorf("expected an error for an unknown VPC, got %v", err)
+	}
+}
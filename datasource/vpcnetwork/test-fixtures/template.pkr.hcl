@@ -0,0 +1,32 @@
+# Copyright IBM Corp. 2013, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+data "amazon-vpc-network" "test" {
+  region = "us-west-2"
+
+  subnet_filter {
+    filters = {
+      "default-for-az" = "true"
+    }
+  }
+}
+
+locals {
+  network = data.amazon-vpc-network.test
+}
+
+source "null" "basic-example" {
+  communicator = "none"
+}
+
+build {
+  sources = [
+    "source.null.basic-example"
+  ]
+
+  provisioner "shell-local" {
+    inline = [
+      "echo ${local.network.vpc_id} ${local.network.vpc_cidr_block} ${local.network.subnets[0].id} ${local.network.subnets[0].availability_zone}",
+    ]
+  }
+}
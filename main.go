@@ -13,9 +13,24 @@ import (
 	"github.com/hashicorp/packer-plugin-amazon/builder/ebsvolume"
 	"github.com/hashicorp/packer-plugin-amazon/builder/instance"
 	"github.com/hashicorp/packer-plugin-amazon/datasource/ami"
+	"github.com/hashicorp/packer-plugin-amazon/datasource/amis"
+	"github.com/hashicorp/packer-plugin-amazon/datasource/assumerole"
+	"github.com/hashicorp/packer-plugin-amazon/datasource/calleridentity"
+	"github.com/hashicorp/packer-plugin-amazon/datasource/instancetypes"
 	"github.com/hashicorp/packer-plugin-amazon/datasource/parameterstore"
 	"github.com/hashicorp/packer-plugin-amazon/datasource/secretsmanager"
+	"github.com/hashicorp/packer-plugin-amazon/datasource/vpcnetwork"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/amicopy"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/amiretention"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/amishare"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/amismoketest"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/amistore"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/ebsdirect"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/export"
 	amazonimport "github.com/hashicorp/packer-plugin-amazon/post-processor/import"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/launchtemplate"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/notify"
+	"github.com/hashicorp/packer-plugin-amazon/post-processor/ssmparameter"
 	"github.com/hashicorp/packer-plugin-amazon/version"
 	"github.com/hashicorp/packer-plugin-sdk/plugin"
 )
@@ -30,7 +45,22 @@ func main() {
 	pps.RegisterDatasource("ami", new(ami.Datasource))
 	pps.RegisterDatasource("secretsmanager", new(secretsmanager.Datasource))
 	pps.RegisterDatasource("parameterstore", new(parameterstore.Datasource))
+	pps.RegisterDatasource("amis", new(amis.Datasource))
+	pps.RegisterDatasource("caller-identity", new(calleridentity.Datasource))
+	pps.RegisterDatasource("assume-role", new(assumerole.Datasource))
+	pps.RegisterDatasource("vpc-network", new(vpcnetwork.Datasource))
+	pps.RegisterDatasource("instance-types", new(instancetypes.Datasource))
 	pps.RegisterPostProcessor("import", new(amazonimport.PostProcessor))
+	pps.RegisterPostProcessor("export", new(export.PostProcessor))
+	pps.RegisterPostProcessor("ebs-direct", new(ebsdirect.PostProcessor))
+	pps.RegisterPostProcessor("ami-share", new(amishare.PostProcessor))
+	pps.RegisterPostProcessor("ami-copy", new(amicopy.PostProcessor))
+	pps.RegisterPostProcessor("ssm-parameter", new(ssmparameter.PostProcessor))
+	pps.RegisterPostProcessor("launch-template", new(launchtemplate.PostProcessor))
+	pps.RegisterPostProcessor("ami-retention", new(amiretention.PostProcessor))
+	pps.RegisterPostProcessor("ami-smoke-test", new(amismoketest.PostProcessor))
+	pps.RegisterPostProcessor("ami-store", new(amistore.PostProcessor))
+	pps.RegisterPostProcessor("notify", new(notify.PostProcessor))
 	pps.SetVersion(version.PluginVersion)
 	err := pps.Run()
 	if err != nil {
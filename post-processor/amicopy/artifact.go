@@ -0,0 +1,78 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amicopy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// Artifact holds the copies of an AMI, by account and region.
+type Artifact struct {
+	// The copies of each account, with the credentials of its role.
+	Accounts map[string]*awscommon.Artifact
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (*Artifact) Files() []string {
+	return nil
+}
+
+// Id returns the copies as a sorted list of account:region:ami-id.
+func (a *Artifact) Id() string {
+	var parts []string
+	for account, copies := range a.Accounts {
+		for region, ami := range copies.Amis {
+			parts = append(parts, fmt.Sprintf("%s:%s:%s", account, region, ami))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (a *Artifact) String() string {
+	var lines []string
+	for account, copies := range a.Accounts {
+		for region, ami := range copies.Amis {
+			lines = append(lines, fmt.Sprintf("%s: %s: %s", account, region, ami))
+		}
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("AMIs were copied:\n%s\n", strings.Join(lines, "\n"))
+}
+
+// State exposes the copies as "amis", a map of accounts to maps of regions
+// to AMI IDs.
+func (a *Artifact) State(name string) interface{} {
+	if name != "amis" {
+		return nil
+	}
+	amis := make(map[string]map[string]string, len(a.Accounts))
+	for account, copies := range a.Accounts {
+		amis[account] = copies.Amis
+	}
+	return amis
+}
+
+// Destroy deregisters the copies and deletes their snapshots, in each
+// account.
+func (a *Artifact) Destroy() error {
+	errs := new(packersdk.MultiError)
+	for _, copies := range a.Accounts {
+		if err := copies.Destroy(); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amicopy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// target is a destination along with the account its role belongs to.
+type target struct {
+	destination *Destination
+	account     string
+	awsConfig   *aws.Config
+	// regionConn returns a client of the destination account in region.
+	regionConn func(region string) (clients.Ec2Client, error)
+}
+
+// copy shares ami and the keys of its snapshots with the accounts of
+// targets, copies it to every region of every target from the target side,
+// and waits for the copies.
+func (p *PostProcessor) copy(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, keys kmsAPI, sourceAccount, sourceRegion, ami string,
+	targets []*target) (*Artifact, error) {
+	images, err := conn.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{ami}})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to describe AMI %s: %s", ami, err)
+	}
+	if len(images.Images) == 0 {
+		return nil, fmt.Errorf("AMI %s not found in %s", ami, sourceRegion)
+	}
+	image := &images.Images[0]
+
+	// The copies in the source account need no permission.
+	var accounts []string
+	for _, t := range targets {
+		if t.account != sourceAccount && !contains(accounts, t.account) {
+			accounts = append(accounts, t.account)
+		}
+	}
+	if len(accounts) > 0 {
+		// Checked first, an AMI with an AWS managed key cannot be copied.
+		grants, err := shareKeys(ctx, ui, conn, keys, image, accounts)
+		if err != nil {
+			if err := revokeGrants(ctx, ui, keys, grants); err != nil {
+				ui.Error(err.Error())
+			}
+			return nil, err
+		}
+		if !p.config.KeepShared {
+			defer func() {
+				if err := revokeGrants(ctx, ui, keys, grants); err != nil {
+					ui.Error(err.Error())
+				}
+			}()
+		}
+
+		if err := p.modifyPermissions(ctx, ui, conn, image, accounts, false); err != nil {
+			return nil, err
+		}
+		if !p.config.KeepShared {
+			defer func() {
+				if err := p.modifyPermissions(ctx, ui, conn, image, accounts, true); err != nil {
+					ui.Error(err.Error())
+				}
+			}()
+		}
+	}
+
+	artifact := &Artifact{Accounts: map[string]*awscommon.Artifact{}}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	errs := new(packersdk.MultiError)
+	for _, t := range targets {
+		regions := t.destination.Regions
+		if len(regions) == 0 {
+			regions = []string{sourceRegion}
+		}
+		accountArtifact, ok := artifact.Accounts[t.account]
+		if !ok {
+			accountArtifact = &awscommon.Artifact{
+				Amis:           map[string]string{},
+				BuilderIdValue: BuilderId,
+				Config:         t.awsConfig,
+			}
+			artifact.Accounts[t.account] = accountArtifact
+		}
+
+		for _, region := range regions {
+			wg.Add(1)
+			ui.Message(fmt.Sprintf("Copying to account %s in %s", t.account, region))
+			go func(t *target, region string) {
+				defer wg.Done()
+				id, err := p.copyImage(ctx, t, image, sourceRegion, region)
+
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("account %s, region %s: %s", t.account, region, err))
+					return
+				}
+				accountArtifact.Amis[region] = id
+			}(t, region)
+		}
+	}
+	ui.Say(fmt.Sprintf("Waiting for the copies of AMI %s to complete", ami))
+	wg.Wait()
+
+	if len(errs.Errors) > 0 {
+		return nil, errs
+	}
+	return artifact, nil
+}
+
+// modifyPermissions shares image and its snapshots with accounts, or
+// stops sharing them when remove is set.
+func (p *PostProcessor) modifyPermissions(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, image *ec2types.Image,
+	accounts []string, remove bool) error {
+	ami := aws.ToString(image.ImageId)
+	var launch []ec2types.LaunchPermission
+	var createVolume []ec2types.CreateVolumePermission
+	for _, account := range accounts {
+		launch = append(launch, ec2types.LaunchPermission{UserId: aws.String(account)})
+		createVolume = append(createVolume, ec2types.CreateVolumePermission{UserId: aws.String(account)})
+	}
+
+	launchModifications := &ec2types.LaunchPermissionModifications{}
+	createVolumeModifications := &ec2types.CreateVolumePermissionModifications{}
+	if remove {
+		ui.Say(fmt.Sprintf("Unsharing AMI %s with accounts %v", ami, accounts))
+		launchModifications.Remove = launch
+		createVolumeModifications.Remove = createVolume
+	} else {
+		ui.Say(fmt.Sprintf("Sharing AMI %s with accounts %v", ami, accounts))
+		launchModifications.Add = launch
+		createVolumeModifications.Add = createVolume
+	}
+
+	_, err := conn.ModifyImageAttribute(ctx, &ec2.ModifyImageAttributeInput{
+		ImageId:          aws.String(ami),
+		LaunchPermission: launchModifications,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to modify the launch permissions of AMI %s: %s", ami, err)
+	}
+	for _, bdm := range image.BlockDeviceMappings {
+		if bdm.Ebs == nil || bdm.Ebs.SnapshotId == nil {
+			continue
+		}
+		_, err := conn.ModifySnapshotAttribute(ctx, &ec2.ModifySnapshotAttributeInput{
+			SnapshotId:             bdm.Ebs.SnapshotId,
+			CreateVolumePermission: createVolumeModifications,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to modify the create volume permissions of snapshot %s: %s", aws.ToString(bdm.Ebs.SnapshotId), err)
+		}
+	}
+	return nil
+}
+
+// copyImage copies image to region with the credentials of t, and waits
+// for the copy to be available.
+func (p *PostProcessor) copyImage(ctx context.Context, t *target, image *ec2types.Image, sourceRegion, region string) (string, error) {
+	d := t.destination
+	regionConn, err := t.regionConn(region)
+	if err != nil {
+		return "", err
+	}
+
+	// Render the name and tags like the builders do, about the source AMI.
+	state := new(multistep.BasicStateBag)
+	state.Put("source_image", image)
+	ictx := p.config.ctx
+	ictx.Data = buildInfo(image, region)
+	name := aws.ToString(image.Name)
+	if d.AMIName != "" {
+		name, err = interpolate.Render(d.AMIName, &ictx)
+		if err != nil {
+			return "", fmt.Errorf("Error interpolating ami_name: %s", err)
+		}
+	}
+	tags, err := awscommon.TagMap(d.Tags).EC2Tags(p.config.ctx, region, state)
+	if err != nil {
+		return "", err
+	}
+
+	input := &ec2.CopyImageInput{
+		SourceRegion:  aws.String(sourceRegion),
+		SourceImageId: image.ImageId,
+		Name:          aws.String(name),
+		Description:   image.Description,
+	}
+	keyID := d.KMSKeyID
+	if regionKeyID, ok := d.RegionKMSKeyIDs[region]; ok {
+		keyID = regionKeyID
+	}
+	if d.Encrypt || keyID != "" {
+		input.Encrypted = aws.Bool(true)
+	}
+	if keyID != "" {
+		input.KmsKeyId = aws.String(keyID)
+	}
+	if len(tags) > 0 {
+		input.TagSpecifications = tags.TagSpecifications(ec2types.ResourceTypeImage, ec2types.ResourceTypeSnapshot)
+	}
+
+	resp, err := regionConn.CopyImage(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("Failed to copy AMI %s: %s", aws.ToString(image.ImageId), err)
+	}
+	id := aws.ToString(resp.ImageId)
+
+	if err := p.config.PollingConfig.WaitUntilAMIAvailable(ctx, regionConn, id); err != nil {
+		return "", fmt.Errorf("Failed waiting for AMI %s: %s", id, err)
+	}
+	return id, nil
+}
+
+// buildInfo returns the template variables of the builders about the
+// source AMI image, for the names of its copies.
+func buildInfo(image *ec2types.Image, region string) *awscommon.BuildInfoTemplate {
+	tags := make(map[string]string, len(image.Tags))
+	for _, tag := range image.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return &awscommon.BuildInfoTemplate{
+		BuildRegion:           region,
+		SourceAMI:             aws.ToString(image.ImageId),
+		SourceAMICreationDate: aws.ToString(image.CreationDate),
+		SourceAMIName:         aws.ToString(image.Name),
+		SourceAMIOwner:        aws.ToString(image.OwnerId),
+		SourceAMIOwnerName:    aws.ToString(image.ImageOwnerAlias),
+		SourceAMITags:         tags,
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amicopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// kmsAPI is the part of KMS needed to let the destination accounts use the
+// key of an encrypted source AMI.
+type kmsAPI interface {
+	DescribeKey(ctx context.Context, params *kms.DescribeKeyInput, optFns ...func(*kms.Options)) (*kms.DescribeKeyOutput, error)
+	CreateGrant(ctx context.Context, params *kms.CreateGrantInput, optFns ...func(*kms.Options)) (*kms.CreateGrantOutput, error)
+	RevokeGrant(ctx context.Context, params *kms.RevokeGrantInput, optFns ...func(*kms.Options)) (*kms.RevokeGrantOutput, error)
+}
+
+// grant is a grant created on a key of the source AMI.
+type grant struct {
+	keyArn string
+	id     string
+}
+
+// shareKeys lets accounts use the keys of the encrypted snapshots of image,
+// with grants named like the ones of the amazon-ami-share post-processor,
+// and returns the grants created, even on failure. AMIs encrypted with an
+// AWS managed key cannot be copied to other accounts.
+func shareKeys(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, keys kmsAPI, image *ec2types.Image,
+	accounts []string) ([]grant, error) {
+	ami := aws.ToString(image.ImageId)
+	var snapshotIDs []string
+	for _, bdm := range image.BlockDeviceMappings {
+		if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+			snapshotIDs = append(snapshotIDs, *bdm.Ebs.SnapshotId)
+		}
+	}
+	if len(snapshotIDs) == 0 {
+		return nil, nil
+	}
+
+	snapshots, err := conn.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: snapshotIDs})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to describe the snapshots of AMI %s: %s", ami, err)
+	}
+	var keyIDs []string
+	for _, snapshot := range snapshots.Snapshots {
+		keyID := aws.ToString(snapshot.KmsKeyId)
+		if aws.ToBool(snapshot.Encrypted) && keyID != "" && !contains(keyIDs, keyID) {
+			keyIDs = append(keyIDs, keyID)
+		}
+	}
+
+	var grants []grant
+	for _, keyID := range keyIDs {
+		described, err := keys.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+		if err != nil {
+			return grants, fmt.Errorf("Failed to describe KMS key %s: %s", keyID, err)
+		}
+		keyArn := aws.ToString(described.KeyMetadata.Arn)
+		if described.KeyMetadata.KeyManager == kmstypes.KeyManagerTypeAws {
+			return grants, fmt.Errorf("The snapshots of AMI %s are encrypted with the AWS managed key %s, which cannot be "+
+				"shared with other accounts. Copy the AMI within its account with a customer managed key first.", ami, keyArn)
+		}
+		parsed, err := arn.Parse(keyArn)
+		if err != nil {
+			return grants, fmt.Errorf("Invalid ARN %q of KMS key %s: %s", keyArn, keyID, err)
+		}
+
+		for _, account := range accounts {
+			ui.Message(fmt.Sprintf("Granting account %s the use of KMS key %s", account, keyArn))
+			created, err := keys.CreateGrant(ctx, &kms.CreateGrantInput{
+				KeyId:            aws.String(keyArn),
+				GranteePrincipal: aws.String(fmt.Sprintf("arn:%s:iam::%s:root", parsed.Partition, account)),
+				Operations:       awscommon.AMIShareGrantOperations,
+				Name:             aws.String(awscommon.AMIShareGrantName(ami)),
+			})
+			if err != nil {
+				return grants, fmt.Errorf("Failed to grant account %s the use of KMS key %s: %s", account, keyArn, err)
+			}
+			grants = append(grants, grant{keyArn: keyArn, id: aws.ToString(created.GrantId)})
+		}
+	}
+	return grants, nil
+}
+
+// revokeGrants revokes the grants created by shareKeys.
+func revokeGrants(ctx context.Context, ui packersdk.Ui, keys kmsAPI, grants []grant) error {
+	for _, g := range grants {
+		ui.Message(fmt.Sprintf("Revoking grant %s of KMS key %s", g.id, g.keyArn))
+		_, err := keys.RevokeGrant(ctx, &kms.RevokeGrantInput{KeyId: aws.String(g.keyArn), GrantId: aws.String(g.id)})
+		if err != nil {
+			return fmt.Errorf("Failed to revoke grant %s of KMS key %s: %s", g.id, g.keyArn, err)
+		}
+	}
+	return nil
+}
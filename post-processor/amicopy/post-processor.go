@@ -0,0 +1,213 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,Destination
+
+// Package amicopy contains a post-processor copying an AMI to other
+// accounts and regions, assuming a role in each destination account.
+package amicopy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-ami-copy"
+
+// Destination is an account the AMI is copied to, and the regions of that
+// account it is copied to.
+type Destination struct {
+	// The role to assume in the destination account, with the credentials
+	// of the post-processor. The AMI is copied by this role, so the copies
+	// belong to its account. See the
+	// [AssumeRoleConfig](#assume-role-configuration) for the options. The
+	// `role_arn` is required.
+	AssumeRole awscommon.AssumeRoleConfig `mapstructure:"assume_role" required:"true"`
+	// The regions of the destination account to copy the AMI to. Defaults to
+	// the region of the source AMI.
+	Regions []string `mapstructure:"regions" required:"false"`
+	// The name of the copies. Defaults to the name of the source AMI. The
+	// template variables of the `ami_name` of the builders, like
+	// `{{ .SourceAMIName }}`, refer to the source AMI.
+	AMIName string `mapstructure:"ami_name" required:"false"`
+	// Whether to encrypt the snapshots of the copies. Defaults to `false`,
+	// the copies are then encrypted only if the source AMI is, with the
+	// default EBS key of the destination account.
+	Encrypt bool `mapstructure:"encrypt_boot" required:"false"`
+	// The KMS key to encrypt the snapshots of the copies with, in every
+	// region of the destination. Implies `encrypt_boot`. An alias or a key ID
+	// is looked up in the destination account.
+	KMSKeyID string `mapstructure:"kms_key_id" required:"false"`
+	// The KMS key to encrypt the snapshots of the copies with, by region.
+	// Takes precedence over `kms_key_id`. Implies `encrypt_boot`.
+	RegionKMSKeyIDs map[string]string `mapstructure:"region_kms_key_ids" required:"false"`
+	// Key/value pair tags applied to the copies and their snapshots. The
+	// template variables of the builders, like `{{ .SourceAMI }}`, refer to
+	// the source AMI.
+	Tags map[string]string `mapstructure:"tags" required:"false"`
+}
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The accounts to copy the AMI to. Can be repeated.
+	Destinations []Destination `mapstructure:"destination" required:"true"`
+	// Keep the source AMI and its snapshots shared with the destination
+	// accounts once the copies are done. By default the launch and create
+	// volume permissions given for the copies are removed. Default `false`.
+	KeepShared bool `mapstructure:"keep_shared" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"destination",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if len(p.config.Destinations) == 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("at least one destination must be set"))
+	}
+	for i, d := range p.config.Destinations {
+		if d.AssumeRole.AssumeRoleARN == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("destination %d: assume_role.role_arn must be set", i))
+		}
+		if d.KMSKeyID != "" && !awscommon.ValidateKmsKey(d.KMSKeyID) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("destination %d: %q is not a valid KMS Key Id.", i, d.KMSKeyID))
+		}
+		for region, keyID := range d.RegionKMSKeyIDs {
+			if len(d.Regions) > 0 && !contains(d.Regions, region) {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("destination %d: region %s in region_kms_key_ids is not in regions", i, region))
+			}
+			if !awscommon.ValidateKmsKey(keyID) {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("destination %d: %q is not a valid KMS Key Id.", i, keyID))
+			}
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// accountID returns the account of the credentials of awsConfig.
+func accountID(ctx context.Context, awsConfig *aws.Config) (string, error) {
+	identity, err := sts.NewFromConfig(*awsConfig).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(identity.Account), nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	ami, ok := amis[awsConfig.Region]
+	if !ok {
+		regions := make([]string, 0, len(amis))
+		for region := range amis {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+		return nil, false, false, fmt.Errorf("The artifact has no AMI in region %s, set region to one of: %s",
+			awsConfig.Region, strings.Join(regions, ", "))
+	}
+
+	ec2Client, err := p.config.NewEC2Client(ctx)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to create EC2 client: %s", err)
+	}
+	sourceAccount, err := accountID(ctx, awsConfig)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Failed to get the account of the source AMI: %s", err)
+	}
+
+	var targets []*target
+	for i := range p.config.Destinations {
+		d := &p.config.Destinations[i]
+		accessConfig := p.config.AccessConfig.WithAssumeRole(d.AssumeRole)
+		destConfig, err := accessConfig.Config(ctx)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("Failed to assume role %s: %s", d.AssumeRole.AssumeRoleARN, err)
+		}
+		account, err := accountID(ctx, destConfig)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("Failed to get the account of role %s: %s", d.AssumeRole.AssumeRoleARN, err)
+		}
+		targets = append(targets, &target{
+			destination: d,
+			account:     account,
+			awsConfig:   destConfig,
+			regionConn: func(region string) (clients.Ec2Client, error) {
+				return awscommon.GetRegionConn(ctx, accessConfig, region)
+			},
+		})
+	}
+
+	keys := kms.NewFromConfig(*awsConfig)
+	copied, err := p.copy(ctx, ui, ec2Client, keys, sourceAccount, awsConfig.Region, ami, targets)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	// The source AMI is still there, destroying it is up to
+	// keep_input_artifact.
+	return copied, true, false, nil
+}
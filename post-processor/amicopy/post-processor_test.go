@@ -0,0 +1,351 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amicopy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key": "foo",
+		"secret_key": "bar",
+		"region":     "us-east-1",
+		"destination": []map[string]interface{}{
+			{"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/staging"}},
+		},
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	p.config.PollingConfig = &awscommon.AWSPollingConfig{DelaySeconds: 1}
+	return &p
+}
+
+// mockSourceEC2 is the account of the source AMI.
+type mockSourceEC2 struct {
+	clients.Ec2Client
+
+	// keyID encrypts the snapshot when set.
+	keyID string
+
+	launchPermissions []*ec2types.LaunchPermissionModifications
+	snapshotShares    int
+	snapshotUnshares  int
+}
+
+func (m *mockSourceEC2) DescribeSnapshots(ctx context.Context, input *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error) {
+	var snapshots []ec2types.Snapshot
+	for _, id := range input.SnapshotIds {
+		snapshots = append(snapshots, ec2types.Snapshot{
+			SnapshotId: aws.String(id),
+			Encrypted:  aws.Bool(m.keyID != ""),
+			KmsKeyId:   aws.String(m.keyID),
+		})
+	}
+	return &ec2.DescribeSnapshotsOutput{Snapshots: snapshots}, nil
+}
+
+func (m *mockSourceEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId:     aws.String(input.ImageIds[0]),
+		Name:        aws.String("app-1.2.3"),
+		Description: aws.String("app"),
+		BlockDeviceMappings: []ec2types.BlockDeviceMapping{
+			{DeviceName: aws.String("/dev/sda1"), Ebs: &ec2types.EbsBlockDevice{SnapshotId: aws.String("snap-1")}},
+		},
+	}}}, nil
+}
+
+func (m *mockSourceEC2) ModifyImageAttribute(ctx context.Context, input *ec2.ModifyImageAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyImageAttributeOutput, error) {
+	m.launchPermissions = append(m.launchPermissions, input.LaunchPermission)
+	return &ec2.ModifyImageAttributeOutput{}, nil
+}
+
+func (m *mockSourceEC2) ModifySnapshotAttribute(ctx context.Context, input *ec2.ModifySnapshotAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifySnapshotAttributeOutput, error) {
+	if len(input.CreateVolumePermission.Add) > 0 {
+		m.snapshotShares++
+	}
+	if len(input.CreateVolumePermission.Remove) > 0 {
+		m.snapshotUnshares++
+	}
+	return &ec2.ModifySnapshotAttributeOutput{}, nil
+}
+
+// mockKMS holds the key of the source AMI.
+type mockKMS struct {
+	keyManager kmstypes.KeyManagerType
+	created    []*kms.CreateGrantInput
+	revoked    []string
+}
+
+func (m *mockKMS) DescribeKey(ctx context.Context, input *kms.DescribeKeyInput, optFns ...func(*kms.Options)) (*kms.DescribeKeyOutput, error) {
+	return &kms.DescribeKeyOutput{
+		KeyMetadata: &kmstypes.KeyMetadata{Arn: aws.String(testKeyArn), KeyManager: m.keyManager},
+	}, nil
+}
+
+func (m *mockKMS) CreateGrant(ctx context.Context, input *kms.CreateGrantInput, optFns ...func(*kms.Options)) (*kms.CreateGrantOutput, error) {
+	m.created = append(m.created, input)
+	return &kms.CreateGrantOutput{GrantId: aws.String(fmt.Sprintf("grant-%d", len(m.created)))}, nil
+}
+
+func (m *mockKMS) RevokeGrant(ctx context.Context, input *kms.RevokeGrantInput, optFns ...func(*kms.Options)) (*kms.RevokeGrantOutput, error) {
+	m.revoked = append(m.revoked, aws.ToString(input.GrantId))
+	return &kms.RevokeGrantOutput{}, nil
+}
+
+// mockDestinationEC2 is a region of a destination account.
+type mockDestinationEC2 struct {
+	clients.Ec2Client
+
+	account string
+	region  string
+	fail    bool
+
+	lock   *sync.Mutex
+	copies map[string]*ec2.CopyImageInput
+}
+
+func (m *mockDestinationEC2) CopyImage(ctx context.Context, input *ec2.CopyImageInput, optFns ...func(*ec2.Options)) (*ec2.CopyImageOutput, error) {
+	if m.fail {
+		return nil, fmt.Errorf("UnauthorizedOperation")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id := fmt.Sprintf("ami-%s-%s", m.account, m.region)
+	m.copies[id] = input
+	return &ec2.CopyImageOutput{ImageId: aws.String(id)}, nil
+}
+
+func (m *mockDestinationEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId: aws.String(input.ImageIds[0]),
+		State:   ec2types.ImageStateAvailable,
+	}}}, nil
+}
+
+func testTargets(p *PostProcessor, accounts []string, failRegion string) ([]*target, map[string]*ec2.CopyImageInput) {
+	lock := new(sync.Mutex)
+	copies := map[string]*ec2.CopyImageInput{}
+	var targets []*target
+	for i, account := range accounts {
+		account := account
+		targets = append(targets, &target{
+			destination: &p.config.Destinations[i],
+			account:     account,
+			awsConfig:   &aws.Config{},
+			regionConn: func(region string) (clients.Ec2Client, error) {
+				return &mockDestinationEC2{
+					account: account, region: region, fail: region == failRegion,
+					lock: lock, copies: copies,
+				}, nil
+			},
+		})
+	}
+	return targets, copies
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessor_ImplementsArtifact(t *testing.T) {
+	var _ packersdk.Artifact = new(Artifact)
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string][]map[string]interface{}{
+		"no destination":     {},
+		"missing role":       {{"regions": []string{"us-east-1"}}},
+		"invalid kms key":    {{"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/staging"}, "kms_key_id": "not a key"}},
+		"kms key for region": {{"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/staging"}, "regions": []string{"us-east-1"}, "region_kms_key_ids": map[string]string{"eu-west-1": "alias/prod"}}},
+	}
+	for name, destinations := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			c["destination"] = destinations
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessorConfigure_DestinationNotInterpolated(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"destination": []map[string]interface{}{{
+			"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/staging"},
+			"ami_name":    "{{ .SourceAMIName }}-staging",
+		}},
+	})
+	if p.config.Destinations[0].AMIName != "{{ .SourceAMIName }}-staging" {
+		t.Errorf("expected ami_name to be rendered for each copy, got %q", p.config.Destinations[0].AMIName)
+	}
+}
+
+func TestPostProcessor_copy(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"destination": []map[string]interface{}{
+			{
+				"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/staging"},
+				"ami_name":    "{{ .SourceAMIName }}-staging",
+				"tags":        map[string]string{"Source": "{{ .SourceAMI }}"},
+			},
+			{
+				"assume_role":        map[string]interface{}{"role_arn": "arn:aws:iam::333333333333:role/prod"},
+				"regions":            []string{"us-east-1", "eu-west-1"},
+				"kms_key_id":         "alias/prod",
+				"region_kms_key_ids": map[string]string{"eu-west-1": "alias/prod-eu"},
+			},
+			{
+				"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::111111111111:role/build"},
+				"regions":     []string{"us-west-2"},
+			},
+		},
+	})
+	source := &mockSourceEC2{}
+	targets, copies := testTargets(p, []string{"222222222222", "333333333333", "111111111111"}, "")
+
+	artifact, err := p.copy(context.Background(), &packersdk.MockUi{}, source, &mockKMS{}, "111111111111", "us-east-1", "ami-source", targets)
+	if err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+
+	want := "111111111111:us-west-2:ami-111111111111-us-west-2," +
+		"222222222222:us-east-1:ami-222222222222-us-east-1," +
+		"333333333333:eu-west-1:ami-333333333333-eu-west-1," +
+		"333333333333:us-east-1:ami-333333333333-us-east-1"
+	if artifact.Id() != want {
+		t.Errorf("unexpected artifact ID:\n%s\nwant:\n%s", artifact.Id(), want)
+	}
+
+	// The source account is not shared with itself, and the permissions are
+	// removed once the copies are done.
+	if len(source.launchPermissions) != 2 {
+		t.Fatalf("expected the AMI to be shared then unshared, got %+v", source.launchPermissions)
+	}
+	if added := source.launchPermissions[0].Add; len(added) != 2 {
+		t.Errorf("expected the AMI to be shared with the 2 other accounts, got %+v", added)
+	}
+	if removed := source.launchPermissions[1].Remove; len(removed) != 2 {
+		t.Errorf("expected the AMI to be unshared with the 2 other accounts, got %+v", removed)
+	}
+	if source.snapshotShares != 1 || source.snapshotUnshares != 1 {
+		t.Errorf("expected the snapshot to be shared then unshared, got %d and %d", source.snapshotShares, source.snapshotUnshares)
+	}
+
+	staging := copies["ami-222222222222-us-east-1"]
+	if aws.ToString(staging.Name) != "app-1.2.3-staging" || aws.ToString(staging.SourceImageId) != "ami-source" ||
+		aws.ToString(staging.SourceRegion) != "us-east-1" || staging.Encrypted != nil {
+		t.Errorf("unexpected staging copy %+v", staging)
+	}
+	if len(staging.TagSpecifications) != 2 || aws.ToString(staging.TagSpecifications[0].Tags[0].Value) != "ami-source" {
+		t.Errorf("expected the copy and its snapshots to be tagged, got %+v", staging.TagSpecifications)
+	}
+	if prod := copies["ami-333333333333-us-east-1"]; aws.ToString(prod.KmsKeyId) != "alias/prod" || !aws.ToBool(prod.Encrypted) {
+		t.Errorf("expected the prod copy to be encrypted with alias/prod, got %+v", prod)
+	}
+	if prodEU := copies["ami-333333333333-eu-west-1"]; aws.ToString(prodEU.KmsKeyId) != "alias/prod-eu" {
+		t.Errorf("expected the eu-west-1 prod copy to be encrypted with alias/prod-eu, got %q", aws.ToString(prodEU.KmsKeyId))
+	}
+
+	amis := artifact.State("amis").(map[string]map[string]string)
+	if amis["333333333333"]["eu-west-1"] != "ami-333333333333-eu-west-1" {
+		t.Errorf("unexpected amis state %v", amis)
+	}
+}
+
+func TestPostProcessor_copy_KeepShared(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{"keep_shared": true})
+	source := &mockSourceEC2{}
+	targets, _ := testTargets(p, []string{"222222222222"}, "")
+
+	if _, err := p.copy(context.Background(), &packersdk.MockUi{}, source, &mockKMS{}, "111111111111", "us-east-1", "ami-source", targets); err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+	if len(source.launchPermissions) != 1 || source.snapshotUnshares != 0 {
+		t.Errorf("expected the AMI to stay shared, got %+v", source.launchPermissions)
+	}
+}
+
+func TestPostProcessor_copy_Failure(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	source := &mockSourceEC2{}
+	targets, _ := testTargets(p, []string{"222222222222"}, "us-east-1")
+
+	_, err := p.copy(context.Background(), &packersdk.MockUi{}, source, &mockKMS{}, "111111111111", "us-east-1", "ami-source", targets)
+	if err == nil || !strings.Contains(err.Error(), "account 222222222222, region us-east-1") {
+		t.Fatalf("expected the failed copy to be reported, got %v", err)
+	}
+	if len(source.launchPermissions) != 2 {
+		t.Errorf("expected the AMI to be unshared after a failure, got %+v", source.launchPermissions)
+	}
+}
+
+const testKeyArn = "arn:aws:kms:us-east-1:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+func TestPostProcessor_copy_EncryptedSource(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"destination": []map[string]interface{}{
+			{"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::222222222222:role/staging"}},
+			{"assume_role": map[string]interface{}{"role_arn": "arn:aws:iam::111111111111:role/build"}},
+		},
+	})
+	source := &mockSourceEC2{keyID: "1234abcd-12ab-34cd-56ef-1234567890ab"}
+	keys := &mockKMS{keyManager: kmstypes.KeyManagerTypeCustomer}
+	targets, _ := testTargets(p, []string{"222222222222", "111111111111"}, "")
+
+	if _, err := p.copy(context.Background(), &packersdk.MockUi{}, source, keys, "111111111111", "us-east-1", "ami-source", targets); err != nil {
+		t.Fatalf("copy() failed: %s", err)
+	}
+	if len(keys.created) != 1 {
+		t.Fatalf("expected a grant for the other account only, got %d", len(keys.created))
+	}
+	created := keys.created[0]
+	if aws.ToString(created.GranteePrincipal) != "arn:aws:iam::222222222222:root" ||
+		aws.ToString(created.Name) != "packer-ami-share-ami-source" || aws.ToString(created.KeyId) != testKeyArn {
+		t.Errorf("unexpected grant %+v", created)
+	}
+	if len(keys.revoked) != 1 || keys.revoked[0] != "grant-1" {
+		t.Errorf("expected the grant to be revoked once the copies are done, got %v", keys.revoked)
+	}
+}
+
+func TestPostProcessor_copy_AWSManagedKey(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	source := &mockSourceEC2{keyID: "alias/aws/ebs"}
+	targets, copies := testTargets(p, []string{"222222222222"}, "")
+
+	_, err := p.copy(context.Background(), &packersdk.MockUi{}, source, &mockKMS{keyManager: kmstypes.KeyManagerTypeAws},
+		"111111111111", "us-east-1", "ami-source", targets)
+	if err == nil || !strings.Contains(err.Error(), "AWS managed key") {
+		t.Fatalf("expected an error for an AMI encrypted with the AWS managed key, got %v", err)
+	}
+	if len(source.launchPermissions) != 0 || len(copies) != 0 {
+		t.Errorf("expected the AMI to be neither shared nor copied, got %+v and %v", source.launchPermissions, copies)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package amiretention contains a post-processor deprecating, disabling or
+// deregistering the older AMIs of the family of the AMIs of an artifact.
+package amiretention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-ami-retention"
+
+const (
+	actionDeprecate  = "deprecate"
+	actionDisable    = "disable"
+	actionDeregister = "deregister"
+)
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The AMIs of the family are the AMIs owned by the account whose name
+	// starts with this prefix, such as `ubuntu-22.04-`.
+	FamilyNamePrefix string `mapstructure:"family_name_prefix" required:"false"`
+	// The AMIs of the family are the AMIs owned by the account with this tag
+	// set to the value it has on the AMI of the artifact, such as
+	// `ami_family`.
+	//
+	// Exactly one of `family_name_prefix` or `family_tag` must be set.
+	FamilyTag string `mapstructure:"family_tag" required:"false"`
+	// The number of AMIs of the family to keep in each region, the newest
+	// ones, including the AMI of the artifact.
+	KeepCount int `mapstructure:"keep_count" required:"false"`
+	// Keep the AMIs of the family created less than this duration ago, such
+	// as `720h`.
+	//
+	// At least one of `keep_count` or `keep_newer_than` must be set. An AMI
+	// is kept if either of them keeps it.
+	KeepNewerThan time.Duration `mapstructure:"keep_newer_than" required:"false"`
+	// What to do with the AMIs that are not kept: `deprecate` sets their
+	// deprecation time, `disable` disables them, and `deregister`
+	// deregisters them and deletes their snapshots. Defaults to `deprecate`.
+	Action string `mapstructure:"action" required:"false"`
+	// The time to deprecate the AMIs at with the `deprecate` action, in
+	// UTC, in the following format: YYYY-MM-DDTHH:MM:SSZ. Defaults to
+	// deprecating them right away.
+	DeprecationTime string `mapstructure:"deprecate_at" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Action == "" {
+		p.config.Action = actionDeprecate
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if (p.config.FamilyNamePrefix == "") == (p.config.FamilyTag == "") {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("exactly one of family_name_prefix or family_tag must be set"))
+	}
+	if p.config.KeepCount < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid keep_count %d, must be positive", p.config.KeepCount))
+	}
+	if p.config.KeepNewerThan < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid keep_newer_than %s, must be positive", p.config.KeepNewerThan))
+	}
+	if p.config.KeepCount == 0 && p.config.KeepNewerThan == 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("at least one of keep_count or keep_newer_than must be set"))
+	}
+	switch p.config.Action {
+	case actionDeprecate, actionDisable, actionDeregister:
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid action %q, must be one of deprecate, disable or deregister", p.config.Action))
+	}
+	if p.config.DeprecationTime != "" {
+		if p.config.Action != actionDeprecate {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("deprecate_at can only be set with the deprecate action"))
+		}
+		if _, err := time.Parse(time.RFC3339, p.config.DeprecationTime); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"deprecate_at is not a valid time: %q. Expect time format: YYYY-MM-DDTHH:MM:SSZ",
+				p.config.DeprecationTime))
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := make([]string, 0, len(amis))
+	for region := range amis {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	for _, region := range regions {
+		conn, err := awscommon.GetRegionConn(ctx, &p.config.AccessConfig, region)
+		if err != nil {
+			return nil, false, false, err
+		}
+		if err := p.prune(ctx, ui, conn, region, amis[region], time.Now()); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	// The AMIs of the artifact are always kept, pass them on to the next
+	// post-processors.
+	return artifact, true, false, nil
+}
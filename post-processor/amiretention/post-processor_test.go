@@ -0,0 +1,348 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amiretention
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var testNow = time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":         "foo",
+		"secret_key":         "bar",
+		"region":             "us-east-1",
+		"family_name_prefix": "web-",
+		"keep_count":         2,
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+func testImage(id string, age time.Duration) ec2types.Image {
+	return ec2types.Image{
+		ImageId:      aws.String(id),
+		Name:         aws.String("web-" + id),
+		CreationDate: aws.String(testNow.Add(-age).Format(time.RFC3339)),
+		State:        ec2types.ImageStateAvailable,
+		Tags:         []ec2types.Tag{{Key: aws.String("ami_family"), Value: aws.String("web")}},
+		BlockDeviceMappings: []ec2types.BlockDeviceMapping{{
+			DeviceName: aws.String("/dev/sda1"),
+			Ebs:        &ec2types.EbsBlockDevice{SnapshotId: aws.String("snap-" + id)},
+		}},
+	}
+}
+
+type mockRetentionEC2 struct {
+	clients.Ec2Client
+
+	images    []ec2types.Image
+	shared    map[string]bool
+	instances map[string]string
+	templates map[string]string
+	// paged puts the instances and launch templates on a second page.
+	paged         bool
+	familyFilters []ec2types.Filter
+
+	deprecated   map[string]time.Time
+	disabled     []string
+	deregistered []string
+	deleted      []string
+}
+
+func newMockRetentionEC2(images ...ec2types.Image) *mockRetentionEC2 {
+	return &mockRetentionEC2{
+		images:     images,
+		shared:     map[string]bool{},
+		instances:  map[string]string{},
+		templates:  map[string]string{},
+		deprecated: map[string]time.Time{},
+	}
+}
+
+func (m *mockRetentionEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	if len(input.ImageIds) == 0 {
+		m.familyFilters = input.Filters
+		return &ec2.DescribeImagesOutput{Images: m.images}, nil
+	}
+	var images []ec2types.Image
+	for _, image := range m.images {
+		for _, id := range input.ImageIds {
+			if aws.ToString(image.ImageId) == id {
+				images = append(images, image)
+			}
+		}
+	}
+	return &ec2.DescribeImagesOutput{Images: images}, nil
+}
+
+func (m *mockRetentionEC2) DescribeImageAttribute(ctx context.Context, input *ec2.DescribeImageAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImageAttributeOutput, error) {
+	resp := &ec2.DescribeImageAttributeOutput{ImageId: input.ImageId}
+	if m.shared[aws.ToString(input.ImageId)] {
+		resp.LaunchPermissions = []ec2types.LaunchPermission{{UserId: aws.String("222222222222")}}
+	}
+	return resp, nil
+}
+
+func (m *mockRetentionEC2) DescribeInstances(ctx context.Context, input *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	resp := &ec2.DescribeInstancesOutput{}
+	if m.paged && input.NextToken == nil {
+		resp.NextToken = aws.String("next")
+		return resp, nil
+	}
+	if instance, ok := m.instances[input.Filters[0].Values[0]]; ok {
+		resp.Reservations = []ec2types.Reservation{{Instances: []ec2types.Instance{{InstanceId: aws.String(instance)}}}}
+	}
+	return resp, nil
+}
+
+func (m *mockRetentionEC2) DescribeLaunchTemplateVersions(ctx context.Context, input *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	resp := &ec2.DescribeLaunchTemplateVersionsOutput{}
+	if m.paged && input.NextToken == nil {
+		resp.NextToken = aws.String("next")
+		return resp, nil
+	}
+	if template, ok := m.templates[input.Filters[0].Values[0]]; ok {
+		resp.LaunchTemplateVersions = []ec2types.LaunchTemplateVersion{{LaunchTemplateName: aws.String(template)}}
+	}
+	return resp, nil
+}
+
+func (m *mockRetentionEC2) EnableImageDeprecation(ctx context.Context, input *ec2.EnableImageDeprecationInput, optFns ...func(*ec2.Options)) (*ec2.EnableImageDeprecationOutput, error) {
+	m.deprecated[aws.ToString(input.ImageId)] = aws.ToTime(input.DeprecateAt)
+	return &ec2.EnableImageDeprecationOutput{}, nil
+}
+
+func (m *mockRetentionEC2) DisableImage(ctx context.Context, input *ec2.DisableImageInput, optFns ...func(*ec2.Options)) (*ec2.DisableImageOutput, error) {
+	m.disabled = append(m.disabled, aws.ToString(input.ImageId))
+	return &ec2.DisableImageOutput{}, nil
+}
+
+func (m *mockRetentionEC2) DeregisterImage(ctx context.Context, input *ec2.DeregisterImageInput, optFns ...func(*ec2.Options)) (*ec2.DeregisterImageOutput, error) {
+	m.deregistered = append(m.deregistered, aws.ToString(input.ImageId))
+	return &ec2.DeregisterImageOutput{}, nil
+}
+
+func (m *mockRetentionEC2) DeleteSnapshot(ctx context.Context, input *ec2.DeleteSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSnapshotOutput, error) {
+	m.deleted = append(m.deleted, aws.ToString(input.SnapshotId))
+	return &ec2.DeleteSnapshotOutput{}, nil
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"no family":          {"family_name_prefix": ""},
+		"two families":       {"family_tag": "ami_family"},
+		"nothing kept":       {"keep_count": 0},
+		"negative count":     {"keep_count": -1},
+		"invalid action":     {"action": "delete"},
+		"invalid deprecate":  {"deprecate_at": "tomorrow"},
+		"deprecate disabled": {"action": "disable", "deprecate_at": "2030-01-01T00:00:00Z"},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessorConfigure_Defaults(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"keep_count":      0,
+		"keep_newer_than": "720h",
+	})
+	if p.config.Action != actionDeprecate {
+		t.Errorf("expected action to default to deprecate, got %q", p.config.Action)
+	}
+	if p.config.KeepNewerThan != 720*time.Hour {
+		t.Errorf("expected keep_newer_than to be 720h, got %s", p.config.KeepNewerThan)
+	}
+}
+
+func TestPostProcessor_prune_Deprecate(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	conn := newMockRetentionEC2(
+		testImage("ami-new", 0),
+		testImage("ami-1", 24*time.Hour),
+		testImage("ami-2", 48*time.Hour),
+		testImage("ami-3", 72*time.Hour),
+	)
+
+	if err := p.prune(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new", testNow); err != nil {
+		t.Fatalf("prune() failed: %s", err)
+	}
+
+	if len(conn.familyFilters) != 1 || aws.ToString(conn.familyFilters[0].Name) != "name" || conn.familyFilters[0].Values[0] != "web-*" {
+		t.Errorf("expected the family to be found by name, got %+v", conn.familyFilters)
+	}
+	if len(conn.deprecated) != 2 {
+		t.Fatalf("expected ami-2 and ami-3 to be deprecated, got %v", conn.deprecated)
+	}
+	if at, ok := conn.deprecated["ami-3"]; !ok || !at.After(testNow) {
+		t.Errorf("expected ami-3 to be deprecated right away, got %v", at)
+	}
+	if _, ok := conn.deprecated["ami-2"]; !ok {
+		t.Errorf("expected ami-2 to be deprecated, got %v", conn.deprecated)
+	}
+}
+
+func TestPostProcessor_prune_KeepNewerThan(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"keep_count":      0,
+		"keep_newer_than": "36h",
+		"action":          "disable",
+	})
+	disabled := testImage("ami-4", 96*time.Hour)
+	disabled.State = ec2types.ImageStateDisabled
+	conn := newMockRetentionEC2(
+		testImage("ami-new", 0),
+		testImage("ami-1", 24*time.Hour),
+		testImage("ami-3", 72*time.Hour),
+		testImage("ami-2", 48*time.Hour),
+		disabled,
+	)
+
+	if err := p.prune(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new", testNow); err != nil {
+		t.Fatalf("prune() failed: %s", err)
+	}
+
+	if expected := []string{"ami-3", "ami-2"}; !reflect.DeepEqual(conn.disabled, expected) {
+		t.Errorf("expected %v to be disabled from the oldest, got %v", expected, conn.disabled)
+	}
+}
+
+func TestPostProcessor_prune_Deregister(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"family_name_prefix": "",
+		"family_tag":         "ami_family",
+		"keep_count":         1,
+		"action":             "deregister",
+	})
+	conn := newMockRetentionEC2(
+		testImage("ami-new", 0),
+		testImage("ami-1", 24*time.Hour),
+	)
+
+	if err := p.prune(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new", testNow); err != nil {
+		t.Fatalf("prune() failed: %s", err)
+	}
+
+	if len(conn.familyFilters) != 1 || aws.ToString(conn.familyFilters[0].Name) != "tag:ami_family" || conn.familyFilters[0].Values[0] != "web" {
+		t.Errorf("expected the family to be found by tag, got %+v", conn.familyFilters)
+	}
+	if !reflect.DeepEqual(conn.deregistered, []string{"ami-1"}) || !reflect.DeepEqual(conn.deleted, []string{"snap-ami-1"}) {
+		t.Errorf("expected ami-1 and its snapshot to be deleted, got %v and %v", conn.deregistered, conn.deleted)
+	}
+}
+
+func TestPostProcessor_prune_MissingTag(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"family_name_prefix": "",
+		"family_tag":         "ami_family",
+	})
+	image := testImage("ami-new", 0)
+	image.Tags = nil
+	conn := newMockRetentionEC2(image)
+
+	err := p.prune(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new", testNow)
+	if err == nil || !strings.Contains(err.Error(), "has no ami_family tag") {
+		t.Errorf("expected a missing tag error, got %v", err)
+	}
+}
+
+func TestPostProcessor_prune_Protected(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"keep_count": 1,
+		"action":     "deregister",
+	})
+	protected := testImage("ami-protected", 24*time.Hour)
+	protected.DeregistrationProtection = aws.String("enabled-with-cooldown")
+	public := testImage("ami-public", 24*time.Hour)
+	public.Public = aws.Bool(true)
+	conn := newMockRetentionEC2(
+		testImage("ami-new", 0),
+		protected,
+		public,
+		testImage("ami-shared", 24*time.Hour),
+		testImage("ami-running", 24*time.Hour),
+		testImage("ami-template", 24*time.Hour),
+		testImage("ami-unused", 24*time.Hour),
+	)
+	conn.shared["ami-shared"] = true
+	conn.instances["ami-running"] = "i-1"
+	conn.templates["ami-template"] = "web"
+
+	ui := &packersdk.MockUi{}
+	if err := p.prune(context.Background(), ui, conn, "us-east-1", "ami-new", testNow); err != nil {
+		t.Fatalf("prune() failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(conn.deregistered, []string{"ami-unused"}) {
+		t.Errorf("expected only ami-unused to be deregistered, got %v", conn.deregistered)
+	}
+	messages := ""
+	for _, message := range ui.SayMessages {
+		messages += message.Message + "\n"
+	}
+	for _, reason := range []string{"deregistration protection", "public", "shared", "instance i-1", "launch template web"} {
+		if !strings.Contains(messages, reason) {
+			t.Errorf("expected the AMIs to be kept because of %q, got:\n%s", reason, messages)
+		}
+	}
+}
+
+func TestPostProcessor_prune_ProtectedOnSecondPage(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"keep_count": 1,
+		"action":     "deregister",
+	})
+	conn := newMockRetentionEC2(
+		testImage("ami-new", 0),
+		testImage("ami-running", 24*time.Hour),
+		testImage("ami-template", 24*time.Hour),
+		testImage("ami-unused", 24*time.Hour),
+	)
+	conn.paged = true
+	conn.instances["ami-running"] = "i-1"
+	conn.templates["ami-template"] = "web"
+
+	if err := p.prune(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new", testNow); err != nil {
+		t.Fatalf("prune() failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(conn.deregistered, []string{"ami-unused"}) {
+		t.Errorf("expected the AMIs in use on a second page to be kept, got %v deregistered", conn.deregistered)
+	}
+}
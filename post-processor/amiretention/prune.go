@@ -0,0 +1,239 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amiretention
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// prune applies the action to the AMIs of the family of ami in region that
+// are not kept, leaving the protected ones alone.
+func (p *PostProcessor) prune(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, region, ami string, now time.Time) error {
+	family, err := p.family(ctx, conn, ami)
+	if err != nil {
+		return fmt.Errorf("Failed to find the family of %s in %s: %s", ami, region, err)
+	}
+
+	expired := p.expired(family, ami, now)
+	if len(expired) == 0 {
+		ui.Say(fmt.Sprintf("No AMI of the family of %s to %s in %s", ami, p.config.Action, region))
+		return nil
+	}
+
+	ui.Say(fmt.Sprintf("Checking %d older AMIs of the family of %s in %s", len(expired), ami, region))
+	for _, image := range expired {
+		id := aws.ToString(image.ImageId)
+		reason, err := protection(ctx, conn, image)
+		if err != nil {
+			return fmt.Errorf("Failed to check whether %s is in use: %s", id, err)
+		}
+		if reason != "" {
+			ui.Message(fmt.Sprintf("Keeping %s (%s): %s", id, aws.ToString(image.Name), reason))
+			continue
+		}
+		if err := p.apply(ctx, conn, image, now); err != nil {
+			return fmt.Errorf("Failed to %s %s in %s: %s", p.config.Action, id, region, err)
+		}
+		ui.Message(fmt.Sprintf("Applied %s to %s (%s)", p.config.Action, id, aws.ToString(image.Name)))
+	}
+	return nil
+}
+
+// family returns the AMIs owned by the account in the family of ami.
+func (p *PostProcessor) family(ctx context.Context, conn clients.Ec2Client, ami string) ([]ec2types.Image, error) {
+	input := &ec2.DescribeImagesInput{
+		Owners: []string{"self"},
+		// Disabled AMIs still cost their snapshots, deregister them too.
+		IncludeDisabled: aws.Bool(p.config.Action == actionDeregister),
+	}
+	if p.config.FamilyNamePrefix != "" {
+		input.Filters = []ec2types.Filter{{
+			Name:   aws.String("name"),
+			Values: []string{p.config.FamilyNamePrefix + "*"},
+		}}
+	} else {
+		resp, err := conn.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{ami}})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Images) == 0 {
+			return nil, fmt.Errorf("AMI %s not found", ami)
+		}
+		value, ok := "", false
+		for _, tag := range resp.Images[0].Tags {
+			if aws.ToString(tag.Key) == p.config.FamilyTag {
+				value, ok = aws.ToString(tag.Value), true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("AMI %s has no %s tag", ami, p.config.FamilyTag)
+		}
+		input.Filters = []ec2types.Filter{{
+			Name:   aws.String("tag:" + p.config.FamilyTag),
+			Values: []string{value},
+		}}
+	}
+
+	var images []ec2types.Image
+	paginator := ec2.NewDescribeImagesPaginator(conn, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, page.Images...)
+	}
+	return images, nil
+}
+
+// expired returns the AMIs of family the action applies to, from the oldest
+// to the newest. ami is always kept.
+func (p *PostProcessor) expired(family []ec2types.Image, ami string, now time.Time) []ec2types.Image {
+	images := make([]ec2types.Image, 0, len(family))
+	for _, image := range family {
+		// The AMI of the artifact is always the newest one.
+		if aws.ToString(image.ImageId) != ami {
+			images = append(images, image)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return creationTime(images[i]).After(creationTime(images[j]))
+	})
+
+	var expired []ec2types.Image
+	for i, image := range images {
+		// The AMI of the artifact counts towards keep_count.
+		if i+1 < p.config.KeepCount {
+			continue
+		}
+		if p.config.KeepNewerThan > 0 && now.Sub(creationTime(image)) < p.config.KeepNewerThan {
+			continue
+		}
+		if p.done(image) {
+			continue
+		}
+		expired = append(expired, image)
+	}
+
+	// Apply the action to the oldest AMIs first.
+	for i, j := 0, len(expired)-1; i < j; i, j = i+1, j-1 {
+		expired[i], expired[j] = expired[j], expired[i]
+	}
+	return expired
+}
+
+// done reports whether the action was already applied to image.
+func (p *PostProcessor) done(image ec2types.Image) bool {
+	switch p.config.Action {
+	case actionDeprecate:
+		return aws.ToString(image.DeprecationTime) != ""
+	case actionDisable:
+		return image.State == ec2types.ImageStateDisabled
+	}
+	return false
+}
+
+// creationTime returns when image was created, or the zero time if EC2 did
+// not say, so that such AMIs are the first to go.
+func creationTime(image ec2types.Image) time.Time {
+	t, err := time.Parse(time.RFC3339, aws.ToString(image.CreationDate))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// protection returns why image must be left alone, or an empty string.
+func protection(ctx context.Context, conn clients.Ec2Client, image ec2types.Image) (string, error) {
+	id := aws.ToString(image.ImageId)
+
+	if protection := aws.ToString(image.DeregistrationProtection); protection != "" && protection != "disabled" {
+		return "deregistration protection is enabled", nil
+	}
+
+	if aws.ToBool(image.Public) {
+		return "the AMI is public", nil
+	}
+	attribute, err := conn.DescribeImageAttribute(ctx, &ec2.DescribeImageAttributeInput{
+		ImageId:   aws.String(id),
+		Attribute: ec2types.ImageAttributeNameLaunchPermission,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(attribute.LaunchPermissions) > 0 {
+		return "the AMI is shared", nil
+	}
+
+	instances := ec2.NewDescribeInstancesPaginator(conn, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("image-id"), Values: []string{id}},
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+		},
+	})
+	for instances.HasMorePages() {
+		page, err := instances.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, reservation := range page.Reservations {
+			if len(reservation.Instances) > 0 {
+				return fmt.Sprintf("the AMI is used by instance %s", aws.ToString(reservation.Instances[0].InstanceId)), nil
+			}
+		}
+	}
+
+	// Without a launch template, the latest and default versions of every
+	// launch template of the account are described.
+	versions := ec2.NewDescribeLaunchTemplateVersionsPaginator(conn, &ec2.DescribeLaunchTemplateVersionsInput{
+		Versions: []string{"$Latest", "$Default"},
+		Filters: []ec2types.Filter{
+			{Name: aws.String("image-id"), Values: []string{id}},
+		},
+	})
+	for versions.HasMorePages() {
+		page, err := versions.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(page.LaunchTemplateVersions) > 0 {
+			return fmt.Sprintf("the AMI is used by launch template %s", aws.ToString(page.LaunchTemplateVersions[0].LaunchTemplateName)), nil
+		}
+	}
+	return "", nil
+}
+
+// apply applies the action to image.
+func (p *PostProcessor) apply(ctx context.Context, conn clients.Ec2Client, image ec2types.Image, now time.Time) error {
+	switch p.config.Action {
+	case actionDeprecate:
+		// EC2 does not accept deprecation times in the past.
+		deprecateAt := now.Add(time.Minute)
+		if p.config.DeprecationTime != "" {
+			deprecateAt, _ = time.Parse(time.RFC3339, p.config.DeprecationTime)
+		}
+		_, err := conn.EnableImageDeprecation(ctx, &ec2.EnableImageDeprecationInput{
+			ImageId:     image.ImageId,
+			DeprecateAt: aws.Time(deprecateAt),
+		})
+		return err
+	case actionDisable:
+		_, err := conn.DisableImage(ctx, &ec2.DisableImageInput{
+			ImageId: image.ImageId,
+		})
+		return err
+	default:
+		return awscommon.DestroyAMIs([]string{aws.ToString(image.ImageId)}, conn)
+	}
+}
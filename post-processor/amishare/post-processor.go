@@ -0,0 +1,156 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package amishare contains a post-processor sharing the AMIs of an
+// artifact with other accounts and organizations, along with their
+// snapshots and the KMS keys they are encrypted with.
+package amishare
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-ami-share"
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// A list of account IDs to share the AMIs with. The snapshots of the
+	// AMIs are shared with them as well, and they are granted the use of
+	// the customer managed KMS keys the snapshots are encrypted with.
+	AMIUsers []string `mapstructure:"ami_users" required:"false"`
+	// A list of groups to share the AMIs with. `all` makes the AMIs public,
+	// AWS doesn't accept any other value. Their snapshots are not made
+	// public.
+	AMIGroups []string `mapstructure:"ami_groups" required:"false"`
+	// A list of ARNs of AWS Organizations to share the AMIs with. KMS grants
+	// cannot be given to an organization: when the snapshots are encrypted,
+	// the statement the key policy needs for the accounts of the
+	// organization to launch the AMIs is reported if it is missing.
+	AMIOrgArns []string `mapstructure:"ami_org_arns" required:"false"`
+	// A list of ARNs of AWS Organizations organizational units (OU) to
+	// share the AMIs with. As for `ami_org_arns`, the missing key policy
+	// statements are reported.
+	AMIOuArns []string `mapstructure:"ami_ou_arns" required:"false"`
+	// Revoke the launch permissions, create volume permissions and KMS
+	// grants given to `ami_users`, `ami_groups`, `ami_org_arns` and
+	// `ami_ou_arns` instead of adding them. Key policy statements are left
+	// alone. Default `false`.
+	Unshare bool `mapstructure:"unshare" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if len(p.config.AMIUsers) == 0 && len(p.config.AMIGroups) == 0 &&
+		len(p.config.AMIOrgArns) == 0 && len(p.config.AMIOuArns) == 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("at least one of ami_users, ami_groups, ami_org_arns or ami_ou_arns must be set"))
+	}
+	for _, user := range p.config.AMIUsers {
+		if !accountIDPattern.MatchString(user) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%q in ami_users is not an account ID", user))
+		}
+	}
+	for _, group := range p.config.AMIGroups {
+		if group != "all" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid group %q in ami_groups, only all is supported", group))
+		}
+	}
+	for _, orgArn := range p.config.AMIOrgArns {
+		if _, ouID, err := orgIDs(orgArn); err != nil || ouID != "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%q in ami_org_arns is not the ARN of an organization", orgArn))
+		}
+	}
+	for _, ouArn := range p.config.AMIOuArns {
+		if _, ouID, err := orgIDs(ouArn); err != nil || ouID == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%q in ami_ou_arns is not the ARN of an organizational unit", ouArn))
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := make([]string, 0, len(amis))
+	for region := range amis {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	errs := new(packersdk.MultiError)
+	for _, region := range regions {
+		ami := amis[region]
+		if p.config.Unshare {
+			ui.Say(fmt.Sprintf("Unsharing AMI %s in %s", ami, region))
+		} else {
+			ui.Say(fmt.Sprintf("Sharing AMI %s in %s", ami, region))
+		}
+
+		conn, err := awscommon.GetRegionConn(ctx, &p.config.AccessConfig, region)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+			continue
+		}
+		if err := p.share(ctx, ui, conn, newKMSClient(awsConfig, region), region, ami); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if len(errs.Errors) > 0 {
+		return nil, false, false, errs
+	}
+
+	// The AMIs are unchanged, pass them on to the next post-processors.
+	return artifact, true, false, nil
+}
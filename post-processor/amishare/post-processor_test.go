@@ -0,0 +1,70 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amishare
+
+import (
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key": "foo",
+		"secret_key": "bar",
+		"region":     "us-east-1",
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"ami_users":    []string{"123456789012"},
+		"ami_groups":   []string{"all"},
+		"ami_org_arns": []string{"arn:aws:organizations::111111111111:organization/o-abcd1234"},
+		"ami_ou_arns":  []string{"arn:aws:organizations::111111111111:ou/o-abcd1234/ou-ab12-cdef3456"},
+		"unshare":      true,
+	})
+	if !p.config.Unshare {
+		t.Errorf("expected unshare to be set")
+	}
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"nothing to share":  {},
+		"invalid account":   {"ami_users": []string{"12345"}},
+		"invalid group":     {"ami_groups": []string{"everyone"}},
+		"OU as org":         {"ami_org_arns": []string{"arn:aws:organizations::111111111111:ou/o-abcd1234/ou-ab12-cdef3456"}},
+		"org as OU":         {"ami_ou_arns": []string{"arn:aws:organizations::111111111111:organization/o-abcd1234"}},
+		"not an org at all": {"ami_org_arns": []string{"o-abcd1234"}},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
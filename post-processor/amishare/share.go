@@ -0,0 +1,339 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amishare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// orgIDs returns the organization ID, and the OU ID for an OU, of an
+// organization or OU ARN, like
+// arn:aws:organizations::123456789012:ou/o-abcd1234/ou-ab12-cdef3456.
+func orgIDs(orgArn string) (orgID string, ouID string, err error) {
+	parsed, err := arn.Parse(orgArn)
+	if err != nil || parsed.Service != "organizations" {
+		return "", "", fmt.Errorf("%q is not the ARN of an organization or an organizational unit", orgArn)
+	}
+	parts := strings.Split(parsed.Resource, "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "organization" && strings.HasPrefix(parts[1], "o-"):
+		return parts[1], "", nil
+	case len(parts) == 3 && parts[0] == "ou" && strings.HasPrefix(parts[1], "o-") && strings.HasPrefix(parts[2], "ou-"):
+		return parts[1], parts[2], nil
+	}
+	return "", "", fmt.Errorf("%q is not the ARN of an organization or an organizational unit", orgArn)
+}
+
+// share adds or, when unsharing, removes the launch permissions of ami,
+// the create volume permissions of its snapshots and the KMS grants on the
+// keys of its snapshots.
+func (p *PostProcessor) share(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, keys kmsAPI, region, ami string) error {
+	images, err := conn.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{ami}})
+	if err != nil {
+		return fmt.Errorf("Failed to describe AMI %s: %s", ami, err)
+	}
+	if len(images.Images) == 0 {
+		return fmt.Errorf("AMI %s not found in %s", ami, region)
+	}
+	var snapshotIDs []string
+	for _, bdm := range images.Images[0].BlockDeviceMappings {
+		if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+			snapshotIDs = append(snapshotIDs, *bdm.Ebs.SnapshotId)
+		}
+	}
+
+	if err := p.modifyLaunchPermission(ctx, ui, conn, ami); err != nil {
+		return err
+	}
+	if len(snapshotIDs) == 0 {
+		return nil
+	}
+	if err := p.modifyCreateVolumePermission(ctx, ui, conn, snapshotIDs); err != nil {
+		return err
+	}
+
+	snapshots, err := conn.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: snapshotIDs})
+	if err != nil {
+		return fmt.Errorf("Failed to describe the snapshots of AMI %s: %s", ami, err)
+	}
+	var keyIDs []string
+	for _, snapshot := range snapshots.Snapshots {
+		keyID := aws.ToString(snapshot.KmsKeyId)
+		if aws.ToBool(snapshot.Encrypted) && keyID != "" && !contains(keyIDs, keyID) {
+			keyIDs = append(keyIDs, keyID)
+		}
+	}
+
+	for _, keyID := range keyIDs {
+		if err := p.shareKey(ctx, ui, keys, ami, keyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PostProcessor) modifyLaunchPermission(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, ami string) error {
+	var permissions []ec2types.LaunchPermission
+	for _, user := range p.config.AMIUsers {
+		permissions = append(permissions, ec2types.LaunchPermission{UserId: aws.String(user)})
+	}
+	for _, group := range p.config.AMIGroups {
+		permissions = append(permissions, ec2types.LaunchPermission{Group: ec2types.PermissionGroup(group)})
+	}
+	for _, orgArn := range p.config.AMIOrgArns {
+		permissions = append(permissions, ec2types.LaunchPermission{OrganizationArn: aws.String(orgArn)})
+	}
+	for _, ouArn := range p.config.AMIOuArns {
+		permissions = append(permissions, ec2types.LaunchPermission{OrganizationalUnitArn: aws.String(ouArn)})
+	}
+
+	modifications := &ec2types.LaunchPermissionModifications{}
+	if p.config.Unshare {
+		ui.Say(fmt.Sprintf("Removing %d launch permissions from AMI %s", len(permissions), ami))
+		modifications.Remove = permissions
+	} else {
+		ui.Say(fmt.Sprintf("Adding %d launch permissions to AMI %s", len(permissions), ami))
+		modifications.Add = permissions
+	}
+	_, err := conn.ModifyImageAttribute(ctx, &ec2.ModifyImageAttributeInput{
+		ImageId:          aws.String(ami),
+		LaunchPermission: modifications,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to modify the launch permissions of AMI %s: %s", ami, err)
+	}
+	return nil
+}
+
+// modifyCreateVolumePermission shares the snapshots with the accounts of
+// ami_users. Snapshots cannot be shared with organizations, and are not
+// made public along with the AMI.
+func (p *PostProcessor) modifyCreateVolumePermission(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, snapshotIDs []string) error {
+	if len(p.config.AMIUsers) == 0 {
+		return nil
+	}
+	var permissions []ec2types.CreateVolumePermission
+	for _, user := range p.config.AMIUsers {
+		permissions = append(permissions, ec2types.CreateVolumePermission{UserId: aws.String(user)})
+	}
+
+	for _, snapshotID := range snapshotIDs {
+		modifications := &ec2types.CreateVolumePermissionModifications{}
+		if p.config.Unshare {
+			ui.Message(fmt.Sprintf("Removing the create volume permissions of snapshot %s", snapshotID))
+			modifications.Remove = permissions
+		} else {
+			ui.Message(fmt.Sprintf("Adding create volume permissions to snapshot %s", snapshotID))
+			modifications.Add = permissions
+		}
+		_, err := conn.ModifySnapshotAttribute(ctx, &ec2.ModifySnapshotAttributeInput{
+			SnapshotId:             aws.String(snapshotID),
+			CreateVolumePermission: modifications,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to modify the create volume permissions of snapshot %s: %s", snapshotID, err)
+		}
+	}
+	return nil
+}
+
+// shareKey lets the accounts of ami_users use the key of the snapshots of
+// ami through grants, and reports the key policy statements organizations
+// and OUs need, since grants cannot be given to them.
+func (p *PostProcessor) shareKey(ctx context.Context, ui packersdk.Ui, keys kmsAPI, ami, keyID string) error {
+	described, err := keys.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return fmt.Errorf("Failed to describe KMS key %s: %s", keyID, err)
+	}
+	key := described.KeyMetadata
+	keyArn := aws.ToString(key.Arn)
+	if key.KeyManager == kmstypes.KeyManagerTypeAws {
+		if p.config.Unshare {
+			return nil
+		}
+		return fmt.Errorf("The snapshots of AMI %s are encrypted with the AWS managed key %s, which cannot be "+
+			"shared with other accounts. Copy the AMI with a customer managed key to share it.", ami, keyArn)
+	}
+
+	if p.config.Unshare {
+		return p.revokeGrants(ctx, ui, keys, ami, keyArn)
+	}
+
+	parsed, err := arn.Parse(keyArn)
+	if err != nil {
+		return fmt.Errorf("Invalid ARN %q of KMS key %s: %s", keyArn, keyID, err)
+	}
+	for _, user := range p.config.AMIUsers {
+		ui.Message(fmt.Sprintf("Granting account %s the use of KMS key %s", user, keyArn))
+		_, err := keys.CreateGrant(ctx, &kms.CreateGrantInput{
+			KeyId:            aws.String(keyArn),
+			GranteePrincipal: aws.String(fmt.Sprintf("arn:%s:iam::%s:root", parsed.Partition, user)),
+			Operations:       awscommon.AMIShareGrantOperations,
+			Name:             aws.String(awscommon.AMIShareGrantName(ami)),
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to grant account %s the use of KMS key %s: %s", user, keyArn, err)
+		}
+	}
+
+	if len(p.config.AMIOrgArns) == 0 && len(p.config.AMIOuArns) == 0 {
+		return nil
+	}
+	var policy string
+	output, err := keys.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{KeyId: aws.String(keyArn), PolicyName: aws.String("default")})
+	if err == nil {
+		policy = aws.ToString(output.Policy)
+	} else {
+		ui.Error(fmt.Sprintf("Failed to read the policy of KMS key %s, cannot check it lets the organizations use the key: %s", keyArn, err))
+	}
+	for _, orgArn := range append(append([]string{}, p.config.AMIOrgArns...), p.config.AMIOuArns...) {
+		orgID, ouID, _ := orgIDs(orgArn)
+		id := orgID
+		if ouID != "" {
+			id = ouID
+		}
+		if policy != "" && policyAllows(policy, id) {
+			continue
+		}
+		ui.Error(fmt.Sprintf("The policy of KMS key %s does not let %s use the key, so it cannot launch AMI %s. "+
+			"Add this statement to the key policy:\n%s", keyArn, orgArn, ami, keyPolicyStatement(orgID, ouID)))
+	}
+	return nil
+}
+
+func (p *PostProcessor) revokeGrants(ctx context.Context, ui packersdk.Ui, keys kmsAPI, ami, keyArn string) error {
+	var grants []kmstypes.GrantListEntry
+	paginator := kms.NewListGrantsPaginator(keys, &kms.ListGrantsInput{KeyId: aws.String(keyArn)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to list the grants of KMS key %s: %s", keyArn, err)
+		}
+		grants = append(grants, page.Grants...)
+	}
+	for _, grant := range grants {
+		grantID, grantee := aws.ToString(grant.GrantId), aws.ToString(grant.GranteePrincipal)
+		if aws.ToString(grant.Name) != awscommon.AMIShareGrantName(ami) || !p.isGrantee(grantee) {
+			continue
+		}
+		ui.Message(fmt.Sprintf("Revoking grant %s of KMS key %s for %s", grantID, keyArn, grantee))
+		_, err := keys.RevokeGrant(ctx, &kms.RevokeGrantInput{KeyId: aws.String(keyArn), GrantId: aws.String(grantID)})
+		if err != nil {
+			return fmt.Errorf("Failed to revoke grant %s of KMS key %s: %s", grantID, keyArn, err)
+		}
+	}
+	if len(p.config.AMIOrgArns) > 0 || len(p.config.AMIOuArns) > 0 {
+		ui.Message(fmt.Sprintf("The statements of the policy of KMS key %s letting organizations use it are left alone", keyArn))
+	}
+	return nil
+}
+
+// isGrantee tells whether principal is the root of one of the accounts of
+// ami_users.
+func (p *PostProcessor) isGrantee(principal string) bool {
+	parsed, err := arn.Parse(principal)
+	if err != nil || parsed.Resource != "root" {
+		return false
+	}
+	return contains(p.config.AMIUsers, parsed.AccountID)
+}
+
+// policyAllows tells whether one of the Allow statements of the key policy
+// has a condition on id, an organization or OU ID.
+func policyAllows(policy string, id string) bool {
+	var document struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return false
+	}
+	type statement struct {
+		Effect    string                            `json:"Effect"`
+		Condition map[string]map[string]interface{} `json:"Condition"`
+	}
+	var statements []statement
+	if err := json.Unmarshal(document.Statement, &statements); err != nil {
+		var single statement
+		if err := json.Unmarshal(document.Statement, &single); err != nil {
+			return false
+		}
+		statements = []statement{single}
+	}
+
+	for _, s := range statements {
+		if s.Effect != "Allow" {
+			continue
+		}
+		for _, conditions := range s.Condition {
+			for _, value := range conditions {
+				switch value := value.(type) {
+				case string:
+					if strings.Contains(value, id) {
+						return true
+					}
+				case []interface{}:
+					for _, v := range value {
+						if v, ok := v.(string); ok && strings.Contains(v, id) {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// keyPolicyStatement returns the key policy statement letting the accounts
+// of an organization, or of one of its OUs, use the key.
+func keyPolicyStatement(orgID, ouID string) string {
+	condition := map[string]interface{}{
+		"StringEquals": map[string]string{"aws:PrincipalOrgID": orgID},
+	}
+	sid := "AllowUseByOrganization" + strings.TrimPrefix(orgID, "o-")
+	if ouID != "" {
+		condition = map[string]interface{}{
+			"ForAnyValue:StringLike": map[string][]string{
+				"aws:PrincipalOrgPaths": {fmt.Sprintf("%s/*/%s/*", orgID, ouID)},
+			},
+		}
+		sid = "AllowUseByOU" + strings.ReplaceAll(strings.TrimPrefix(ouID, "ou-"), "-", "")
+	}
+
+	actions := make([]string, len(awscommon.AMIShareGrantOperations))
+	for i, operation := range awscommon.AMIShareGrantOperations {
+		actions[i] = "kms:" + string(operation)
+	}
+	statement, _ := json.MarshalIndent(map[string]interface{}{
+		"Sid":       sid,
+		"Effect":    "Allow",
+		"Principal": map[string]string{"AWS": "*"},
+		"Action":    actions,
+		"Resource":  "*",
+		"Condition": condition,
+	}, "", "  ")
+	return string(statement)
+}
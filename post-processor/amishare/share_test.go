@@ -0,0 +1,250 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amishare
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const (
+	testKeyArn = "arn:aws:kms:us-east-1:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	testOrgArn = "arn:aws:organizations::111111111111:organization/o-abcd1234"
+)
+
+type mockShareEC2 struct {
+	clients.Ec2Client
+
+	encrypted bool
+	keyID     string
+
+	launchPermissions []*ec2types.LaunchPermissionModifications
+	volumePermissions map[string]*ec2types.CreateVolumePermissionModifications
+}
+
+func (m *mockShareEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId: aws.String(input.ImageIds[0]),
+		BlockDeviceMappings: []ec2types.BlockDeviceMapping{
+			{DeviceName: aws.String("/dev/sda1"), Ebs: &ec2types.EbsBlockDevice{SnapshotId: aws.String("snap-1")}},
+			{DeviceName: aws.String("/dev/sdb"), Ebs: &ec2types.EbsBlockDevice{SnapshotId: aws.String("snap-2")}},
+			{DeviceName: aws.String("/dev/sdc"), VirtualName: aws.String("ephemeral0")},
+		},
+	}}}, nil
+}
+
+func (m *mockShareEC2) DescribeSnapshots(ctx context.Context, input *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error) {
+	var snapshots []ec2types.Snapshot
+	for _, id := range input.SnapshotIds {
+		snapshot := ec2types.Snapshot{SnapshotId: aws.String(id), Encrypted: aws.Bool(m.encrypted)}
+		if m.encrypted {
+			snapshot.KmsKeyId = aws.String(m.keyID)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return &ec2.DescribeSnapshotsOutput{Snapshots: snapshots}, nil
+}
+
+func (m *mockShareEC2) ModifyImageAttribute(ctx context.Context, input *ec2.ModifyImageAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyImageAttributeOutput, error) {
+	m.launchPermissions = append(m.launchPermissions, input.LaunchPermission)
+	return &ec2.ModifyImageAttributeOutput{}, nil
+}
+
+func (m *mockShareEC2) ModifySnapshotAttribute(ctx context.Context, input *ec2.ModifySnapshotAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifySnapshotAttributeOutput, error) {
+	if m.volumePermissions == nil {
+		m.volumePermissions = map[string]*ec2types.CreateVolumePermissionModifications{}
+	}
+	m.volumePermissions[aws.ToString(input.SnapshotId)] = input.CreateVolumePermission
+	return &ec2.ModifySnapshotAttributeOutput{}, nil
+}
+
+// mockKMS lists its grants over two pages.
+type mockKMS struct {
+	keyManager kmstypes.KeyManagerType
+	policy     string
+	grants     []kmstypes.GrantListEntry
+	created    []*kms.CreateGrantInput
+	revoked    []string
+}
+
+func (m *mockKMS) DescribeKey(ctx context.Context, input *kms.DescribeKeyInput, optFns ...func(*kms.Options)) (*kms.DescribeKeyOutput, error) {
+	return &kms.DescribeKeyOutput{
+		KeyMetadata: &kmstypes.KeyMetadata{Arn: aws.String(testKeyArn), KeyManager: m.keyManager},
+	}, nil
+}
+
+func (m *mockKMS) CreateGrant(ctx context.Context, input *kms.CreateGrantInput, optFns ...func(*kms.Options)) (*kms.CreateGrantOutput, error) {
+	m.created = append(m.created, input)
+	return &kms.CreateGrantOutput{}, nil
+}
+
+func (m *mockKMS) ListGrants(ctx context.Context, input *kms.ListGrantsInput, optFns ...func(*kms.Options)) (*kms.ListGrantsOutput, error) {
+	half := len(m.grants) / 2
+	if input.Marker == nil {
+		return &kms.ListGrantsOutput{Grants: m.grants[:half], NextMarker: aws.String("next"), Truncated: true}, nil
+	}
+	return &kms.ListGrantsOutput{Grants: m.grants[half:], Truncated: false}, nil
+}
+
+func (m *mockKMS) RevokeGrant(ctx context.Context, input *kms.RevokeGrantInput, optFns ...func(*kms.Options)) (*kms.RevokeGrantOutput, error) {
+	m.revoked = append(m.revoked, aws.ToString(input.GrantId))
+	return &kms.RevokeGrantOutput{}, nil
+}
+
+func (m *mockKMS) GetKeyPolicy(ctx context.Context, input *kms.GetKeyPolicyInput, optFns ...func(*kms.Options)) (*kms.GetKeyPolicyOutput, error) {
+	return &kms.GetKeyPolicyOutput{Policy: aws.String(m.policy)}, nil
+}
+
+func TestPostProcessor_share(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"ami_users":    []string{"123456789012", "210987654321"},
+		"ami_org_arns": []string{testOrgArn},
+	})
+	conn := &mockShareEC2{encrypted: true, keyID: "1234abcd-12ab-34cd-56ef-1234567890ab"}
+	keys := &mockKMS{keyManager: kmstypes.KeyManagerTypeCustomer, policy: `{"Statement": []}`}
+	ui := &packersdk.MockUi{}
+
+	if err := p.share(context.Background(), ui, conn, keys, "us-east-1", "ami-1234"); err != nil {
+		t.Fatalf("share() failed: %s", err)
+	}
+
+	if len(conn.launchPermissions) != 1 || len(conn.launchPermissions[0].Add) != 3 {
+		t.Fatalf("expected 3 launch permissions to be added, got %+v", conn.launchPermissions)
+	}
+	if len(conn.volumePermissions) != 2 {
+		t.Fatalf("expected both snapshots to be shared, got %v", conn.volumePermissions)
+	}
+	for snapshot, modifications := range conn.volumePermissions {
+		if len(modifications.Add) != 2 {
+			t.Errorf("expected snapshot %s to be shared with both accounts, got %+v", snapshot, modifications.Add)
+		}
+	}
+
+	if len(keys.created) != 2 {
+		t.Fatalf("expected a grant per account, got %d", len(keys.created))
+	}
+	grant := keys.created[0]
+	if aws.ToString(grant.GranteePrincipal) != "arn:aws:iam::123456789012:root" || aws.ToString(grant.Name) != "packer-ami-share-ami-1234" || aws.ToString(grant.KeyId) != testKeyArn {
+		t.Errorf("unexpected grant %+v", grant)
+	}
+
+	if !ui.ErrorCalled || !strings.Contains(ui.ErrorMessage, `"aws:PrincipalOrgID": "o-abcd1234"`) {
+		t.Errorf("expected the missing key policy statement to be reported, got %q", ui.ErrorMessage)
+	}
+}
+
+func TestPostProcessor_share_PolicyAllowsOrg(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{"ami_org_arns": []string{testOrgArn}})
+	conn := &mockShareEC2{encrypted: true, keyID: testKeyArn}
+	keys := &mockKMS{keyManager: kmstypes.KeyManagerTypeCustomer, policy: `{
+		"Statement": {
+			"Effect": "Allow",
+			"Principal": {"AWS": "*"},
+			"Action": "kms:*",
+			"Resource": "*",
+			"Condition": {"StringEquals": {"aws:PrincipalOrgID": ["o-abcd1234"]}}
+		}
+	}`}
+	ui := &packersdk.MockUi{}
+
+	if err := p.share(context.Background(), ui, conn, keys, "us-east-1", "ami-1234"); err != nil {
+		t.Fatalf("share() failed: %s", err)
+	}
+	if len(conn.volumePermissions) != 0 {
+		t.Errorf("expected snapshots not to be shared with organizations, got %v", conn.volumePermissions)
+	}
+	if len(keys.created) != 0 {
+		t.Errorf("expected no grant for organizations, got %d", len(keys.created))
+	}
+	if ui.ErrorCalled {
+		t.Errorf("expected nothing to be reported, got %q", ui.ErrorMessage)
+	}
+}
+
+func TestPostProcessor_share_AWSManagedKey(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{"ami_users": []string{"123456789012"}})
+	conn := &mockShareEC2{encrypted: true, keyID: testKeyArn}
+
+	err := p.share(context.Background(), &packersdk.MockUi{}, conn, &mockKMS{keyManager: kmstypes.KeyManagerTypeAws}, "us-east-1", "ami-1234")
+	if err == nil || !strings.Contains(err.Error(), "AWS managed key") {
+		t.Fatalf("expected an error about the AWS managed key, got %v", err)
+	}
+}
+
+func TestPostProcessor_share_Unencrypted(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{"ami_users": []string{"123456789012"}})
+	conn := &mockShareEC2{}
+	keys := &mockKMS{}
+
+	if err := p.share(context.Background(), &packersdk.MockUi{}, conn, keys, "us-east-1", "ami-1234"); err != nil {
+		t.Fatalf("share() failed: %s", err)
+	}
+	if len(keys.created) != 0 {
+		t.Errorf("expected no grant for unencrypted snapshots")
+	}
+}
+
+func TestPostProcessor_unshare(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"ami_users": []string{"123456789012"},
+		"unshare":   true,
+	})
+	conn := &mockShareEC2{encrypted: true, keyID: testKeyArn}
+	keys := &mockKMS{keyManager: kmstypes.KeyManagerTypeCustomer, grants: []kmstypes.GrantListEntry{
+		{GrantId: aws.String("grant-1"), Name: aws.String("packer-ami-share-ami-1234"), GranteePrincipal: aws.String("arn:aws:iam::123456789012:root")},
+		{GrantId: aws.String("grant-2"), Name: aws.String("packer-ami-share-ami-1234"), GranteePrincipal: aws.String("arn:aws:iam::999999999999:root")},
+		{GrantId: aws.String("grant-3"), Name: aws.String("packer-ami-share-ami-5678"), GranteePrincipal: aws.String("arn:aws:iam::123456789012:root")},
+		{GrantId: aws.String("grant-4"), GranteePrincipal: aws.String("arn:aws:iam::123456789012:role/ebs")},
+		{GrantId: aws.String("grant-5"), Name: aws.String("packer-ami-share-ami-1234"), GranteePrincipal: aws.String("arn:aws:iam::123456789012:root")},
+	}}
+
+	if err := p.share(context.Background(), &packersdk.MockUi{}, conn, keys, "us-east-1", "ami-1234"); err != nil {
+		t.Fatalf("share() failed: %s", err)
+	}
+	if len(conn.launchPermissions) != 1 || len(conn.launchPermissions[0].Remove) != 1 || len(conn.launchPermissions[0].Add) != 0 {
+		t.Errorf("expected the launch permission to be removed, got %+v", conn.launchPermissions)
+	}
+	for snapshot, modifications := range conn.volumePermissions {
+		if len(modifications.Remove) != 1 {
+			t.Errorf("expected the create volume permission of %s to be removed, got %+v", snapshot, modifications)
+		}
+	}
+	if !reflect.DeepEqual(keys.revoked, []string{"grant-1", "grant-5"}) {
+		t.Errorf("expected grant-1 and grant-5 of both pages to be revoked, got %v", keys.revoked)
+	}
+}
+
+func TestOrgIDs(t *testing.T) {
+	orgID, ouID, err := orgIDs("arn:aws:organizations::111111111111:ou/o-abcd1234/ou-ab12-cdef3456")
+	if err != nil || orgID != "o-abcd1234" || ouID != "ou-ab12-cdef3456" {
+		t.Errorf("unexpected OU IDs %q, %q, %v", orgID, ouID, err)
+	}
+	orgID, ouID, err = orgIDs(testOrgArn)
+	if err != nil || orgID != "o-abcd1234" || ouID != "" {
+		t.Errorf("unexpected organization IDs %q, %q, %v", orgID, ouID, err)
+	}
+	if _, _, err := orgIDs("arn:aws:iam::111111111111:root"); err == nil {
+		t.Errorf("expected an error for an IAM ARN")
+	}
+}
+
+func TestKeyPolicyStatement_OU(t *testing.T) {
+	statement := keyPolicyStatement("o-abcd1234", "ou-ab12-cdef3456")
+	if !strings.Contains(statement, `"o-abcd1234/*/ou-ab12-cdef3456/*"`) || !strings.Contains(statement, "kms:CreateGrant") {
+		t.Errorf("unexpected statement %s", statement)
+	}
+	if !policyAllows(`{"Statement": [`+statement+`]}`, "ou-ab12-cdef3456") {
+		t.Errorf("expected the reported statement to satisfy the check")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package amismoketest contains a post-processor launching the AMIs of an
+// artifact and checking they boot.
+package amismoketest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-ami-smoke-test"
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+	awscommon.RunConfig    `mapstructure:",squash"`
+
+	// The regions of the artifact to launch an instance in. Defaults to all
+	// the regions of the artifact. As the network configuration applies to
+	// every region, use filters rather than IDs to test several regions.
+	Regions []string `mapstructure:"regions" required:"false"`
+	// The commands to run on the instances once the communicator is
+	// connected. The smoke test fails if any of them exits with a non-zero
+	// status. Requires a communicator.
+	CheckCommands []string `mapstructure:"check_commands" required:"false"`
+	// Do not wait for the system and instance status checks of the instances
+	// to pass. Default `false`.
+	SkipStatusChecks bool `mapstructure:"skip_status_checks" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"run_tags",
+				"run_tag",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	// The AMIs of the artifact are launched, RunConfig is only told about a
+	// source AMI to validate the rest of its configuration.
+	if p.config.SourceAmi != "" || p.config.SourceAmiSSMParameter != "" || !p.config.SourceAmiFilter.Empty() {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("source_ami, source_ami_filter and source_ami_ssm_parameter cannot be set, the AMIs of the artifact are launched"))
+	}
+	p.config.SourceAmi = "ami-artifact"
+	errs = packersdk.MultiErrorAppend(errs, p.config.RunConfig.Prepare(&p.config.ctx)...)
+	p.config.SourceAmi = ""
+
+	if p.config.IsSpotInstance() {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("spot instances are not supported, use instance_type"))
+	}
+	if len(p.config.CheckCommands) > 0 && p.config.Comm.Type == "none" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("check_commands requires a communicator"))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := p.config.Regions
+	if len(regions) == 0 {
+		for region := range amis {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+	}
+	for _, region := range regions {
+		if _, ok := amis[region]; !ok {
+			return nil, false, false, fmt.Errorf("The artifact has no AMI in %s", region)
+		}
+	}
+
+	for _, region := range regions {
+		if err := p.smokeTest(ctx, ui, region, amis[region]); err != nil {
+			return nil, false, false, fmt.Errorf("Smoke test of %s in %s failed: %s", amis[region], region, err)
+		}
+	}
+
+	// Every AMI booted, pass them on to the next post-processors.
+	return artifact, true, false, nil
+}
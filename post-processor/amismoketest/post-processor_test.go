@@ -0,0 +1,142 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amismoketest
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":    "foo",
+		"secret_key":    "bar",
+		"region":        "us-east-1",
+		"instance_type": "t3.micro",
+		"ssh_username":  "ubuntu",
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+type mockConsoleEC2 struct {
+	clients.Ec2Client
+
+	output string
+}
+
+func (m *mockConsoleEC2) GetConsoleOutput(ctx context.Context, input *ec2.GetConsoleOutputInput, optFns ...func(*ec2.Options)) (*ec2.GetConsoleOutputOutput, error) {
+	return &ec2.GetConsoleOutputOutput{
+		InstanceId: input.InstanceId,
+		Output:     aws.String(base64.StdEncoding.EncodeToString([]byte(m.output))),
+	}, nil
+}
+
+func testState(client clients.Ec2Client) (multistep.StateBag, *packersdk.MockUi) {
+	ui := &packersdk.MockUi{}
+	state := new(multistep.BasicStateBag)
+	state.Put("ec2v2", client)
+	state.Put("ui", ui)
+	state.Put("instance", ec2types.Instance{InstanceId: aws.String("i-1")})
+	return state, ui
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"source ami":       {"source_ami": "ami-12345678"},
+		"no instance type": {"instance_type": ""},
+		"spot":             {"spot_price": "auto", "instance_type": "", "spot_instance_types": []string{"t3.micro"}},
+		"no communicator":  {"communicator": "none", "check_commands": []string{"true"}},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessorConfigure_NoSourceAMI(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"communicator": "none",
+	})
+	if p.config.SourceAmi != "" {
+		t.Errorf("expected no source AMI, got %q", p.config.SourceAmi)
+	}
+}
+
+func TestStepRunChecks(t *testing.T) {
+	state, _ := testState(&mockConsoleEC2{})
+	comm := &packersdk.MockCommunicator{}
+	state.Put("communicator", comm)
+
+	step := &stepRunChecks{Commands: []string{"systemctl is-system-running"}}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("expected the checks to pass, got %v", state.Get("error"))
+	}
+	if comm.StartCmd.Command != "systemctl is-system-running" {
+		t.Errorf("unexpected command %q", comm.StartCmd.Command)
+	}
+
+	comm.StartExitStatus = 1
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("expected the checks to fail")
+	}
+	if err, ok := state.GetOk("error"); !ok || !strings.Contains(err.(error).Error(), "exited with status 1") {
+		t.Errorf("expected an exit status error, got %v", err)
+	}
+}
+
+func TestStepConsoleOutput(t *testing.T) {
+	client := &mockConsoleEC2{output: "Kernel panic - not syncing"}
+
+	// The console output is only shown when the smoke test fails.
+	state, ui := testState(client)
+	step := &stepConsoleOutput{}
+	step.Cleanup(state)
+	if len(ui.SayMessages) != 0 {
+		t.Errorf("expected no console output, got %v", ui.SayMessages)
+	}
+
+	state.Put("error", context.DeadlineExceeded)
+	step.Cleanup(state)
+	found := false
+	for _, message := range ui.SayMessages {
+		if strings.Contains(message.Message, "Kernel panic") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the console output to be shown, got %v", ui.SayMessages)
+	}
+}
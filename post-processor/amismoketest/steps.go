@@ -0,0 +1,274 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amismoketest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// smokeTest launches an instance of ami in region and checks it, with the
+// steps of the builders. The instance is always terminated.
+func (p *PostProcessor) smokeTest(ctx context.Context, ui packersdk.Ui, region, ami string) error {
+	awsConfig, err := p.config.AccessConfig.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating config: %w", err)
+	}
+	regionConfig := *awsConfig
+	regionConfig.Region = region
+	client := ec2.NewFromConfig(regionConfig)
+
+	ui.Say(fmt.Sprintf("Smoke testing %s in %s", ami, region))
+
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &p.config)
+	state.Put("access_config", &p.config.AccessConfig)
+	state.Put("ec2v2", client)
+	state.Put("iam", iam.NewFromConfig(regionConfig))
+	state.Put("ui", ui)
+	state.Put("region", region)
+	state.Put("aws_config", &regionConfig)
+
+	// The steps set the key pair and the password of the instance on the
+	// communicator config, so each region works on a copy.
+	comm := p.config.RunConfig.Comm
+
+	tenancy := p.config.Placement.Tenancy
+	if tenancy == "" {
+		tenancy = p.config.Tenancy
+	}
+
+	steps := []multistep.Step{
+		&awscommon.StepSourceAMIInfo{
+			SourceAmi: ami,
+			// The AMI may have been built with deprecate_at.
+			IncludeDeprecated: true,
+		},
+		&awscommon.StepNetworkInfo{
+			VpcId:                    p.config.VpcId,
+			VpcFilter:                p.config.VpcFilter,
+			SecurityGroupIds:         p.config.SecurityGroupIds,
+			SecurityGroupFilter:      p.config.SecurityGroupFilter,
+			SubnetId:                 p.config.SubnetId,
+			SubnetFilter:             p.config.SubnetFilter,
+			AvailabilityZone:         p.config.AvailabilityZone,
+			AssociatePublicIpAddress: p.config.AssociatePublicIpAddress,
+			RequestedMachineType:     p.config.InstanceType,
+		},
+		&awscommon.StepKeyPair{
+			Debug:        p.config.PackerDebug,
+			Comm:         &comm,
+			IsRestricted: p.config.IsChinaCloud(),
+			DebugKeyPath: fmt.Sprintf("ec2_%s.pem", p.config.PackerBuildName),
+			Tags:         p.config.RunTags,
+			Ctx:          p.config.ctx,
+		},
+		&awscommon.StepSecurityGroup{
+			PollingConfig:             p.config.PollingConfig,
+			SecurityGroupFilter:       p.config.SecurityGroupFilter,
+			SecurityGroupIds:          p.config.SecurityGroupIds,
+			CommConfig:                &comm,
+			TemporarySGSourceCidrs:    p.config.TemporarySGSourceCidrs,
+			TemporarySGSourcePublicIp: p.config.TemporarySGSourcePublicIp,
+			SkipSSHRuleCreation:       p.config.SSMAgentEnabled(),
+			IsRestricted:              p.config.IsChinaCloud(),
+			Tags:                      p.config.RunTags,
+			Ctx:                       p.config.ctx,
+		},
+		&awscommon.StepIamInstanceProfile{
+			PollingConfig:         p.config.PollingConfig,
+			IamInstanceProfile:    p.config.IamInstanceProfile,
+			SkipProfileValidation: p.config.SkipProfileValidation,
+			TemporaryIamInstanceProfilePolicyDocument: p.config.TemporaryIamInstanceProfilePolicyDocument,
+			Tags: p.config.RunTags,
+			Ctx:  p.config.ctx,
+		},
+		&awscommon.StepRunSourceInstance{
+			PollingConfig:                     p.config.PollingConfig,
+			AssociatePublicIpAddress:          p.config.AssociatePublicIpAddress,
+			CapacityReservationPreference:     p.config.CapacityReservationPreference,
+			CapacityReservationId:             p.config.CapacityReservationId,
+			CapacityReservationGroupArn:       p.config.CapacityReservationGroupArn,
+			Comm:                              &comm,
+			Ctx:                               p.config.ctx,
+			Debug:                             p.config.PackerDebug,
+			EbsOptimized:                      p.config.EbsOptimized,
+			EnableNitroEnclave:                p.config.EnableNitroEnclave,
+			IsBurstableInstanceType:           p.config.RunConfig.IsBurstableInstanceType(),
+			EnableUnlimitedCredits:            p.config.EnableUnlimitedCredits,
+			HttpEndpoint:                      p.config.Metadata.HttpEndpoint,
+			HttpTokens:                        p.config.Metadata.HttpTokens,
+			HttpPutResponseHopLimit:           p.config.Metadata.HttpPutResponseHopLimit,
+			InstanceMetadataTags:              p.config.Metadata.InstanceMetadataTags,
+			InstanceInitiatedShutdownBehavior: p.config.InstanceInitiatedShutdownBehavior,
+			InstanceType:                      p.config.InstanceType,
+			IsRestricted:                      p.config.IsChinaCloud(),
+			SourceAMI:                         ami,
+			Tags:                              p.config.RunTags,
+			LicenseSpecifications:             p.config.LicenseSpecifications,
+			HostResourceGroupArn:              p.config.Placement.HostResourceGroupArn,
+			HostId:                            p.config.Placement.HostId,
+			Tenancy:                           tenancy,
+			UserData:                          p.config.UserData,
+			UserDataFile:                      p.config.UserDataFile,
+		},
+		&stepConsoleOutput{},
+		&stepStatusChecks{
+			PollingConfig: p.config.PollingConfig,
+			Skip:          p.config.SkipStatusChecks,
+		},
+		&awscommon.StepGetPassword{
+			Debug:     p.config.PackerDebug,
+			Comm:      &comm,
+			Timeout:   p.config.WindowsPasswordTimeout,
+			BuildName: p.config.PackerBuildName,
+		},
+		&awscommon.StepCreateSSMTunnel{
+			AwsConfig:        regionConfig,
+			Region:           region,
+			PauseBeforeSSM:   p.config.PauseBeforeSSM,
+			LocalPortNumber:  p.config.SessionManagerPort,
+			RemotePortNumber: comm.Port(),
+			SSMAgentEnabled:  p.config.SSMAgentEnabled(),
+			SSHConfig:        &comm.SSH,
+		},
+		&communicator.StepConnect{
+			Config: &comm,
+			Host: awscommon.SSHHost(
+				ctx,
+				client,
+				p.config.SSHInterface,
+				comm.Host(),
+			),
+			SSHPort: awscommon.Port(
+				p.config.SSHInterface,
+				comm.Port(),
+			),
+			SSHConfig: comm.SSHConfigFunc(),
+		},
+		&stepRunChecks{
+			Commands: p.config.CheckCommands,
+		},
+	}
+
+	runner := commonsteps.NewRunner(steps, p.config.PackerConfig, ui)
+	runner.Run(ctx, state)
+	if rawErr, ok := state.GetOk("error"); ok {
+		return rawErr.(error)
+	}
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return fmt.Errorf("Smoke test cancelled")
+	}
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return fmt.Errorf("Smoke test of %s in %s failed", ami, region)
+	}
+	return nil
+}
+
+// stepStatusChecks waits for the system and instance status checks of the
+// instance to pass.
+type stepStatusChecks struct {
+	PollingConfig *awscommon.AWSPollingConfig
+	Skip          bool
+}
+
+func (s *stepStatusChecks) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Skip {
+		return multistep.ActionContinue
+	}
+	client := state.Get("ec2v2").(clients.Ec2Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	instance := state.Get("instance").(ec2types.Instance)
+	instanceId := aws.ToString(instance.InstanceId)
+
+	ui.Say(fmt.Sprintf("Waiting for the status checks of %s to pass...", instanceId))
+	if err := s.PollingConfig.WaitUntilInstanceStatusOk(ctx, client, instanceId); err != nil {
+		err := fmt.Errorf("Error waiting for the status checks of %s to pass: %s", instanceId, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	ui.Message("Status checks passed")
+	return multistep.ActionContinue
+}
+
+func (s *stepStatusChecks) Cleanup(multistep.StateBag) {}
+
+// stepConsoleOutput shows the console output of the instance when a later
+// step fails, before the instance is terminated.
+type stepConsoleOutput struct{}
+
+func (s *stepConsoleOutput) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	return multistep.ActionContinue
+}
+
+func (s *stepConsoleOutput) Cleanup(state multistep.StateBag) {
+	if _, ok := state.GetOk("error"); !ok {
+		return
+	}
+	client := state.Get("ec2v2").(clients.Ec2Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	instance := state.Get("instance").(ec2types.Instance)
+
+	resp, err := client.GetConsoleOutput(context.TODO(), &ec2.GetConsoleOutputInput{
+		InstanceId: instance.InstanceId,
+		Latest:     aws.Bool(true),
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error getting the console output of %s: %s", aws.ToString(instance.InstanceId), err))
+		return
+	}
+	output, err := base64.StdEncoding.DecodeString(aws.ToString(resp.Output))
+	if err != nil || len(output) == 0 {
+		ui.Say(fmt.Sprintf("No console output for %s", aws.ToString(instance.InstanceId)))
+		return
+	}
+	ui.Say(fmt.Sprintf("Console output of %s:", aws.ToString(instance.InstanceId)))
+	ui.Message(string(output))
+}
+
+// stepRunChecks runs the check commands on the instance.
+type stepRunChecks struct {
+	Commands []string
+}
+
+func (s *stepRunChecks) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Commands) == 0 {
+		return multistep.ActionContinue
+	}
+	comm := state.Get("communicator").(packersdk.Communicator)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	for _, command := range s.Commands {
+		ui.Say(fmt.Sprintf("Running check: %s", command))
+		cmd := &packersdk.RemoteCmd{Command: command}
+		if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+			err := fmt.Errorf("Error running check %q: %s", command, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if status := cmd.ExitStatus(); status != 0 {
+			err := fmt.Errorf("Check %q exited with status %d", command, status)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+	return multistep.ActionContinue
+}
+
+func (s *stepRunChecks) Cleanup(multistep.StateBag) {}
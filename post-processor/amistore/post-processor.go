@@ -0,0 +1,182 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package amistore contains a post-processor storing the AMIs of an artifact
+// in S3, and restoring AMIs stored in S3.
+package amistore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-ami-store"
+
+const (
+	modeStore   = "store"
+	modeRestore = "restore"
+)
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The name of the S3 bucket the AMIs are stored in, or restored from.
+	// The bucket must be in the region of the AMI. This is a template
+	// engine, `{{ .BuildRegion }}` is the region of the AMI, so that each
+	// region can have its own bucket, such as
+	// `ami-archive-{{ .BuildRegion }}`.
+	S3Bucket string `mapstructure:"s3_bucket_name" required:"true"`
+	// `store` stores the AMIs of the artifact in the bucket, `restore`
+	// restores the AMI stored in `object_key` in the bucket as a new AMI in
+	// `region`, ignoring the artifact. Defaults to `store`.
+	Mode string `mapstructure:"mode" required:"false"`
+	// Tags to add to the stored objects, in `store` mode.
+	S3ObjectTags map[string]string `mapstructure:"s3_object_tags" required:"false"`
+	// The key of the stored object to restore the AMI from, such as
+	// `ami-0123456789abcdef0.bin`. Required in `restore` mode.
+	ObjectKey string `mapstructure:"object_key" required:"false"`
+	// The name of the restored AMI. Defaults to the name of the stored AMI.
+	AMIName string `mapstructure:"ami_name" required:"false"`
+	// Tags to add to the restored AMI and its snapshots, in `restore` mode.
+	// This is a [template engine](/packer/docs/templates/legacy_json_templates/engine).
+	Tags map[string]string `mapstructure:"tags" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+// bucketData is the data s3_bucket_name is rendered with.
+type bucketData struct {
+	BuildRegion string
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"s3_bucket_name",
+				"tags",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Mode == "" {
+		p.config.Mode = modeStore
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if p.config.S3Bucket == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("s3_bucket_name must be set"))
+	}
+	switch p.config.Mode {
+	case modeStore:
+		if p.config.ObjectKey != "" || p.config.AMIName != "" || len(p.config.Tags) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("object_key, ami_name and tags can only be set in restore mode"))
+		}
+	case modeRestore:
+		if p.config.ObjectKey == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("object_key must be set in restore mode"))
+		}
+		if len(p.config.S3ObjectTags) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("s3_object_tags can only be set in store mode"))
+		}
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid mode %q, must be store or restore", p.config.Mode))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	if p.config.Mode == modeRestore {
+		conn, err := p.config.NewEC2Client(ctx)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("failed to create EC2 client: %s", err)
+		}
+		ami, err := p.restore(ctx, ui, conn, awsConfig.Region)
+		if err != nil {
+			return nil, false, false, err
+		}
+		restored := &awscommon.Artifact{
+			Amis:           map[string]string{awsConfig.Region: ami},
+			BuilderIdValue: BuilderId,
+			Config:         awsConfig,
+		}
+		// The artifact is left alone, whatever it is.
+		return restored, true, false, nil
+	}
+
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := make([]string, 0, len(amis))
+	for region := range amis {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	objects := make(map[string]string, len(amis))
+	for _, region := range regions {
+		conn, err := awscommon.GetRegionConn(ctx, &p.config.AccessConfig, region)
+		if err != nil {
+			return nil, false, false, err
+		}
+		url, err := p.store(ctx, ui, conn, region, amis[region])
+		if err != nil {
+			return nil, false, false, err
+		}
+		objects[region] = url
+	}
+
+	// The same AMIs, which the stored objects are recorded along with.
+	stored := &awscommon.Artifact{
+		Amis:           amis,
+		BuilderIdValue: artifact.BuilderId(),
+		Config:         awsConfig,
+		StateData: map[string]interface{}{
+			"generated_data": artifact.State("generated_data"),
+			"stored_objects": objects,
+		},
+	}
+	return stored, true, false, nil
+}
@@ -0,0 +1,177 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amistore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":     "foo",
+		"secret_key":     "bar",
+		"region":         "us-east-1",
+		"s3_bucket_name": "ami-archive-{{ .BuildRegion }}",
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	p.config.PollingConfig = &awscommon.AWSPollingConfig{DelaySeconds: 1}
+	return &p
+}
+
+// mockStoreEC2 completes store tasks after reporting their progress once.
+type mockStoreEC2 struct {
+	clients.Ec2Client
+
+	storeInput   *ec2.CreateStoreImageTaskInput
+	restoreInput *ec2.CreateRestoreImageTaskInput
+	describes    int
+	failure      string
+}
+
+func (m *mockStoreEC2) CreateStoreImageTask(ctx context.Context, input *ec2.CreateStoreImageTaskInput, optFns ...func(*ec2.Options)) (*ec2.CreateStoreImageTaskOutput, error) {
+	m.storeInput = input
+	return &ec2.CreateStoreImageTaskOutput{ObjectKey: aws.String(aws.ToString(input.ImageId) + ".bin")}, nil
+}
+
+func (m *mockStoreEC2) DescribeStoreImageTasks(ctx context.Context, input *ec2.DescribeStoreImageTasksInput, optFns ...func(*ec2.Options)) (*ec2.DescribeStoreImageTasksOutput, error) {
+	m.describes++
+	task := ec2types.StoreImageTaskResult{
+		AmiId:              aws.String(input.ImageIds[0]),
+		S3objectKey:        aws.String(input.ImageIds[0] + ".bin"),
+		StoreTaskState:     aws.String("InProgress"),
+		ProgressPercentage: aws.Int32(50),
+	}
+	switch {
+	case m.failure != "":
+		task.StoreTaskState = aws.String("Failed")
+		task.StoreTaskFailureReason = aws.String(m.failure)
+	case m.describes > 1:
+		task.StoreTaskState = aws.String("Completed")
+		task.ProgressPercentage = aws.Int32(100)
+	}
+	return &ec2.DescribeStoreImageTasksOutput{StoreImageTaskResults: []ec2types.StoreImageTaskResult{task}}, nil
+}
+
+func (m *mockStoreEC2) CreateRestoreImageTask(ctx context.Context, input *ec2.CreateRestoreImageTaskInput, optFns ...func(*ec2.Options)) (*ec2.CreateRestoreImageTaskOutput, error) {
+	m.restoreInput = input
+	return &ec2.CreateRestoreImageTaskOutput{ImageId: aws.String("ami-restored")}, nil
+}
+
+func (m *mockStoreEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId: aws.String(input.ImageIds[0]),
+		State:   ec2types.ImageStateAvailable,
+	}}}, nil
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"no bucket":          {"s3_bucket_name": ""},
+		"invalid mode":       {"mode": "archive"},
+		"store with key":     {"object_key": "ami-1.bin"},
+		"restore without":    {"mode": "restore"},
+		"restore with s3tag": {"mode": "restore", "object_key": "ami-1.bin", "s3_object_tags": map[string]string{"a": "b"}},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessor_store(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"s3_object_tags": map[string]string{"Retention": "7y"},
+	})
+	conn := &mockStoreEC2{}
+	ui := &packersdk.MockUi{}
+
+	url, err := p.store(context.Background(), ui, conn, "eu-west-1", "ami-1")
+	if err != nil {
+		t.Fatalf("store() failed: %s", err)
+	}
+	if url != "s3://ami-archive-eu-west-1/ami-1.bin" {
+		t.Errorf("unexpected stored object %q", url)
+	}
+	input := conn.storeInput
+	if aws.ToString(input.Bucket) != "ami-archive-eu-west-1" || len(input.S3ObjectTags) != 1 ||
+		aws.ToString(input.S3ObjectTags[0].Key) != "Retention" {
+		t.Errorf("unexpected CreateStoreImageTask input %+v", input)
+	}
+	progress := false
+	for _, message := range ui.SayMessages {
+		if strings.Contains(message.Message, "InProgress, 50%") {
+			progress = true
+		}
+	}
+	if !progress {
+		t.Errorf("expected the progress to be reported, got %v", ui.SayMessages)
+	}
+}
+
+func TestPostProcessor_store_Failed(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	conn := &mockStoreEC2{failure: "AccessDenied"}
+
+	_, err := p.store(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-1")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("expected the store to fail, got %v", err)
+	}
+}
+
+func TestPostProcessor_restore(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"mode":       "restore",
+		"object_key": "ami-1.bin",
+		"ami_name":   "web-restored",
+		"tags":       map[string]string{"Restored": "{{ .BuildRegion }}"},
+	})
+	conn := &mockStoreEC2{}
+
+	ami, err := p.restore(context.Background(), &packersdk.MockUi{}, conn, "us-east-1")
+	if err != nil {
+		t.Fatalf("restore() failed: %s", err)
+	}
+	if ami != "ami-restored" {
+		t.Errorf("unexpected restored AMI %q", ami)
+	}
+	input := conn.restoreInput
+	if aws.ToString(input.Bucket) != "ami-archive-us-east-1" || aws.ToString(input.ObjectKey) != "ami-1.bin" ||
+		aws.ToString(input.Name) != "web-restored" {
+		t.Errorf("unexpected CreateRestoreImageTask input %+v", input)
+	}
+	if len(input.TagSpecifications) != 2 || aws.ToString(input.TagSpecifications[0].Tags[0].Value) != "us-east-1" {
+		t.Errorf("expected the AMI and its snapshots to be tagged, got %+v", input.TagSpecifications)
+	}
+}
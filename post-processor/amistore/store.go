@@ -0,0 +1,106 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amistore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// bucket renders the name of the bucket of region.
+func (p *PostProcessor) bucket(region string) (string, error) {
+	ictx := p.config.ctx
+	ictx.Data = &bucketData{BuildRegion: region}
+	bucket, err := interpolate.Render(p.config.S3Bucket, &ictx)
+	if err != nil {
+		return "", fmt.Errorf("Error interpolating s3_bucket_name: %s", err)
+	}
+	return bucket, nil
+}
+
+// store stores ami in the bucket of region, waits for the store task to
+// complete, and returns the S3 URL of the stored object.
+func (p *PostProcessor) store(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, region, ami string) (string, error) {
+	bucket, err := p.bucket(region)
+	if err != nil {
+		return "", err
+	}
+
+	ui.Say(fmt.Sprintf("Storing AMI %s (%s) in s3://%s", ami, region, bucket))
+	input := &ec2.CreateStoreImageTaskInput{
+		ImageId: aws.String(ami),
+		Bucket:  aws.String(bucket),
+	}
+	keys := make([]string, 0, len(p.config.S3ObjectTags))
+	for key := range p.config.S3ObjectTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		input.S3ObjectTags = append(input.S3ObjectTags, ec2types.S3ObjectTag{
+			Key:   aws.String(key),
+			Value: aws.String(p.config.S3ObjectTags[key]),
+		})
+	}
+	resp, err := conn.CreateStoreImageTask(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("Failed to start storing AMI %s in %s: %s", ami, region, err)
+	}
+	url := fmt.Sprintf("s3://%s/%s", bucket, aws.ToString(resp.ObjectKey))
+
+	ui.Say(fmt.Sprintf("Waiting for AMI %s to be stored to %s (may take a while)", ami, url))
+	err = p.config.PollingConfig.WaitUntilImageStored(ctx, conn, ami, awscommon.ReportTaskProgress(ui))
+	if err != nil {
+		return "", fmt.Errorf("Failed to store AMI %s in %s: %s", ami, region, err)
+	}
+	ui.Say(fmt.Sprintf("AMI %s stored to %s", ami, url))
+	return url, nil
+}
+
+// restore restores the stored object as a new AMI in region, waits for it
+// to be available, and returns its ID.
+func (p *PostProcessor) restore(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, region string) (string, error) {
+	bucket, err := p.bucket(region)
+	if err != nil {
+		return "", err
+	}
+
+	ui.Say(fmt.Sprintf("Restoring s3://%s/%s as an AMI in %s", bucket, p.config.ObjectKey, region))
+	input := &ec2.CreateRestoreImageTaskInput{
+		Bucket:    aws.String(bucket),
+		ObjectKey: aws.String(p.config.ObjectKey),
+	}
+	if p.config.AMIName != "" {
+		input.Name = aws.String(p.config.AMIName)
+	}
+	if len(p.config.Tags) > 0 {
+		tags, err := awscommon.TagMap(p.config.Tags).EC2Tags(p.config.ctx, region, new(multistep.BasicStateBag))
+		if err != nil {
+			return "", fmt.Errorf("Error tagging restored AMI: %s", err)
+		}
+		input.TagSpecifications = tags.TagSpecifications(ec2types.ResourceTypeImage, ec2types.ResourceTypeSnapshot)
+	}
+	resp, err := conn.CreateRestoreImageTask(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("Failed to restore s3://%s/%s: %s", bucket, p.config.ObjectKey, err)
+	}
+	ami := aws.ToString(resp.ImageId)
+
+	ui.Say(fmt.Sprintf("Waiting for restored AMI %s to become available", ami))
+	if err := p.config.PollingConfig.WaitUntilAMIAvailable(ctx, conn, ami); err != nil {
+		return "", fmt.Errorf("Error waiting for restored AMI %s: %s", ami, err)
+	}
+	ui.Say(fmt.Sprintf("Restored AMI %s", ami))
+	return ami, nil
+}
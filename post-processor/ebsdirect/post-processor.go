@@ -0,0 +1,373 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package ebsdirect contains a post-processor writing a local raw disk
+// image straight to an EBS snapshot with the EBS direct APIs, and
+// registering an AMI from it.
+package ebsdirect
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ebs"
+	ebstypes "github.com/aws/aws-sdk-go-v2/service/ebs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
+)
+
+const BuilderId = "packer.post-processor.amazon-ebs-direct"
+
+const (
+	gib                       = 1024 * 1024 * 1024
+	defaultUploadConcurrency  = 16
+	defaultRootDeviceName     = "/dev/sda1"
+	defaultRootVolumeType     = "gp3"
+	defaultSnapshotBlockSizeB = 512 * 1024
+)
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The name of the resulting AMI.
+	AMIName string `mapstructure:"ami_name" required:"true"`
+	// The description to set for the resulting AMI and its snapshot.
+	AMIDescription string `mapstructure:"ami_description" required:"false"`
+	// The artifact file to write to the snapshot, matched against the base
+	// name of the artifact files. Defaults to the only file of the
+	// artifact, or to its only file with the `.raw` or `.img` extension.
+	// The file must be a raw disk image, sparse files are fine.
+	DiskFile string `mapstructure:"disk_file" required:"false"`
+	// The architecture of the resulting AMI. One of `i386`, `x86_64`,
+	// `arm64`, `x86_64_mac` or `arm64_mac`. Defaults to `x86_64`.
+	Architecture string `mapstructure:"architecture" required:"false"`
+	// The boot mode of the resulting AMI. One of `legacy-bios`, `uefi` or
+	// `uefi-preferred`. Defaults to `uefi` for `arm64` AMIs, and is left
+	// unset otherwise.
+	BootMode string `mapstructure:"boot_mode" required:"false"`
+	// Enable enhanced networking (ENA) on the resulting AMI. Defaults to
+	// `true`.
+	AMIENASupport config.Trilean `mapstructure:"ena_support" required:"false"`
+	// The device name of the root volume of the resulting AMI. Defaults to
+	// `/dev/sda1`.
+	RootDeviceName string `mapstructure:"root_device_name" required:"false"`
+	// The size of the snapshot and of the root volume, in GiB. Defaults to
+	// the size of the disk file, rounded up to the next GiB.
+	VolumeSize int64 `mapstructure:"volume_size" required:"false"`
+	// The type of the root volume of the resulting AMI. Defaults to `gp3`.
+	VolumeType string `mapstructure:"volume_type" required:"false"`
+	// Whether to encrypt the snapshot. The AMI and the volumes launched from
+	// it are encrypted as well. Default `false`.
+	AMIEncryptBootVolume bool `mapstructure:"encrypt_boot" required:"false"`
+	// The ARN of the KMS key to encrypt the snapshot with. Defaults to the
+	// default EBS key of the account.
+	AMIKmsKeyId string `mapstructure:"kms_key_id" required:"false"`
+	// Key/value pair tags applied to the snapshot and the AMI.
+	Tags map[string]string `mapstructure:"tags" required:"false"`
+	// The number of blocks written to the snapshot in parallel. Defaults to
+	// `16`.
+	UploadConcurrency int `mapstructure:"upload_concurrency" required:"false"`
+	// The endpoint of the EBS direct APIs, for a stand-in of the service.
+	// Defaults to the endpoint of the region.
+	CustomEndpointEBS string `mapstructure:"custom_endpoint_ebs" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"tags",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Architecture == "" {
+		p.config.Architecture = string(ec2types.ArchitectureValuesX8664)
+	}
+	if p.config.BootMode == "" && p.config.Architecture == string(ec2types.ArchitectureValuesArm64) {
+		p.config.BootMode = string(ec2types.BootModeValuesUefi)
+	}
+	if p.config.AMIENASupport == config.TriUnset {
+		p.config.AMIENASupport = config.TriTrue
+	}
+	if p.config.RootDeviceName == "" {
+		p.config.RootDeviceName = defaultRootDeviceName
+	}
+	if p.config.VolumeType == "" {
+		p.config.VolumeType = defaultRootVolumeType
+	}
+	if p.config.UploadConcurrency == 0 {
+		p.config.UploadConcurrency = defaultUploadConcurrency
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if p.config.AMIName == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("ami_name must be set"))
+	}
+	if !slices.Contains(ec2types.ArchitectureValues("").Values(), ec2types.ArchitectureValues(p.config.Architecture)) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid architecture '%s'", p.config.Architecture))
+	}
+	if p.config.BootMode != "" {
+		if err := awscommon.IsValidBootMode(p.config.BootMode); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if p.config.VolumeSize < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid volume_size %d, must be positive", p.config.VolumeSize))
+	}
+	if p.config.UploadConcurrency < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid upload_concurrency %d, must be positive", p.config.UploadConcurrency))
+	}
+	if p.config.AMIKmsKeyId != "" {
+		if !p.config.AMIEncryptBootVolume {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("kms_key_id requires encrypt_boot to be true"))
+		}
+		if !strings.HasPrefix(p.config.AMIKmsKeyId, "arn:") {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("kms_key_id must be the ARN of a KMS key"))
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	source, err := findDiskFile(artifact.Files(), p.config.DiskFile)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+	ec2Client, err := p.config.NewEC2Client(ctx)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to create EC2 client: %s", err)
+	}
+
+	tags, err := awscommon.TagMap(p.config.Tags).EC2Tags(p.config.ctx, awsConfig.Region, new(multistep.BasicStateBag))
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	snapshotID, err := p.writeSnapshot(ctx, ui, p.ebsClient(awsConfig), ec2Client, source, tags)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	ami, err := p.registerImage(ctx, ui, ec2Client, snapshotID, tags)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	return &awscommon.Artifact{
+		Amis:           map[string]string{awsConfig.Region: ami},
+		BuilderIdValue: BuilderId,
+		StateData: map[string]interface{}{
+			"snapshots": map[string][]string{awsConfig.Region: {snapshotID}},
+		},
+		Config: awsConfig,
+	}, false, false, nil
+}
+
+// ebsClient returns a client for the EBS direct APIs of the region.
+func (p *PostProcessor) ebsClient(awsConfig *aws.Config) *ebs.Client {
+	return ebs.NewFromConfig(*awsConfig, func(o *ebs.Options) {
+		if p.config.CustomEndpointEBS != "" {
+			o.BaseEndpoint = aws.String(p.config.CustomEndpointEBS)
+		}
+	})
+}
+
+// findDiskFile returns the artifact file to write to the snapshot.
+func findDiskFile(files []string, name string) (string, error) {
+	if name != "" {
+		for _, file := range files {
+			if filepath.Base(file) == name || file == name {
+				return file, nil
+			}
+		}
+		return "", fmt.Errorf("No artifact file matches disk_file %q, the artifact files are: %s", name, strings.Join(files, ", "))
+	}
+
+	if len(files) == 1 {
+		return files[0], nil
+	}
+	var disks []string
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".raw", ".img":
+			disks = append(disks, file)
+		}
+	}
+	if len(disks) != 1 {
+		return "", fmt.Errorf("Found %d disk images in the %d artifact files, set disk_file to the one to write", len(disks), len(files))
+	}
+	return disks[0], nil
+}
+
+// writeSnapshot writes source to a new snapshot with the EBS direct APIs,
+// and waits for the snapshot to be completed. The snapshot is deleted if
+// it could not be written.
+func (p *PostProcessor) writeSnapshot(ctx context.Context, ui packersdk.Ui, client ebsAPI, ec2Client clients.Ec2Client,
+	source string, tags awscommon.EC2Tags) (string, error) {
+	disk, err := os.Open(source)
+	if err != nil {
+		return "", fmt.Errorf("Failed to open disk %s: %s", source, err)
+	}
+	defer disk.Close()
+
+	info, err := disk.Stat()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read the size of disk %s: %s", source, err)
+	}
+	size := info.Size()
+	volumeSize := (size + gib - 1) / gib
+	if p.config.VolumeSize > 0 {
+		if p.config.VolumeSize < volumeSize {
+			return "", fmt.Errorf("volume_size %d GiB is smaller than disk %s (%d bytes)", p.config.VolumeSize, source, size)
+		}
+		volumeSize = p.config.VolumeSize
+	}
+
+	input := &ebs.StartSnapshotInput{
+		VolumeSize:  aws.Int64(volumeSize),
+		Encrypted:   aws.Bool(p.config.AMIEncryptBootVolume),
+		ClientToken: aws.String(uuid.TimeOrderedUUID()),
+	}
+	if p.config.AMIDescription != "" {
+		input.Description = aws.String(p.config.AMIDescription)
+	}
+	if p.config.AMIKmsKeyId != "" {
+		input.KmsKeyArn = aws.String(p.config.AMIKmsKeyId)
+	}
+	for _, tag := range tags {
+		input.Tags = append(input.Tags, ebstypes.Tag{Key: tag.Key, Value: tag.Value})
+	}
+
+	ui.Say(fmt.Sprintf("Starting a %d GiB snapshot for %s", volumeSize, source))
+	started, err := client.StartSnapshot(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("Failed to start snapshot: %s", err)
+	}
+	snapshotID := aws.ToString(started.SnapshotId)
+	blockSize := int(aws.ToInt32(started.BlockSize))
+	if blockSize == 0 {
+		blockSize = defaultSnapshotBlockSizeB
+	}
+
+	uploader := &blockUploader{
+		client:      client,
+		snapshotID:  snapshotID,
+		blockSize:   blockSize,
+		concurrency: p.config.UploadConcurrency,
+	}
+	ui.Say(fmt.Sprintf("Writing %s to snapshot %s", source, snapshotID))
+	result, err := uploader.upload(ctx, ui, disk, size)
+	if err == nil {
+		ui.Say(fmt.Sprintf("Completing snapshot %s, %d blocks written", snapshotID, result.changedBlocks))
+		_, err = client.CompleteSnapshot(ctx, &ebs.CompleteSnapshotInput{
+			SnapshotId:                aws.String(snapshotID),
+			ChangedBlocksCount:        aws.Int32(int32(result.changedBlocks)),
+			Checksum:                  aws.String(result.checksum),
+			ChecksumAlgorithm:         ebstypes.ChecksumAlgorithmChecksumAlgorithmSha256,
+			ChecksumAggregationMethod: ebstypes.ChecksumAggregationMethodChecksumAggregationLinear,
+		})
+	}
+	if err == nil {
+		err = p.config.PollingConfig.WaitUntilSnapshotDone(ctx, ec2Client, snapshotID)
+	}
+	if err != nil {
+		ui.Say(fmt.Sprintf("Deleting snapshot %s", snapshotID))
+		_, deleteErr := ec2Client.DeleteSnapshot(context.TODO(), &ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshotID)})
+		if deleteErr != nil {
+			ui.Error(fmt.Sprintf("Failed to delete snapshot %s, delete it manually: %s", snapshotID, deleteErr))
+		}
+		return "", fmt.Errorf("Failed to write snapshot %s: %s", snapshotID, err)
+	}
+
+	return snapshotID, nil
+}
+
+// registerImage registers the AMI of snapshotID and waits for it to be
+// available.
+func (p *PostProcessor) registerImage(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client,
+	snapshotID string, tags awscommon.EC2Tags) (string, error) {
+	input := &ec2.RegisterImageInput{
+		Name:               aws.String(p.config.AMIName),
+		Architecture:       ec2types.ArchitectureValues(p.config.Architecture),
+		RootDeviceName:     aws.String(p.config.RootDeviceName),
+		VirtualizationType: aws.String("hvm"),
+		EnaSupport:         p.config.AMIENASupport.ToBoolPointer(),
+		BlockDeviceMappings: []ec2types.BlockDeviceMapping{{
+			DeviceName: aws.String(p.config.RootDeviceName),
+			Ebs: &ec2types.EbsBlockDevice{
+				SnapshotId:          aws.String(snapshotID),
+				VolumeType:          ec2types.VolumeType(p.config.VolumeType),
+				DeleteOnTermination: aws.Bool(true),
+			},
+		}},
+		TagSpecifications: tags.TagSpecifications(ec2types.ResourceTypeImage),
+	}
+	if p.config.AMIDescription != "" {
+		input.Description = aws.String(p.config.AMIDescription)
+	}
+	if p.config.BootMode != "" {
+		input.BootMode = ec2types.BootModeValues(p.config.BootMode)
+	}
+
+	ui.Say(fmt.Sprintf("Registering AMI %s from snapshot %s", p.config.AMIName, snapshotID))
+	resp, err := ec2Client.RegisterImage(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("Failed to register AMI: %s", err)
+	}
+	ami := aws.ToString(resp.ImageId)
+
+	ui.Say(fmt.Sprintf("Waiting for AMI %s to become available", ami))
+	if err := p.config.PollingConfig.WaitUntilAMIAvailable(ctx, ec2Client, ami); err != nil {
+		return "", fmt.Errorf("Failed waiting for AMI %s: %s", ami, err)
+	}
+	return ami, nil
+}
@@ -0,0 +1,355 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package ebsdirect
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ebs"
+	ebstypes "github.com/aws/aws-sdk-go-v2/service/ebs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const testBlockSize = 8
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key": "foo",
+		"secret_key": "bar",
+		"region":     "us-east-1",
+		"ami_name":   "packer-test",
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	p.config.PollingConfig = &awscommon.AWSPollingConfig{DelaySeconds: 1}
+	return &p
+}
+
+type mockEBSDirectEC2 struct {
+	clients.Ec2Client
+
+	register *ec2.RegisterImageInput
+	deleted  []string
+}
+
+func (m *mockEBSDirectEC2) DescribeSnapshots(ctx context.Context, input *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error) {
+	return &ec2.DescribeSnapshotsOutput{Snapshots: []ec2types.Snapshot{{
+		SnapshotId: aws.String(input.SnapshotIds[0]),
+		State:      ec2types.SnapshotStateCompleted,
+	}}}, nil
+}
+
+func (m *mockEBSDirectEC2) DeleteSnapshot(ctx context.Context, input *ec2.DeleteSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSnapshotOutput, error) {
+	m.deleted = append(m.deleted, aws.ToString(input.SnapshotId))
+	return &ec2.DeleteSnapshotOutput{}, nil
+}
+
+func (m *mockEBSDirectEC2) RegisterImage(ctx context.Context, input *ec2.RegisterImageInput, optFns ...func(*ec2.Options)) (*ec2.RegisterImageOutput, error) {
+	m.register = input
+	return &ec2.RegisterImageOutput{ImageId: aws.String("ami-1234")}, nil
+}
+
+func (m *mockEBSDirectEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId: aws.String(input.ImageIds[0]),
+		State:   ec2types.ImageStateAvailable,
+	}}}, nil
+}
+
+// fakeEBS is a stand-in for the EBS direct APIs, checking the requests the
+// way the service does.
+type fakeEBS struct {
+	lock      sync.Mutex
+	start     *ebs.StartSnapshotInput
+	blocks    map[int32][]byte
+	checksums map[int32]string
+	completed bool
+	// failBlock makes PutSnapshotBlock fail for this index when >= 0.
+	failBlock int32
+}
+
+func newFakeEBS() *fakeEBS {
+	return &fakeEBS{
+		blocks:    map[int32][]byte{},
+		checksums: map[int32]string{},
+		failBlock: -1,
+	}
+}
+
+func (f *fakeEBS) StartSnapshot(ctx context.Context, input *ebs.StartSnapshotInput, optFns ...func(*ebs.Options)) (*ebs.StartSnapshotOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.start = input
+	return &ebs.StartSnapshotOutput{
+		SnapshotId: aws.String("snap-1234"),
+		BlockSize:  aws.Int32(testBlockSize),
+		Status:     ebstypes.StatusPending,
+	}, nil
+}
+
+func (f *fakeEBS) PutSnapshotBlock(ctx context.Context, input *ebs.PutSnapshotBlockInput, optFns ...func(*ebs.Options)) (*ebs.PutSnapshotBlockOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	index := aws.ToInt32(input.BlockIndex)
+	if index == f.failBlock {
+		return nil, fmt.Errorf("block rejected")
+	}
+	body, _ := io.ReadAll(input.BlockData)
+	if aws.ToInt32(input.DataLength) != testBlockSize || len(body) != testBlockSize {
+		return nil, fmt.Errorf("blocks must be full")
+	}
+	sum := sha256.Sum256(body)
+	checksum := base64.StdEncoding.EncodeToString(sum[:])
+	if aws.ToString(input.Checksum) != checksum || input.ChecksumAlgorithm != ebstypes.ChecksumAlgorithmChecksumAlgorithmSha256 {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+	f.blocks[index] = body
+	f.checksums[index] = checksum
+	return &ebs.PutSnapshotBlockOutput{}, nil
+}
+
+func (f *fakeEBS) CompleteSnapshot(ctx context.Context, input *ebs.CompleteSnapshotInput, optFns ...func(*ebs.Options)) (*ebs.CompleteSnapshotOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if count := int(aws.ToInt32(input.ChangedBlocksCount)); count != len(f.blocks) {
+		return nil, fmt.Errorf("%d blocks written, %d announced", len(f.blocks), count)
+	}
+	var indexes []int32
+	for index := range f.checksums {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	h := sha256.New()
+	for _, index := range indexes {
+		sum, _ := base64.StdEncoding.DecodeString(f.checksums[index])
+		h.Write(sum)
+	}
+	if input.ChecksumAggregationMethod != ebstypes.ChecksumAggregationMethodChecksumAggregationLinear ||
+		aws.ToString(input.Checksum) != base64.StdEncoding.EncodeToString(h.Sum(nil)) {
+		return nil, fmt.Errorf("aggregated checksum mismatch")
+	}
+	f.completed = true
+	return &ebs.CompleteSnapshotOutput{Status: ebstypes.StatusCompleted}, nil
+}
+
+// testDisk writes a disk of four blocks, the second one empty and the last
+// one partial.
+func testDisk(t *testing.T) string {
+	disk := bytes.Join([][]byte{
+		[]byte("aaaaaaaa"),
+		make([]byte, testBlockSize),
+		[]byte("cccccccc"),
+		[]byte("dd"),
+	}, nil)
+	path := filepath.Join(t.TempDir(), "disk.raw")
+	if err := os.WriteFile(path, disk, 0644); err != nil {
+		t.Fatalf("failed to write disk: %s", err)
+	}
+	return path
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Defaults(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	if p.config.Architecture != "x86_64" {
+		t.Errorf("expected architecture to default to x86_64, got %q", p.config.Architecture)
+	}
+	if p.config.BootMode != "" {
+		t.Errorf("expected no default boot_mode for x86_64, got %q", p.config.BootMode)
+	}
+	if !p.config.AMIENASupport.True() {
+		t.Errorf("expected ena_support to default to true")
+	}
+	if p.config.RootDeviceName != "/dev/sda1" || p.config.VolumeType != "gp3" || p.config.UploadConcurrency != 16 {
+		t.Errorf("unexpected defaults: %q, %q, %d", p.config.RootDeviceName, p.config.VolumeType, p.config.UploadConcurrency)
+	}
+
+	p = testPostProcessor(t, map[string]interface{}{"architecture": "arm64"})
+	if p.config.BootMode != "uefi" {
+		t.Errorf("expected arm64 to default to uefi, got %q", p.config.BootMode)
+	}
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"missing ami_name":     {"ami_name": ""},
+		"invalid architecture": {"architecture": "sparc"},
+		"invalid boot_mode":    {"boot_mode": "bios"},
+		"negative volume_size": {"volume_size": -1},
+		"kms without encrypt":  {"kms_key_id": "arn:aws:kms:us-east-1:123456789012:key/1234"},
+		"kms not an ARN":       {"encrypt_boot": true, "kms_key_id": "alias/mykey"},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestFindDiskFile(t *testing.T) {
+	tests := []struct {
+		files   []string
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{files: []string{"out/disk"}, want: "out/disk"},
+		{files: []string{"out/disk.raw", "out/disk.sha256"}, want: "out/disk.raw"},
+		{files: []string{"out/a.img", "out/b.raw"}, wantErr: true},
+		{files: []string{"out/a.img", "out/b.raw"}, name: "b.raw", want: "out/b.raw"},
+		{files: []string{"out/a.img"}, name: "c.raw", wantErr: true},
+		{files: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := findDiskFile(tt.files, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("findDiskFile(%v, %q) error = %v, wantErr %t", tt.files, tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("findDiskFile(%v, %q) = %q, want %q", tt.files, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPostProcessor_writeSnapshot(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"ami_description":    "test disk",
+		"upload_concurrency": 2,
+	})
+	fake := newFakeEBS()
+	conn := &mockEBSDirectEC2{}
+	tags := awscommon.EC2Tags{{Key: aws.String("Name"), Value: aws.String("test")}}
+
+	snapshotID, err := p.writeSnapshot(context.Background(), &packersdk.MockUi{}, fake, conn, testDisk(t), tags)
+	if err != nil {
+		t.Fatalf("writeSnapshot() failed: %s", err)
+	}
+	if snapshotID != "snap-1234" {
+		t.Errorf("unexpected snapshot %q", snapshotID)
+	}
+
+	if aws.ToInt64(fake.start.VolumeSize) != 1 || aws.ToString(fake.start.Description) != "test disk" || aws.ToString(fake.start.ClientToken) == "" {
+		t.Errorf("unexpected StartSnapshot input %+v", fake.start)
+	}
+	if len(fake.start.Tags) != 1 || aws.ToString(fake.start.Tags[0].Key) != "Name" || aws.ToString(fake.start.Tags[0].Value) != "test" {
+		t.Errorf("expected the snapshot to be tagged, got %v", fake.start.Tags)
+	}
+	if !fake.completed {
+		t.Fatalf("expected the snapshot to be completed")
+	}
+	if _, ok := fake.blocks[1]; ok || len(fake.blocks) != 3 {
+		t.Errorf("expected the empty block to be skipped, got blocks %v", fake.blocks)
+	}
+	if want := []byte("dd\x00\x00\x00\x00\x00\x00"); !bytes.Equal(fake.blocks[3], want) {
+		t.Errorf("expected the last block to be padded, got %q", fake.blocks[3])
+	}
+	if len(conn.deleted) != 0 {
+		t.Errorf("expected the snapshot to be kept, deleted %v", conn.deleted)
+	}
+}
+
+func TestPostProcessor_writeSnapshot_Failure(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	fake := newFakeEBS()
+	fake.failBlock = 2
+	conn := &mockEBSDirectEC2{}
+
+	_, err := p.writeSnapshot(context.Background(), &packersdk.MockUi{}, fake, conn, testDisk(t), nil)
+	if err == nil || !strings.Contains(err.Error(), "block rejected") {
+		t.Fatalf("expected the block error, got %v", err)
+	}
+	if fake.completed {
+		t.Errorf("expected the snapshot not to be completed")
+	}
+	if len(conn.deleted) != 1 || conn.deleted[0] != "snap-1234" {
+		t.Errorf("expected the snapshot to be deleted, deleted %v", conn.deleted)
+	}
+}
+
+func TestPostProcessor_writeSnapshot_VolumeTooSmall(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	p.config.VolumeSize = 1
+	path := filepath.Join(t.TempDir(), "disk.raw")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to write disk: %s", err)
+	}
+	if err := os.Truncate(path, gib+1); err != nil {
+		t.Fatalf("failed to grow disk: %s", err)
+	}
+
+	_, err := p.writeSnapshot(context.Background(), &packersdk.MockUi{}, newFakeEBS(), &mockEBSDirectEC2{}, path, nil)
+	if err == nil || !strings.Contains(err.Error(), "volume_size") {
+		t.Fatalf("expected a volume_size error, got %v", err)
+	}
+}
+
+func TestPostProcessor_registerImage(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"architecture": "arm64",
+		"ena_support":  false,
+	})
+	conn := &mockEBSDirectEC2{}
+
+	ami, err := p.registerImage(context.Background(), &packersdk.MockUi{}, conn, "snap-1234", nil)
+	if err != nil {
+		t.Fatalf("registerImage() failed: %s", err)
+	}
+	if ami != "ami-1234" {
+		t.Errorf("unexpected AMI %q", ami)
+	}
+
+	input := conn.register
+	if input.Architecture != ec2types.ArchitectureValuesArm64 || input.BootMode != ec2types.BootModeValuesUefi {
+		t.Errorf("unexpected architecture %q or boot mode %q", input.Architecture, input.BootMode)
+	}
+	if aws.ToBool(input.EnaSupport) {
+		t.Errorf("expected ENA support to be disabled")
+	}
+	if len(input.BlockDeviceMappings) != 1 {
+		t.Fatalf("expected a single block device, got %d", len(input.BlockDeviceMappings))
+	}
+	bdm := input.BlockDeviceMappings[0]
+	if aws.ToString(bdm.DeviceName) != "/dev/sda1" || aws.ToString(input.RootDeviceName) != "/dev/sda1" ||
+		aws.ToString(bdm.Ebs.SnapshotId) != "snap-1234" || bdm.Ebs.VolumeType != ec2types.VolumeTypeGp3 {
+		t.Errorf("unexpected root device %+v", bdm)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package ebsdirect
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ebs"
+	ebstypes "github.com/aws/aws-sdk-go-v2/service/ebs/types"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// ebsAPI is the part of the EBS direct APIs used to write a new snapshot.
+type ebsAPI interface {
+	StartSnapshot(ctx context.Context, params *ebs.StartSnapshotInput, optFns ...func(*ebs.Options)) (*ebs.StartSnapshotOutput, error)
+	PutSnapshotBlock(ctx context.Context, params *ebs.PutSnapshotBlockInput, optFns ...func(*ebs.Options)) (*ebs.PutSnapshotBlockOutput, error)
+	CompleteSnapshot(ctx context.Context, params *ebs.CompleteSnapshotInput, optFns ...func(*ebs.Options)) (*ebs.CompleteSnapshotOutput, error)
+}
+
+// blockUploader writes a disk image to a snapshot started with the EBS
+// direct APIs, one block at a time.
+type blockUploader struct {
+	client      ebsAPI
+	snapshotID  string
+	blockSize   int
+	concurrency int
+}
+
+// uploadResult is what CompleteSnapshot needs to know about the blocks
+// written to the snapshot.
+type uploadResult struct {
+	// The number of blocks written, all-zero blocks are skipped.
+	changedBlocks int64
+	// The base64 encoded SHA256 of the checksums of the written blocks,
+	// in the order of their index.
+	checksum string
+}
+
+// blockCount returns the number of blocks of blockSize needed to hold size
+// bytes.
+func blockCount(size int64, blockSize int) int64 {
+	return (size + int64(blockSize) - 1) / int64(blockSize)
+}
+
+// isZero tells whether block only holds zeroes. A new snapshot reads as
+// zeroes where nothing was written, so these blocks are skipped.
+func isZero(block []byte, zero []byte) bool {
+	return bytes.Equal(block, zero[:len(block)])
+}
+
+// readBlock reads the block at index of disk into buf, padding the last
+// block of the disk with zeroes.
+func readBlock(disk io.ReaderAt, size int64, index int64, buf []byte) error {
+	n, err := disk.ReadAt(buf, index*int64(len(buf)))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if n < len(buf) && index*int64(len(buf))+int64(n) < size {
+		return fmt.Errorf("short read of block %d", index)
+	}
+	clear(buf[n:])
+	return nil
+}
+
+// upload writes the size bytes of disk to the snapshot, skipping all-zero
+// blocks, with concurrency blocks in flight.
+func (u *blockUploader) upload(ctx context.Context, ui packersdk.Ui, disk io.ReaderAt, size int64) (*uploadResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blocks := blockCount(size, u.blockSize)
+	zero := make([]byte, u.blockSize)
+
+	// The checksums of the written blocks, by index.
+	checksums := make([][sha256.Size]byte, blocks)
+	written := make([]bool, blocks)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	errs := new(packersdk.MultiError)
+	var done, changed int64
+	reported := 0
+
+	indexes := make(chan int64)
+	wg.Add(u.concurrency)
+	for i := 0; i < u.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, u.blockSize)
+			for index := range indexes {
+				err := u.uploadBlock(ctx, disk, size, index, buf, zero, &checksums[index], &written[index], func() int {
+					lock.Lock()
+					defer lock.Unlock()
+					return int(done * 100 / blocks)
+				})
+
+				lock.Lock()
+				if err != nil {
+					errs = packersdk.MultiErrorAppend(errs, err)
+					cancel()
+				}
+				done++
+				if written[index] {
+					changed++
+				}
+				if progress := int(done * 100 / blocks); progress/10 > reported/10 {
+					reported = progress
+					ui.Message(fmt.Sprintf("Written %d%% of the disk, %d non-empty blocks", progress, changed))
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for index := int64(0); index < blocks; index++ {
+		select {
+		case indexes <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if len(errs.Errors) > 0 {
+		return nil, errs
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	for index := range checksums {
+		if written[index] {
+			h.Write(checksums[index][:])
+		}
+	}
+	return &uploadResult{
+		changedBlocks: changed,
+		checksum:      base64.StdEncoding.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// uploadBlock reads the block at index into buf and writes it to the
+// snapshot, unless it only holds zeroes. The checksum of the block and
+// whether it was written are stored in checksum and written.
+func (u *blockUploader) uploadBlock(ctx context.Context, disk io.ReaderAt, size int64, index int64, buf []byte, zero []byte,
+	checksum *[sha256.Size]byte, written *bool, progress func() int) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := readBlock(disk, size, index, buf); err != nil {
+		return fmt.Errorf("Failed to read block %d of the disk: %s", index, err)
+	}
+	if isZero(buf, zero) {
+		return nil
+	}
+
+	*checksum = sha256.Sum256(buf)
+	_, err := u.client.PutSnapshotBlock(ctx, &ebs.PutSnapshotBlockInput{
+		SnapshotId:        aws.String(u.snapshotID),
+		BlockIndex:        aws.Int32(int32(index)),
+		BlockData:         bytes.NewReader(buf),
+		DataLength:        aws.Int32(int32(len(buf))),
+		Checksum:          aws.String(base64.StdEncoding.EncodeToString(checksum[:])),
+		ChecksumAlgorithm: ebstypes.ChecksumAlgorithmChecksumAlgorithmSha256,
+		Progress:          aws.Int32(int32(progress())),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			// Another block failed first
+			return nil
+		}
+		return fmt.Errorf("Failed to write block %d to snapshot %s: %s", index, u.snapshotID, err)
+	}
+	*written = true
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package export
+
+import (
+	"fmt"
+	"os"
+)
+
+// Artifact is an AMI exported to S3, and optionally downloaded.
+type Artifact struct {
+	// The region and ID of the exported AMI.
+	Region string
+	AMI    string
+
+	// The ID of the export task.
+	TaskID string
+
+	// Where the exported disk is in S3.
+	S3Bucket string
+	S3Key    string
+
+	// The downloaded disks, if any.
+	DownloadedFiles []string
+}
+
+func (a *Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *Artifact) Files() []string {
+	return a.DownloadedFiles
+}
+
+func (a *Artifact) Id() string {
+	return a.S3URL()
+}
+
+// S3URL returns the URL of the exported disk in S3.
+func (a *Artifact) S3URL() string {
+	return fmt.Sprintf("s3://%s/%s", a.S3Bucket, a.S3Key)
+}
+
+func (a *Artifact) String() string {
+	msg := fmt.Sprintf("AMI %s (%s) was exported to %s", a.AMI, a.Region, a.S3URL())
+	for _, file := range a.DownloadedFiles {
+		msg += fmt.Sprintf("\nDownloaded to: %s", file)
+	}
+	return msg
+}
+
+func (a *Artifact) State(name string) interface{} {
+	switch name {
+	case "export_task_id":
+		return a.TaskID
+	case "s3_url":
+		return a.S3URL()
+	default:
+		return nil
+	}
+}
+
+// Destroy removes the downloaded disks. The disk in S3 is left alone.
+func (a *Artifact) Destroy() error {
+	for _, file := range a.DownloadedFiles {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
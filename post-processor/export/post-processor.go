@@ -0,0 +1,266 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package export contains a post-processor exporting an AMI built by one of
+// the amazon builders to S3 as a virtual machine disk.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-export"
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The name of the S3 bucket the AMI is exported to. The bucket must be
+	// in the region of the exported AMI, and grant the VM Import service
+	// role the permissions listed in
+	// [the AWS documentation](https://docs.aws.amazon.com/vm-import/latest/userguide/required-permissions.html).
+	S3Bucket string `mapstructure:"s3_bucket_name" required:"true"`
+	// The prefix of the exported disk in the bucket. EC2 names the disk
+	// after the export task, so the disk is exported to
+	// `<s3_prefix><export task ID>.<format>`. Defaults to `packer-export/`.
+	S3Prefix string `mapstructure:"s3_prefix" required:"false"`
+	// The disk image format to export the AMI to. One of `vmdk`, `vhd` or
+	// `raw`. Defaults to `vmdk`.
+	Format string `mapstructure:"format" required:"false"`
+	// The name of the role VM Import uses to write to the bucket. Defaults
+	// to `vmimport`.
+	RoleName string `mapstructure:"role_name" required:"false"`
+	// A description of the export task.
+	Description string `mapstructure:"description" required:"false"`
+	// A local directory to download the exported disk to once the export
+	// completes. The directory is created if needed. The downloaded disk is
+	// the file of the artifact, for the post-processors that follow, such as
+	// `checksum` or `compress`. By default the disk is left in S3 only and
+	// the artifact has no files.
+	DownloadPath string `mapstructure:"download_path" required:"false"`
+	// This option is useful if you use an object store whose API is
+	// compatible with S3, or a local stand-in for it, to download the
+	// exported disk from. Specify another endpoint like this
+	// https://s3.custom.endpoint.com. Requests to it use path-style
+	// addressing.
+	CustomEndpointS3 string `mapstructure:"custom_endpoint_s3" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Format == "" {
+		p.config.Format = "vmdk"
+	}
+	if p.config.S3Prefix == "" {
+		p.config.S3Prefix = "packer-export/"
+	}
+	if p.config.RoleName == "" {
+		p.config.RoleName = "vmimport"
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if p.config.S3Bucket == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("s3_bucket_name must be set"))
+	}
+
+	switch p.config.Format {
+	case "vmdk", "vhd", "raw":
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"invalid format '%s'. Only 'vmdk', 'vhd', or 'raw' are allowed", p.config.Format))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	ami, ok := amis[awsConfig.Region]
+	if !ok {
+		regions := make([]string, 0, len(amis))
+		for region := range amis {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+		return nil, false, false, fmt.Errorf("The artifact has no AMI in region %s, set region to one of: %s",
+			awsConfig.Region, strings.Join(regions, ", "))
+	}
+
+	ec2Client, err := p.config.NewEC2Client(ctx)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to create EC2 client: %s", err)
+	}
+
+	s3Client := s3.NewFromConfig(*awsConfig, func(o *s3.Options) {
+		if p.config.CustomEndpointS3 != "" {
+			o.BaseEndpoint = aws.String(p.config.CustomEndpointS3)
+			o.UsePathStyle = true
+		}
+	})
+
+	exported, err := p.export(ctx, ui, ec2Client, s3Client, awsConfig.Region, ami)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	// The AMI is still there, destroying it is up to keep_input_artifact.
+	return exported, true, false, nil
+}
+
+// export exports ami to S3, waits for the export and downloads it when
+// download_path is set.
+func (p *PostProcessor) export(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, s3Client *s3.Client,
+	region string, ami string) (*Artifact, error) {
+	ui.Say(fmt.Sprintf("Exporting AMI %s to s3://%s/%s as %s", ami, p.config.S3Bucket, p.config.S3Prefix, p.config.Format))
+	input := &ec2.ExportImageInput{
+		ImageId:         aws.String(ami),
+		DiskImageFormat: ec2types.DiskImageFormat(strings.ToUpper(p.config.Format)),
+		RoleName:        aws.String(p.config.RoleName),
+		S3ExportLocation: &ec2types.ExportTaskS3LocationRequest{
+			S3Bucket: aws.String(p.config.S3Bucket),
+			S3Prefix: aws.String(p.config.S3Prefix),
+		},
+	}
+	if p.config.Description != "" {
+		input.Description = aws.String(p.config.Description)
+	}
+	exportResp, err := ec2Client.ExportImage(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start export of AMI %s: %s", ami, err)
+	}
+	taskID := aws.ToString(exportResp.ExportImageTaskId)
+
+	if err := p.waitExport(ctx, ui, ec2Client, taskID); err != nil {
+		return nil, err
+	}
+
+	exported := &Artifact{
+		Region:   region,
+		AMI:      ami,
+		TaskID:   taskID,
+		S3Bucket: p.config.S3Bucket,
+		// EC2 names the exported disk after the task
+		S3Key: fmt.Sprintf("%s%s.%s", p.config.S3Prefix, taskID, p.config.Format),
+	}
+
+	if p.config.DownloadPath != "" {
+		file := filepath.Join(p.config.DownloadPath, path.Base(exported.S3Key))
+		if err := download(ctx, ui, s3Client, exported.S3Bucket, exported.S3Key, file); err != nil {
+			return nil, err
+		}
+		exported.DownloadedFiles = []string{file}
+	}
+
+	return exported, nil
+}
+
+// waitExport waits for the export task to complete, reporting its progress
+// to ui.
+func (p *PostProcessor) waitExport(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, taskID string) error {
+	ui.Say(fmt.Sprintf("Waiting for task %s to complete (may take a while)", taskID))
+	waitErr := p.config.PollingConfig.WaitUntilImageExported(ctx, ec2Client, taskID, awscommon.ReportTaskProgress(ui))
+	if waitErr == nil {
+		ui.Say(fmt.Sprintf("Export task %s complete", taskID))
+		return nil
+	}
+
+	// The task holds the most useful error message
+	exportResult, err := ec2Client.DescribeExportImageTasks(ctx, &ec2.DescribeExportImageTasksInput{
+		ExportImageTaskIds: []string{taskID},
+	})
+	if err != nil || len(exportResult.ExportImageTasks) == 0 {
+		return fmt.Errorf("Export task %s failed, error: %s", taskID, waitErr)
+	}
+	return fmt.Errorf("Export task %s failed with status message: %s, error: %s",
+		taskID, aws.ToString(exportResult.ExportImageTasks[0].StatusMessage), waitErr)
+}
+
+// download copies the exported disk from S3 to file, reporting progress to
+// ui.
+func download(ctx context.Context, ui packersdk.Ui, client *s3.Client, bucket, key, file string) error {
+	ui.Say(fmt.Sprintf("Downloading s3://%s/%s to %s", bucket, key, file))
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("Failed to create download directory: %s", err)
+	}
+
+	resp, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to download s3://%s/%s: %s", bucket, key, err)
+	}
+	defer resp.Body.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("Failed to create %s: %s", file, err)
+	}
+	defer f.Close()
+
+	body := ui.TrackProgress(path.Base(key), 0, aws.ToInt64(resp.ContentLength), resp.Body)
+	defer body.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		return fmt.Errorf("Failed to download s3://%s/%s: %s", bucket, key, err)
+	}
+	return f.Close()
+}
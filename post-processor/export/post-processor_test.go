@@ -0,0 +1,232 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package export
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":     "foo",
+		"secret_key":     "bar",
+		"region":         "us-east-1",
+		"s3_bucket_name": "exportbucket",
+	}
+}
+
+// mockExportEC2 replays a sequence of export task states, one per describe
+// call, repeating the last one.
+type mockExportEC2 struct {
+	clients.Ec2Client
+
+	input *ec2.ExportImageInput
+	tasks []ec2types.ExportImageTask
+	calls int
+}
+
+func (m *mockExportEC2) ExportImage(ctx context.Context, input *ec2.ExportImageInput, optFns ...func(*ec2.Options)) (*ec2.ExportImageOutput, error) {
+	m.input = input
+	return &ec2.ExportImageOutput{ExportImageTaskId: aws.String("export-ami-1234")}, nil
+}
+
+func (m *mockExportEC2) DescribeExportImageTasks(ctx context.Context, input *ec2.DescribeExportImageTasksInput, optFns ...func(*ec2.Options)) (*ec2.DescribeExportImageTasksOutput, error) {
+	task := m.tasks[min(m.calls, len(m.tasks)-1)]
+	m.calls++
+	return &ec2.DescribeExportImageTasksOutput{ExportImageTasks: []ec2types.ExportImageTask{task}}, nil
+}
+
+func exportTask(status, progress, message string) ec2types.ExportImageTask {
+	return ec2types.ExportImageTask{
+		ExportImageTaskId: aws.String("export-ami-1234"),
+		Status:            aws.String(status),
+		Progress:          aws.String(progress),
+		StatusMessage:     aws.String(message),
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	p.config.PollingConfig = &awscommon.AWSPollingConfig{DelaySeconds: 1}
+	return &p
+}
+
+// testS3Client returns a client of a fake S3 serving objects with the
+// content of their key.
+func testS3Client(t *testing.T) *s3.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	t.Cleanup(srv.Close)
+
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("foo", "bar", ""),
+		Retryer:      aws.NopRetryer{},
+	})
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessor_ImplementsArtifact(t *testing.T) {
+	var _ packersdk.Artifact = new(Artifact)
+}
+
+func TestPostProcessorConfigure_Defaults(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	if p.config.Format != "vmdk" {
+		t.Errorf("expected format to default to vmdk, got %q", p.config.Format)
+	}
+	if p.config.S3Prefix != "packer-export/" {
+		t.Errorf("expected s3_prefix to default to packer-export/, got %q", p.config.S3Prefix)
+	}
+	if p.config.RoleName != "vmimport" {
+		t.Errorf("expected role_name to default to vmimport, got %q", p.config.RoleName)
+	}
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"missing bucket": {"s3_bucket_name": ""},
+		"invalid format": {"format": "ova"},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessor_export(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "exports")
+	p := testPostProcessor(t, map[string]interface{}{
+		"format":        "vhd",
+		"s3_prefix":     "images/",
+		"download_path": dir,
+	})
+	conn := &mockExportEC2{tasks: []ec2types.ExportImageTask{
+		exportTask("active", "40", "converting"),
+		exportTask("completed", "", ""),
+	}}
+	ui := &packersdk.MockUi{}
+
+	artifact, err := p.export(context.Background(), ui, conn, testS3Client(t), "us-east-1", "ami-1234")
+	if err != nil {
+		t.Fatalf("export() failed: %s", err)
+	}
+
+	if conn.input.DiskImageFormat != ec2types.DiskImageFormatVhd {
+		t.Errorf("expected a VHD export, got %q", conn.input.DiskImageFormat)
+	}
+	if aws.ToString(conn.input.S3ExportLocation.S3Prefix) != "images/" {
+		t.Errorf("expected the images/ prefix, got %q", aws.ToString(conn.input.S3ExportLocation.S3Prefix))
+	}
+	if artifact.Id() != "s3://exportbucket/images/export-ami-1234.vhd" {
+		t.Errorf("unexpected artifact ID %q", artifact.Id())
+	}
+
+	found := false
+	for _, msg := range ui.SayMessages {
+		if msg.Message == "export-ami-1234: active, 40% (converting)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the task progress to be reported, got %v", ui.SayMessages)
+	}
+
+	want := filepath.Join(dir, "export-ami-1234.vhd")
+	if files := artifact.Files(); len(files) != 1 || files[0] != want {
+		t.Fatalf("expected the artifact files to be [%s], got %v", want, files)
+	}
+	content, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("failed to read the downloaded disk: %s", err)
+	}
+	if string(content) != "/exportbucket/images/export-ami-1234.vhd" {
+		t.Errorf("unexpected downloaded content %q", content)
+	}
+
+	if err := artifact.Destroy(); err != nil {
+		t.Fatalf("Destroy() failed: %s", err)
+	}
+	if _, err := os.Stat(want); !os.IsNotExist(err) {
+		t.Errorf("expected Destroy to remove the downloaded disk")
+	}
+}
+
+func TestPostProcessor_export_NoDownload(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	conn := &mockExportEC2{tasks: []ec2types.ExportImageTask{exportTask("completed", "", "")}}
+
+	artifact, err := p.export(context.Background(), &packersdk.MockUi{}, conn, nil, "us-east-1", "ami-1234")
+	if err != nil {
+		t.Fatalf("export() failed: %s", err)
+	}
+	if len(artifact.Files()) != 0 {
+		t.Errorf("expected no files without download_path, got %v", artifact.Files())
+	}
+}
+
+func TestPostProcessor_export_Failure(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	conn := &mockExportEC2{tasks: []ec2types.ExportImageTask{
+		exportTask("deleted", "", "ClientError: Insufficient permissions on the bucket"),
+	}}
+
+	_, err := p.export(context.Background(), &packersdk.MockUi{}, conn, nil, "us-east-1", "ami-1234")
+	if err == nil {
+		t.Fatalf("expected the export to fail")
+	}
+	if !strings.Contains(err.Error(), "Insufficient permissions") {
+		t.Errorf("expected the status message in the error, got %q", err)
+	}
+}
+
+func TestPostProcessor_PostProcess_MissingRegion(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{"skip_credential_validation": true})
+	artifact := &awscommon.Artifact{Amis: map[string]string{"eu-west-1": "ami-1234"}}
+
+	_, _, _, err := p.PostProcess(context.Background(), &packersdk.MockUi{}, artifact)
+	if err == nil || !strings.Contains(err.Error(), "eu-west-1") {
+		t.Fatalf("expected an error listing the regions of the artifact, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"fmt"
+	"strings"
+)
+
+// importFailureHints maps known VM Import failure messages to what can be done
+// about them. The first hint with a pattern found in the status message wins.
+var importFailureHints = []struct {
+	patterns []string
+	hint     string
+}{
+	{
+		patterns: []string{"service role", "vmimport"},
+		hint: "VM Import could not use its service role. Make sure the role exists, that it trusts " +
+			"vmie.amazonaws.com, and that it can read the S3 bucket, see " +
+			"https://docs.aws.amazon.com/vm-import/latest/userguide/required-permissions.html#vmimport-role. " +
+			"Set role_name if the role is not named vmimport.",
+	},
+	{
+		patterns: []string{"kms"},
+		hint: "VM Import could not use the KMS key. Grant the service role kms:CreateGrant, kms:Decrypt, " +
+			"kms:DescribeKey, kms:Encrypt, kms:GenerateDataKey* and kms:ReEncrypt* on the keys set in " +
+			"ami_kms_key and s3_encryption_key, and make sure they are enabled.",
+	},
+	{
+		patterns: []string{"unsupported kernel"},
+		hint: "The kernel of the guest is not supported by VM Import, see " +
+			"https://docs.aws.amazon.com/vm-import/latest/userguide/prerequisites.html. " +
+			"Set import_mode to snapshot to register the disk as-is without inspecting the guest.",
+	},
+	{
+		patterns: []string{"ovf"},
+		hint: "VM Import could not read the OVF descriptor of the OVA. Check that the OVA was exported " +
+			"with a single OVF file and stream-optimized VMDK disks, or import the disks directly with " +
+			"format set to vmdk.",
+	},
+	{
+		patterns: []string{"unknown os", "missing os files", "no valid partitions"},
+		hint: "VM Import could not find a supported operating system on the boot disk. Check that the " +
+			"first disk is the boot disk, or set import_mode to snapshot to register the disk as-is.",
+	},
+}
+
+// importFailureHint returns the remediation hint for an import task status
+// message, or an empty string for unknown failures.
+func importFailureHint(statusMessage string) string {
+	message := strings.ToLower(statusMessage)
+	for _, h := range importFailureHints {
+		for _, pattern := range h.patterns {
+			if strings.Contains(message, pattern) {
+				return h.hint
+			}
+		}
+	}
+	return ""
+}
+
+// importFailure builds the error for a failed import task, with the
+// remediation hint for its status message when there is one.
+func importFailure(taskID string, statusMessage string, err error) error {
+	msg := fmt.Sprintf("Import task %s failed", taskID)
+	if statusMessage != "" {
+		msg += fmt.Sprintf(" with status message: %s", statusMessage)
+	}
+	if err != nil {
+		msg += fmt.Sprintf(", error: %s", err)
+	}
+	if hint := importFailureHint(statusMessage); hint != "" {
+		msg += "\n" + hint
+	}
+	return fmt.Errorf("%s", msg)
+}
@@ -0,0 +1,155 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// mockImportEC2 replays a sequence of import task states, one per describe
+// call, repeating the last one.
+type mockImportEC2 struct {
+	clients.Ec2Client
+
+	imageTasks    [][]ec2types.ImportImageTask
+	snapshotTasks [][]ec2types.ImportSnapshotTask
+	calls         int
+}
+
+func (m *mockImportEC2) DescribeImportImageTasks(ctx context.Context, input *ec2.DescribeImportImageTasksInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImportImageTasksOutput, error) {
+	tasks := m.imageTasks[min(m.calls, len(m.imageTasks)-1)]
+	m.calls++
+	return &ec2.DescribeImportImageTasksOutput{ImportImageTasks: tasks}, nil
+}
+
+func (m *mockImportEC2) DescribeImportSnapshotTasks(ctx context.Context, input *ec2.DescribeImportSnapshotTasksInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImportSnapshotTasksOutput, error) {
+	tasks := m.snapshotTasks[min(m.calls, len(m.snapshotTasks)-1)]
+	m.calls++
+	return &ec2.DescribeImportSnapshotTasksOutput{ImportSnapshotTasks: tasks}, nil
+}
+
+func imageTask(status, progress, message string) []ec2types.ImportImageTask {
+	return []ec2types.ImportImageTask{{
+		ImportTaskId:  aws.String("import-ami-1234"),
+		ImageId:       aws.String("ami-1234"),
+		Status:        aws.String(status),
+		Progress:      aws.String(progress),
+		StatusMessage: aws.String(message),
+	}}
+}
+
+func testWaitPostProcessor() *PostProcessor {
+	p := &PostProcessor{}
+	p.config.PollingConfig = &awscommon.AWSPollingConfig{DelaySeconds: 1}
+	return p
+}
+
+func TestImportFailureHint(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"ClientError: The service role vmimport provided does not exist or does not have sufficient permissions", "role_name"},
+		{"ClientError: Unsupported kernel version 6.8.0-1009-aws", "import_mode"},
+		{"ClientError: Disk validation failed [OVF file parsing error: Invalid OVF manifest]", "OVF descriptor"},
+		{"ClientError: The provided KMS key is not accessible", "kms:CreateGrant"},
+		{"ClientError: Unknown OS / Missing OS files.", "boot disk"},
+		{"ServerError: An internal error occurred", ""},
+	}
+
+	for _, tt := range tests {
+		hint := importFailureHint(tt.message)
+		if tt.want == "" && hint != "" {
+			t.Errorf("expected no hint for %q, got %q", tt.message, hint)
+		}
+		if !strings.Contains(hint, tt.want) {
+			t.Errorf("expected the hint for %q to mention %q, got %q", tt.message, tt.want, hint)
+		}
+	}
+}
+
+func TestPostProcessor_waitImageImport(t *testing.T) {
+	p := testWaitPostProcessor()
+	ui := &packersdk.MockUi{}
+	conn := &mockImportEC2{imageTasks: [][]ec2types.ImportImageTask{
+		imageTask("active", "28", "converting"),
+		imageTask("completed", "", ""),
+	}}
+
+	task, err := p.waitImageImport(context.Background(), ui, conn, "import-ami-1234")
+	if err != nil {
+		t.Fatalf("waitImageImport() failed: %s", err)
+	}
+	if aws.ToString(task.ImageId) != "ami-1234" {
+		t.Errorf("expected ami-1234, got %q", aws.ToString(task.ImageId))
+	}
+
+	found := false
+	for _, msg := range ui.SayMessages {
+		if msg.Message == "import-ami-1234: active, 28% (converting)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the task progress to be reported, got %v", ui.SayMessages)
+	}
+}
+
+func TestPostProcessor_waitImageImport_Failure(t *testing.T) {
+	p := testWaitPostProcessor()
+	conn := &mockImportEC2{imageTasks: [][]ec2types.ImportImageTask{
+		imageTask("deleted", "", "ClientError: Unsupported kernel version 6.8.0"),
+	}}
+
+	task, err := p.waitImageImport(context.Background(), &packersdk.MockUi{}, conn, "import-ami-1234")
+	if err == nil {
+		t.Fatalf("expected the import to fail")
+	}
+	if aws.ToString(task.Status) != "deleted" {
+		t.Errorf("expected the failed task to be returned, got %v", task)
+	}
+	if !strings.Contains(err.Error(), "Unsupported kernel version") || !strings.Contains(err.Error(), "import_mode") {
+		t.Errorf("expected the status message and a hint in the error, got %q", err)
+	}
+}
+
+func TestPostProcessor_waitImageImport_MissingTask(t *testing.T) {
+	p := testWaitPostProcessor()
+	conn := &mockImportEC2{imageTasks: [][]ec2types.ImportImageTask{{}}}
+
+	if _, err := p.waitImageImport(context.Background(), &packersdk.MockUi{}, conn, "import-ami-1234"); err == nil {
+		t.Fatalf("expected an error for a missing task")
+	}
+}
+
+func TestPostProcessor_waitSnapshotImport_Failure(t *testing.T) {
+	p := testWaitPostProcessor()
+	conn := &mockImportEC2{snapshotTasks: [][]ec2types.ImportSnapshotTask{{{
+		ImportTaskId: aws.String("import-snap-1234"),
+		SnapshotTaskDetail: &ec2types.SnapshotTaskDetail{
+			Status:        aws.String("deleted"),
+			StatusMessage: aws.String("ClientError: The service role vmimport provided does not exist"),
+		},
+	}}}}
+
+	detail, err := p.waitSnapshotImport(context.Background(), &packersdk.MockUi{}, conn, "import-snap-1234")
+	if err == nil {
+		t.Fatalf("expected the import to fail")
+	}
+	if aws.ToString(detail.Status) != "deleted" {
+		t.Errorf("expected the failed task to be returned, got %v", detail)
+	}
+	if !strings.Contains(err.Error(), "role_name") {
+		t.Errorf("expected a hint about the service role in the error, got %q", err)
+	}
+}
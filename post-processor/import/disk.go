@@ -0,0 +1,145 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc mapstructure-to-hcl2 -type DiskConfig
+
+package amazonimport
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DiskConfig selects one of the artifact files to import, and where to attach
+// it on the resulting AMI.
+//
+// HCL2 example:
+//
+// ```hcl
+//
+//	disks {
+//	  file = "appliance-disk1.vmdk"
+//	}
+//	disks {
+//	  file        = "appliance-disk2.vmdk"
+//	  device_name = "/dev/sdf"
+//	}
+//
+// ```
+type DiskConfig struct {
+	// The artifact file to import. Matched against the full path of the
+	// artifact files first, then against their base name.
+	File string `mapstructure:"file" required:"true"`
+	// The device name to attach the disk to on the resulting AMI. Only used
+	// when `import_mode` is `snapshot`; the first disk is always the root
+	// device and is attached to `root_device_name`. Other disks default to
+	// `/dev/sdb`, `/dev/sdc` and so on, in order, skipping the names of the
+	// root device and of the other disks.
+	DeviceName string `mapstructure:"device_name" required:"false"`
+}
+
+// importDisk is a local disk file on its way to becoming an EBS snapshot.
+type importDisk struct {
+	Source     string
+	S3Key      string
+	DeviceName string
+	SnapshotId string
+}
+
+func (d *DiskConfig) Prepare() []error {
+	var errs []error
+	if d.File == "" {
+		errs = append(errs, fmt.Errorf("disks: file must be set"))
+	}
+	return errs
+}
+
+// findDisks picks the disks to import from the files of the artifact. When no
+// disks are configured, every file with the configured format's extension is
+// imported, in the order the artifact lists them.
+func findDisks(files []string, format string, disks []DiskConfig) ([]importDisk, error) {
+	var found []importDisk
+
+	if len(disks) == 0 {
+		for _, file := range files {
+			if strings.HasSuffix(file, "."+format) {
+				found = append(found, importDisk{Source: file})
+			}
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("No %s image file found in artifact from builder", format)
+		}
+		return found, nil
+	}
+
+	for _, disk := range disks {
+		source := ""
+		for _, file := range files {
+			if file == disk.File {
+				source = file
+				break
+			}
+		}
+		if source == "" {
+			for _, file := range files {
+				if filepath.Base(file) == disk.File {
+					source = file
+					break
+				}
+			}
+		}
+		if source == "" {
+			return nil, fmt.Errorf("No file matching %q found in artifact from builder", disk.File)
+		}
+		found = append(found, importDisk{
+			Source:     source,
+			DeviceName: disk.DeviceName,
+		})
+	}
+
+	return found, nil
+}
+
+// diskS3Key returns the key to upload the disk at index to. A single disk is
+// uploaded to s3_key_name as is, several disks get a numbered suffix before
+// the extension so that they don't overwrite each other.
+func diskS3Key(key string, index int, total int) string {
+	if total <= 1 {
+		return key
+	}
+	ext := path.Ext(key)
+	return fmt.Sprintf("%s-disk%d%s", strings.TrimSuffix(key, ext), index+1, ext)
+}
+
+// defaultDeviceNames returns the device names of the disks when registering
+// an AMI from imported snapshots, given their device_name. The first disk is
+// the root device, the other disks without a device name get the first of
+// /dev/sdb to /dev/sdz not taken by the root device or another disk.
+func defaultDeviceNames(rootDeviceName string, deviceNames []string) ([]string, error) {
+	taken := map[string]bool{rootDeviceName: true}
+	for _, name := range deviceNames {
+		taken[name] = true
+	}
+
+	names := make([]string, len(deviceNames))
+	next := 'b'
+	for i, name := range deviceNames {
+		switch {
+		case i == 0:
+			name = rootDeviceName
+		case name == "":
+			for taken[fmt.Sprintf("/dev/sd%c", next)] {
+				next++
+			}
+			if next > 'z' {
+				return nil, fmt.Errorf("disks: no device name left for disk %d out of /dev/sdb to /dev/sdz, set its device_name", i+1)
+			}
+			name = fmt.Sprintf("/dev/sd%c", next)
+			next++
+		}
+		names[i] = name
+	}
+	return names, nil
+}
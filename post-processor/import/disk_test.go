@@ -0,0 +1,124 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindDisks(t *testing.T) {
+	files := []string{
+		"output/appliance.ovf",
+		"output/appliance-disk1.vmdk",
+		"output/appliance-disk2.vmdk",
+		"other/appliance-disk2.vmdk",
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		disks   []DiskConfig
+		want    []importDisk
+		wantErr bool
+	}{
+		{
+			name:   "every file matching the format",
+			format: "vmdk",
+			want: []importDisk{
+				{Source: "output/appliance-disk1.vmdk"},
+				{Source: "output/appliance-disk2.vmdk"},
+				{Source: "other/appliance-disk2.vmdk"},
+			},
+		},
+		{
+			name:    "no file matching the format",
+			format:  "raw",
+			wantErr: true,
+		},
+		{
+			name:   "configured order and device names",
+			format: "vmdk",
+			disks: []DiskConfig{
+				{File: "other/appliance-disk2.vmdk"},
+				{File: "appliance-disk1.vmdk", DeviceName: "/dev/sdf"},
+			},
+			want: []importDisk{
+				{Source: "other/appliance-disk2.vmdk"},
+				{Source: "output/appliance-disk1.vmdk", DeviceName: "/dev/sdf"},
+			},
+		},
+		{
+			name:   "base name matches the first listed file",
+			format: "vmdk",
+			disks: []DiskConfig{
+				{File: "appliance-disk2.vmdk"},
+			},
+			want: []importDisk{
+				{Source: "output/appliance-disk2.vmdk"},
+			},
+		},
+		{
+			name:   "configured file missing from the artifact",
+			format: "vmdk",
+			disks: []DiskConfig{
+				{File: "appliance-disk3.vmdk"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findDisks(files, tt.format, tt.disks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findDisks() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("findDisks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiskS3Key(t *testing.T) {
+	if got := diskS3Key("packer-import-1.vmdk", 0, 1); got != "packer-import-1.vmdk" {
+		t.Errorf("expected a single disk to keep its key, got %q", got)
+	}
+	if got := diskS3Key("imports/packer-import-1.vmdk", 1, 2); got != "imports/packer-import-1-disk2.vmdk" {
+		t.Errorf("expected a numbered key, got %q", got)
+	}
+	if got := diskS3Key("imports/appliance", 0, 2); got != "imports/appliance-disk1" {
+		t.Errorf("expected a numbered key without extension, got %q", got)
+	}
+}
+
+func TestDefaultDeviceNames(t *testing.T) {
+	got, err := defaultDeviceNames("/dev/xvda", []string{"", "", "/dev/sdb", "", "/dev/sdd", ""})
+	if err != nil {
+		t.Fatalf("defaultDeviceNames() failed: %s", err)
+	}
+	want := []string{"/dev/xvda", "/dev/sdc", "/dev/sdb", "/dev/sde", "/dev/sdd", "/dev/sdf"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected device names: %s", diff)
+	}
+
+	// The root device is skipped too.
+	got, err = defaultDeviceNames("/dev/sdb", []string{"", ""})
+	if err != nil {
+		t.Fatalf("defaultDeviceNames() failed: %s", err)
+	}
+	if got[1] != "/dev/sdc" {
+		t.Errorf("expected /dev/sdc for the second disk with /dev/sdb as root device, got %q", got[1])
+	}
+
+	// /dev/sdb to /dev/sdz name 25 disks besides the root device.
+	if _, err := defaultDeviceNames("/dev/sda1", make([]string, 26)); err != nil {
+		t.Errorf("expected 26 disks to be named, got %s", err)
+	}
+	if _, err := defaultDeviceNames("/dev/sda1", make([]string, 27)); err == nil {
+		t.Errorf("expected an error for 27 disks")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+)
+
+// prepareRegions checks ami_regions and region_kms_key_ids the way the
+// builders do, and the keys used to encrypt the copies.
+func (c *Config) prepareRegions() []error {
+	var errs []error
+
+	for region := range c.AMIRegionKMSKeyIDs {
+		if !slices.Contains(c.AMIRegions, region) {
+			errs = append(errs, fmt.Errorf("Region %s is in region_kms_key_ids but not in ami_regions", region))
+		}
+	}
+	if len(c.AMIRegionKMSKeyIDs) > 0 {
+		for _, region := range c.AMIRegions {
+			if _, ok := c.AMIRegionKMSKeyIDs[region]; !ok {
+				errs = append(errs, fmt.Errorf("Region %s is in ami_regions but not in region_kms_key_ids", region))
+			}
+		}
+		if !c.Encrypt {
+			errs = append(errs, fmt.Errorf("If you have set region_kms_key_ids, ami_encrypt must also be true."))
+		}
+	}
+
+	for _, kmsKey := range c.AMIRegionKMSKeyIDs {
+		if kmsKey != "" && !awscommon.ValidateKmsKey(kmsKey) {
+			errs = append(errs, fmt.Errorf("%q is not a valid KMS Key Id.", kmsKey))
+		}
+	}
+
+	// Snapshots encrypted with a default key can not be shared
+	if len(c.SnapshotUsers) > 0 && c.Encrypt {
+		if c.KMSKey == "" {
+			errs = append(errs, fmt.Errorf("Cannot share snapshot encrypted with default KMS key, set ami_kms_key"))
+		}
+		for region, kmsKey := range c.AMIRegionKMSKeyIDs {
+			if kmsKey == "" {
+				errs = append(errs, fmt.Errorf("Cannot share snapshot encrypted with default KMS key in region %s", region))
+			}
+		}
+	}
+
+	return errs
+}
+
+// literalTemplate returns a template rendering to s, for the steps that
+// render values which were rendered already.
+func literalTemplate(s string) string {
+	return strings.ReplaceAll(s, "{{", `{{"{{"}}`)
+}
+
+// distribute runs the imported AMI through the steps the builders finish
+// their AMIs with: it is copied to ami_regions, then deprecated, protected,
+// shared and tagged in every region. It returns the AMI and snapshot IDs
+// by region.
+func (p *PostProcessor) distribute(ctx context.Context, ui packersdk.Ui, awsConfig *aws.Config, ec2Client clients.Ec2Client,
+	name string, ami string, snapshotIds []string) (map[string]string, map[string][]string, error) {
+	state := new(multistep.BasicStateBag)
+	state.Put("access_config", &p.config.AccessConfig)
+	state.Put("aws_config", awsConfig)
+	state.Put("ec2v2", ec2Client)
+	state.Put("ui", ui)
+	state.Put("amis", map[string]string{awsConfig.Region: ami})
+	state.Put("snapshots", map[string][]string{awsConfig.Region: snapshotIds})
+
+	// A nil trilean keeps the encryption of the imported AMI in the copies
+	var encrypt config.Trilean
+	if p.config.Encrypt {
+		encrypt = config.TriTrue
+	}
+
+	steps := []multistep.Step{
+		&awscommon.StepAMIRegionCopy{
+			AccessConfig:      &p.config.AccessConfig,
+			Regions:           p.config.AMIRegions,
+			AMIKmsKeyId:       p.config.KMSKey,
+			RegionKeyIds:      p.config.AMIRegionKMSKeyIDs,
+			EncryptBootVolume: encrypt,
+			Name:              name,
+			OriginalRegion:    awsConfig.Region,
+		},
+		&awscommon.StepEnableDeprecation{
+			AccessConfig:    &p.config.AccessConfig,
+			DeprecationTime: p.config.DeprecationTime,
+		},
+		&awscommon.StepEnableDeregistrationProtection{
+			AccessConfig:             &p.config.AccessConfig,
+			DeregistrationProtection: &p.config.DeregistrationProtection,
+		},
+		&awscommon.StepModifyAMIAttributes{
+			Description:   literalTemplate(p.config.Description),
+			Users:         p.config.Users,
+			Groups:        p.config.Groups,
+			OrgArns:       p.config.OrgArns,
+			OuArns:        p.config.OuArns,
+			SnapshotUsers: p.config.SnapshotUsers,
+			IMDSSupport:   p.config.AMIIMDSSupport,
+			Ctx:           p.config.ctx,
+			GeneratedData: &packerbuilderdata.GeneratedData{State: state},
+		},
+		&awscommon.StepCreateTags{
+			Tags:         p.config.Tags,
+			SnapshotTags: p.config.SnapshotTags,
+			Ctx:          p.config.ctx,
+		},
+	}
+
+	runner := commonsteps.NewRunner(steps, p.config.PackerConfig, ui)
+	runner.Run(ctx, state)
+	if rawErr, ok := state.GetOk("error"); ok {
+		return nil, nil, rawErr.(error)
+	}
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return nil, nil, fmt.Errorf("Distribution of AMI %s was cancelled", ami)
+	}
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return nil, nil, fmt.Errorf("Distribution of AMI %s failed", ami)
+	}
+
+	return state.Get("amis").(map[string]string), state.Get("snapshots").(map[string][]string), nil
+}
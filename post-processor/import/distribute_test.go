@@ -0,0 +1,179 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestPostProcessorConfigure_Distribution(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "copy to regions",
+			config: map[string]interface{}{
+				"ami_regions": []string{"us-west-2", "eu-west-1"},
+			},
+		},
+		{
+			name: "encrypted copies with region keys",
+			config: map[string]interface{}{
+				"ami_encrypt":        true,
+				"ami_kms_key":        "1234abcd-12ab-34cd-56ef-1234567890ab",
+				"ami_regions":        []string{"us-west-2"},
+				"region_kms_key_ids": map[string]string{"us-west-2": "alias/import"},
+				"snapshot_users":     []string{"123456789012"},
+			},
+		},
+		{
+			name: "region key without region",
+			config: map[string]interface{}{
+				"ami_encrypt":        true,
+				"ami_regions":        []string{"us-west-2"},
+				"region_kms_key_ids": map[string]string{"eu-west-1": "alias/import"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "region keys without encryption",
+			config: map[string]interface{}{
+				"ami_regions":        []string{"us-west-2"},
+				"region_kms_key_ids": map[string]string{"us-west-2": "alias/import"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid region key",
+			config: map[string]interface{}{
+				"ami_encrypt":        true,
+				"ami_regions":        []string{"us-west-2"},
+				"region_kms_key_ids": map[string]string{"us-west-2": "not a key"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sharing snapshots encrypted with the default key",
+			config: map[string]interface{}{
+				"ami_encrypt":    true,
+				"snapshot_users": []string{"123456789012"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "deprecate_at",
+			config: map[string]interface{}{
+				"deprecate_at": "2027-01-01T00:00:00Z",
+			},
+		},
+		{
+			name: "invalid deprecate_at",
+			config: map[string]interface{}{
+				"deprecate_at": "next year",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range tt.config {
+				c[k] = v
+			}
+			err := p.Configure(c)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("should not have error: %s", err)
+			}
+		})
+	}
+}
+
+func TestPostProcessorConfigure_DeregistrationProtectionCooldown(t *testing.T) {
+	var p PostProcessor
+	c := testConfig()
+	c["deregistration_protection"] = map[string]interface{}{
+		"with_cooldown": true,
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !p.config.DeregistrationProtection.Enabled {
+		t.Errorf("expected with_cooldown to enable deregistration protection")
+	}
+}
+
+func TestPostProcessorConfigure_TagsRenderedPerRegion(t *testing.T) {
+	var p PostProcessor
+	c := testConfig()
+	c["tags"] = map[string]string{"Region": "{{ .BuildRegion }}"}
+	c["snapshot_tags"] = map[string]string{"Region": "{{ .BuildRegion }}"}
+	c["ami_description"] = "Imported in {{ .BuildRegion }}"
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if p.config.Tags["Region"] != "{{ .BuildRegion }}" || p.config.SnapshotTags["Region"] != "{{ .BuildRegion }}" {
+		t.Errorf("expected tags to be left for the tagging step to render, got %v and %v", p.config.Tags, p.config.SnapshotTags)
+	}
+	if p.config.Description != "Imported in {{ .BuildRegion }}" {
+		t.Errorf("expected ami_description to be left for the attributes step to render, got %q", p.config.Description)
+	}
+}
+
+func TestPostProcessor_distribute_Description(t *testing.T) {
+	var descriptions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("Action") != "ModifyImageAttribute" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		descriptions = append(descriptions, r.Form.Get("Description.Value"))
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<ModifyImageAttributeResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><return>true</return></ModifyImageAttributeResponse>`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("AWS_ENDPOINT_URL_EC2", srv.URL)
+
+	var p PostProcessor
+	c := testSnapshotConfig()
+	c["ami_description"] = "Imported from {{ .SourceAMIName }}"
+	c["custom_endpoint_ec2"] = srv.URL
+	c["skip_credential_validation"] = true
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	// The generated data of the builder, which the distribution steps do
+	// not know about.
+	p.config.ctx.Data = map[string]interface{}{"SourceAMIName": "web-v1"}
+	if err := p.renderTemplates(); err != nil {
+		t.Fatalf("renderTemplates() failed: %s", err)
+	}
+
+	awsConfig, err := p.config.Config(context.Background())
+	if err != nil {
+		t.Fatalf("Config() failed: %s", err)
+	}
+	ec2Client, err := p.config.NewEC2Client(context.Background())
+	if err != nil {
+		t.Fatalf("NewEC2Client() failed: %s", err)
+	}
+	if _, _, err := p.distribute(context.Background(), packersdk.TestUi(t), awsConfig, ec2Client, "imported", "ami-1234", []string{"snap-1234"}); err != nil {
+		t.Fatalf("distribute() failed: %s", err)
+	}
+	if len(descriptions) != 1 || descriptions[0] != "Imported from web-v1" {
+		t.Errorf("expected the description rendered with the generated data, got %q", descriptions)
+	}
+}
@@ -9,20 +9,20 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/retry"
@@ -32,6 +32,11 @@ import (
 
 const BuilderId = "packer.post-processor.amazon-import"
 
+const (
+	importModeImage    = "image"
+	importModeSnapshot = "snapshot"
+)
+
 // Configuration of this post processor
 type Config struct {
 	common.PackerConfig    `mapstructure:",squash"`
@@ -63,6 +68,105 @@ type Config struct {
 	Architecture   string `mapstructure:"architecture"`
 	BootMode       string `mapstructure:"boot_mode"`
 	Platform       string `mapstructure:"platform"`
+	// How the uploaded disk is turned into an AMI. With `image` (the
+	// default), the disk goes through `ImportImage`, which inspects and
+	// modifies the guest OS. With `snapshot`, the disk is imported as-is
+	// through `ImportSnapshot` and the AMI is registered from the resulting
+	// snapshot with `RegisterImage`, so the guest OS is never altered.
+	// `snapshot` requires `ami_name` and a `format` of `raw`, `vhd` or `vmdk`.
+	ImportMode string `mapstructure:"import_mode" required:"false"`
+	// The device name of the root volume of the registered AMI. Only used
+	// when `import_mode` is `snapshot`. Defaults to `/dev/sda1`.
+	RootDeviceName string `mapstructure:"root_device_name" required:"false"`
+	// The size of the root volume of the registered AMI, in GiB. Only used
+	// when `import_mode` is `snapshot`. Defaults to the size of the imported
+	// snapshot.
+	RootVolumeSize int32 `mapstructure:"root_volume_size" required:"false"`
+	// Additional block device mappings to register with the AMI. A mapping
+	// whose `device_name` matches `root_device_name` is used as the root
+	// volume, with its `snapshot_id` set to the imported snapshot. Only used
+	// when `import_mode` is `snapshot`.
+	AMIMappings awscommon.BlockDevices `mapstructure:"ami_block_device_mappings" required:"false"`
+	// Enable enhanced networking (ENA but not SriovNetSupport) on the
+	// registered AMI. Only used when `import_mode` is `snapshot`.
+	AMIENASupport config.Trilean `mapstructure:"ena_support" required:"false"`
+	// Enable enhanced networking (SriovNetSupport but not ENA) on the
+	// registered AMI. Only used when `import_mode` is `snapshot`. Default
+	// `false`.
+	AMISriovNetSupport bool `mapstructure:"sriov_support" required:"false"`
+	// Base64 representation of the non-volatile UEFI variable store. For more information
+	// see [AWS documentation](https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/uefi-secure-boot-optionB.html).
+	// Only used when `import_mode` is `snapshot`.
+	UefiData string `mapstructure:"uefi_data" required:"false"`
+	// NitroTPM Support. Valid options are `v2.0`. See the documentation on
+	// [NitroTPM Support](https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/enable-nitrotpm-support-on-ami.html) for
+	// more information. Only used when `import_mode` is `snapshot`.
+	TpmSupport string `mapstructure:"tpm_support" required:"false"`
+	// The artifact files to import as the disks of the AMI, in order. The
+	// first disk is the boot disk. By default every artifact file with the
+	// extension of `format` is imported, in the order the builder lists them.
+	// See [DiskConfig](#disk-configuration) for the available fields.
+	Disks []DiskConfig `mapstructure:"disks" required:"false"`
+	// The size of the parts, in MiB, that files larger than one part are
+	// uploaded to S3 in. Interrupted multipart uploads are resumed on the
+	// next run, re-sending only the parts missing from S3. The part size is
+	// raised as needed to stay under the 10,000 parts S3 allows. Must be at
+	// least `5`. Defaults to `64`.
+	S3UploadPartSize int64 `mapstructure:"s3_upload_part_size" required:"false"`
+	// The number of parts uploaded to S3 in parallel. Defaults to `5`.
+	S3UploadConcurrency int `mapstructure:"s3_upload_concurrency" required:"false"`
+	// This option is useful if you use an object store whose API is
+	// compatible with S3, or a local stand-in for it. Specify another
+	// endpoint like this https://s3.custom.endpoint.com. Requests to it use
+	// path-style addressing.
+	CustomEndpointS3 string `mapstructure:"custom_endpoint_s3" required:"false"`
+	// Check the VM Import service role, `role_name` or `vmimport`, before
+	// uploading anything, and create it or grant it what the import needs
+	// when it falls short: a trust policy letting `vmie.amazonaws.com` assume
+	// it, and read access to `s3_bucket_name`, as well as to `ami_kms_key`
+	// and `s3_encryption_key` when they are set, which must then be key IDs
+	// or key ARNs rather than aliases. Permissions are added as
+	// an inline policy named `packer-vmimport`. Requires `iam:GetRole`,
+	// `iam:SimulatePrincipalPolicy`, `iam:CreateRole`,
+	// `iam:UpdateAssumeRolePolicy` and `iam:PutRolePolicy`. Default `false`.
+	CreateServiceRole bool `mapstructure:"create_service_role" required:"false"`
+	// Check the VM Import service role like `create_service_role` does, but
+	// without changing it: the build fails before uploading anything if the
+	// role is missing, or with the policy statements it is missing. Requires
+	// `iam:GetRole` and `iam:SimulatePrincipalPolicy`. Default `false`.
+	ValidateServiceRole bool `mapstructure:"validate_service_role" required:"false"`
+	// A list of regions to copy the AMI to. Tags and attributes are copied
+	// along with the AMI. AMI copying takes time depending on the size of
+	// the AMI, but will generally take many minutes.
+	AMIRegions []string `mapstructure:"ami_regions" required:"false"`
+	// regions to copy the ami to, along with the custom kms key id (alias or
+	// arn) to use for encryption for that region. Keys must match the regions
+	// provided in `ami_regions`. If you just want to encrypt using a default
+	// ID, you can stick with `ami_kms_key` and `ami_regions`. If you want a
+	// region to be encrypted with that region's default key ID, you can use
+	// an empty string `""` instead of a key id in this map. (e.g. `"us-east-1":
+	// ""`) However, you cannot use default key IDs if you are using this in
+	// conjunction with `snapshot_users` -- in that situation you must use
+	// custom keys. Requires `ami_encrypt` to be `true`.
+	AMIRegionKMSKeyIDs map[string]string `mapstructure:"region_kms_key_ids" required:"false"`
+	// The date and time to deprecate the AMI, in UTC, in the following
+	// format: YYYY-MM-DDTHH:MM:SSZ. If you specify a value for seconds,
+	// Amazon EC2 rounds the seconds to the nearest minute. Applied to the
+	// AMI in every region it is copied to.
+	DeprecationTime string `mapstructure:"deprecate_at" required:"false"`
+	// Enable AMI deregistration protection on the AMI in every region it is
+	// copied to. See
+	// [DeregistrationProtectionOptions](/packer/integrations/hashicorp/amazon/latest/components/builder/ebs#deregistration-protection-options)
+	// for the available fields.
+	DeregistrationProtection awscommon.DeregistrationProtectionOptions `mapstructure:"deregistration_protection" required:"false"`
+	// A list of account IDs that have access to create volumes from the
+	// snapshots of the AMI, in every region.
+	SnapshotUsers []string `mapstructure:"snapshot_users" required:"false"`
+	// Key/value pair tags to apply to the snapshots of the AMI. They
+	// override the `tags` with the same keys on the snapshots. Like `tags`,
+	// they are rendered with the `region` the disk is imported to, so
+	// `{{ .BuildRegion }}` is that region on the copies too.
+	SnapshotTags map[string]string `mapstructure:"snapshot_tags" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -82,6 +186,9 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		InterpolateFilter: &interpolate.RenderFilter{
 			Exclude: []string{
 				"s3_key_name",
+				"ami_description",
+				"snapshot_tags",
+				"tags",
 			},
 		},
 	}, raws...)
@@ -102,6 +209,14 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		p.config.Architecture = "x86_64"
 	}
 
+	if p.config.ImportMode == "" {
+		p.config.ImportMode = importModeImage
+	}
+
+	if p.config.ImportMode == importModeSnapshot && p.config.RootDeviceName == "" {
+		p.config.RootDeviceName = "/dev/sda1"
+	}
+
 	errs := new(packersdk.MultiError)
 
 	if p.config.BootMode == "" {
@@ -146,10 +261,72 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 			errs, fmt.Errorf("invalid format '%s'. Only 'ova', 'raw', 'vhd', 'vhdx', or 'vmdk' are allowed", p.config.Format))
 	}
 
+	for _, disk := range p.config.Disks {
+		errs = packersdk.MultiErrorAppend(errs, disk.Prepare()...)
+	}
+
+	if p.config.Format == "ova" && len(p.config.Disks) > 1 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("only one disk can be imported with the 'ova' format"))
+	}
+
+	switch p.config.ImportMode {
+	case importModeImage:
+		for _, disk := range p.config.Disks {
+			if disk.DeviceName != "" {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+					"disks: device_name can only be set when import_mode is '%s'", importModeSnapshot))
+				break
+			}
+		}
+		if p.config.UefiData != "" || p.config.TpmSupport != "" || len(p.config.AMIMappings) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"uefi_data, tpm_support and ami_block_device_mappings can only be set when import_mode is '%s'", importModeSnapshot))
+		}
+	case importModeSnapshot:
+		switch p.config.Format {
+		case "raw", "vmdk", "vhd":
+		default:
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"invalid format '%s' for snapshot imports. Only 'raw', 'vhd', or 'vmdk' are allowed", p.config.Format))
+		}
+		if p.config.Name == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"ami_name must be set when import_mode is '%s'", importModeSnapshot))
+		}
+		if p.config.LicenseType != "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"license_type can not be set when import_mode is '%s'", importModeSnapshot))
+		}
+		if p.config.TpmSupport != "" && ec2types.TpmSupportValues(p.config.TpmSupport) != ec2types.TpmSupportValuesV20 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(`The only valid tpm_support value is %q`,
+				ec2types.TpmSupportValuesV20))
+		}
+		if p.config.UefiData != "" && p.config.BootMode == "legacy-bios" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(`You can't use uefi_data with boot_mode set to "legacy-bios".`))
+		}
+		errs = packersdk.MultiErrorAppend(errs, p.config.AMIMappings.Prepare(&p.config.ctx)...)
+		if len(p.config.Disks) > 0 && p.config.Disks[0].DeviceName != "" && p.config.Disks[0].DeviceName != p.config.RootDeviceName {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"disks: the first disk is the root device, its device_name must be empty or match root_device_name %q", p.config.RootDeviceName))
+		}
+		deviceNames := make([]string, len(p.config.Disks))
+		for i, disk := range p.config.Disks {
+			deviceNames[i] = disk.DeviceName
+		}
+		if _, err := defaultDeviceNames(p.config.RootDeviceName, deviceNames); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"invalid import_mode '%s'. Only '%s' and '%s' are allowed", p.config.ImportMode, importModeImage, importModeSnapshot))
+	}
+
 	switch p.config.Platform {
 	case "windows", "linux":
 	case "":
-		if p.config.BootMode == "uefi" {
+		// RegisterImage does not need the platform, only the image import
+		// service does to pick the right conversion for uefi guests.
+		if p.config.BootMode == "uefi" && p.config.ImportMode == importModeImage {
 			errs = packersdk.MultiErrorAppend(
 				errs, fmt.Errorf("invalid platform '%s', 'platform' must be set for 'uefi' image imports", p.config.Platform))
 		}
@@ -161,8 +338,54 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		errs = packersdk.MultiErrorAppend(
 			errs, fmt.Errorf("invalid s3 encryption format '%s'. Only 'AES256' and 'aws:kms' are allowed", p.config.S3Encryption))
 	}
+	if p.config.CreateServiceRole && p.config.ValidateServiceRole {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("create_service_role and validate_service_role are mutually exclusive"))
+	}
+	if p.config.CreateServiceRole || p.config.ValidateServiceRole {
+		// The policy of the role names the keys, an alias would not match
+		// the key it points to.
+		if p.config.Encrypt && isKMSAlias(p.config.KMSKey) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"ami_kms_key must be a key ID or ARN, not the alias %q, with create_service_role or validate_service_role", p.config.KMSKey))
+		}
+		if p.config.S3Encryption == "aws:kms" && isKMSAlias(p.config.S3EncryptionKey) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"s3_encryption_key must be a key ID or ARN, not the alias %q, with create_service_role or validate_service_role", p.config.S3EncryptionKey))
+		}
+	}
+	if p.config.S3UploadPartSize == 0 {
+		p.config.S3UploadPartSize = defaultUploadPartSizeMB
+	}
+	if p.config.S3UploadPartSize*1024*1024 < minUploadPartSize {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("invalid s3_upload_part_size %d, must be at least 5 (MiB)", p.config.S3UploadPartSize))
+	}
+	if p.config.S3UploadConcurrency == 0 {
+		p.config.S3UploadConcurrency = defaultUploadConcurrency
+	}
+	if p.config.S3UploadConcurrency < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("invalid s3_upload_concurrency %d, must be positive", p.config.S3UploadConcurrency))
+	}
+
+	errs = packersdk.MultiErrorAppend(errs, p.config.prepareRegions()...)
+
+	if p.config.DeprecationTime != "" {
+		if _, err := time.Parse(time.RFC3339, p.config.DeprecationTime); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"deprecate_at is not a valid time: %q. Expect time format: YYYY-MM-DDTHH:MM:SSZ",
+				p.config.DeprecationTime))
+		}
+	}
+
+	if p.config.DeregistrationProtection.WithCooldown {
+		p.config.DeregistrationProtection.Enabled = true
+	}
 
-	if p.config.BootMode != "legacy-bios" && p.config.BootMode != "uefi" {
+	// ImportImage only knows about legacy-bios and uefi, RegisterImage
+	// also supports uefi-preferred which IsValidBootMode already checked.
+	if p.config.ImportMode == importModeImage && p.config.BootMode != "legacy-bios" && p.config.BootMode != "uefi" {
 		errs = packersdk.MultiErrorAppend(
 			errs, fmt.Errorf("invalid boot mode '%s'. Only 'uefi' and 'legacy-bios' are allowed", p.config.BootMode))
 	}
@@ -208,91 +431,178 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 	}
 	p.config.ctx.Data = generatedData
 
-	s3Client := s3.NewFromConfig(*config)
-
-	// Render this key since we didn't in the configure phase
-	p.config.S3Key, err = interpolate.Render(p.config.S3Key, &p.config.ctx)
-	if err != nil {
-		return nil, false, false, fmt.Errorf("Error rendering s3_key_name template: %s", err)
+	if p.config.CreateServiceRole || p.config.ValidateServiceRole {
+		iamClient := iam.NewFromConfig(*config)
+		if err := p.ensureServiceRole(ctx, ui, iamClient, p.config.CreateServiceRole); err != nil {
+			return nil, false, false, err
+		}
 	}
-	log.Printf("Rendered s3_key_name as %s", p.config.S3Key)
 
-	log.Println("Looking for image in artifact")
-	// Locate the files output from the builder
-	source := ""
-	for _, path := range artifact.Files() {
-		if strings.HasSuffix(path, "."+p.config.Format) {
-			source = path
-			break
+	s3Client := s3.NewFromConfig(*config, func(o *s3.Options) {
+		if p.config.CustomEndpointS3 != "" {
+			o.BaseEndpoint = aws.String(p.config.CustomEndpointS3)
+			o.UsePathStyle = true
 		}
+	})
+
+	if err := p.renderTemplates(); err != nil {
+		return nil, false, false, err
 	}
 
-	// Hope we found something useful
-	if source == "" {
-		return nil, false, false, fmt.Errorf("No %s image file found in artifact from builder", p.config.Format)
+	log.Println("Looking for images in artifact")
+	// Locate the files output from the builder
+	disks, err := findDisks(artifact.Files(), p.config.Format, p.config.Disks)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if p.config.Format == "ova" && len(disks) > 1 {
+		return nil, false, false, fmt.Errorf("Found %d ova files in artifact from builder, only one can be imported", len(disks))
+	}
+	for i := range disks {
+		disks[i].S3Key = diskS3Key(p.config.S3Key, i, len(disks))
 	}
 
 	if p.config.S3Encryption == "AES256" && p.config.S3EncryptionKey != "" {
 		ui.Say(fmt.Sprintf("Ignoring s3_encryption_key because s3_encryption is set to '%s'", p.config.S3Encryption))
 	}
 
-	// open the source file
-	log.Printf("Opening file %s to upload", source)
-	file, err := os.Open(source)
+	// Copy the image files into the S3 bucket specified
+	uploader := p.newUploader(s3Client)
+	for _, disk := range disks {
+		if err := uploader.Upload(ctx, ui, disk.Source, disk.S3Key); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	ec2Client, err := p.config.NewEC2Client(ctx)
 	if err != nil {
-		return nil, false, false, fmt.Errorf("Failed to open %s: %s", source, err)
+		return nil, false, false, fmt.Errorf("failed to create EC2 client: %s", err)
 	}
 
-	ui.Say(fmt.Sprintf("Uploading %s to s3://%s/%s", source, p.config.S3Bucket, p.config.S3Key))
+	// Import task IDs and their final status, for the artifact
+	tasks := map[string]string{}
 
-	// Prepare S3 request
-	updata := &s3.PutObjectInput{
-		Body:   file,
-		Bucket: &p.config.S3Bucket,
-		Key:    &p.config.S3Key,
+	var createdami string
+	switch p.config.ImportMode {
+	case importModeSnapshot:
+		createdami, err = p.importSnapshot(ctx, ui, ec2Client, disks, tasks)
+	default:
+		createdami, err = p.importImage(ctx, ui, ec2Client, config.Region, disks, tasks)
+	}
+	if err != nil {
+		return nil, false, false, err
 	}
 
-	// Add encryption if specified in the config
-	if p.config.S3Encryption != "" {
-		updata.ServerSideEncryption = s3types.ServerSideEncryption(p.config.S3Encryption)
-		if p.config.S3Encryption == string(s3types.ServerSideEncryptionAwsKms) && p.config.S3EncryptionKey != "" {
-			updata.SSEKMSKeyId = aws.String(p.config.S3EncryptionKey)
-		}
+	log.Printf("Getting details of %s", createdami)
+
+	imageResp, err := ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{createdami},
+	})
+
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Failed to retrieve details for AMI %s: %s", createdami, err)
 	}
 
-	// Copy the image file into the S3 bucket specified
-	uploader := manager.NewUploader(s3Client)
-	if _, err = uploader.Upload(ctx, updata); err != nil {
-		return nil, false, false, fmt.Errorf("Failed to upload %s: %s", source, err)
+	if len(imageResp.Images) == 0 {
+		return nil, false, false, fmt.Errorf("AMI %s has no images", createdami)
 	}
 
-	// May as well stop holding this open now
-	file.Close()
+	image := imageResp.Images[0]
 
-	ui.Say(fmt.Sprintf("Completed upload of %s to s3://%s/%s", source, p.config.S3Bucket, p.config.S3Key))
+	log.Printf("Walking block device mappings for %s to find snapshots", createdami)
 
-	// Call EC2 image import process
-	log.Printf("Calling EC2 to import from s3://%s/%s", p.config.S3Bucket, p.config.S3Key)
+	var snapshotIds []string
+	for _, device := range image.BlockDeviceMappings {
+		if device.Ebs == nil || device.Ebs.SnapshotId == nil {
+			continue
+		}
+		snapshotIds = append(snapshotIds, *device.Ebs.SnapshotId)
+		for i := range disks {
+			if disks[i].DeviceName == aws.ToString(device.DeviceName) {
+				disks[i].SnapshotId = *device.Ebs.SnapshotId
+			}
+		}
+	}
 
-	ec2Client, err := p.config.NewEC2Client(ctx)
+	diskSnapshots := make(map[string]string, len(disks))
+	for _, disk := range disks {
+		ui.Say(fmt.Sprintf("Disk %s: device %s, snapshot %s", disk.Source, disk.DeviceName, disk.SnapshotId))
+		diskSnapshots[disk.Source] = disk.SnapshotId
+	}
+
+	amis, snapshots, err := p.distribute(ctx, ui, config, ec2Client, aws.ToString(image.Name), createdami, snapshotIds)
 	if err != nil {
-		return nil, false, false, fmt.Errorf("failed to create EC2 client: %s", err)
+		return nil, false, false, err
 	}
 
-	params := &ec2.ImportImageInput{
-		Encrypted: &p.config.Encrypt,
-		DiskContainers: []ec2types.ImageDiskContainer{
-			{
-				Format: &p.config.Format,
-				UserBucket: &ec2types.UserBucket{
-					S3Bucket: &p.config.S3Bucket,
-					S3Key:    &p.config.S3Key,
-				},
-			},
+	log.Printf("Adding created AMIs %v to output artifacts", amis)
+	artifact = &awscommon.Artifact{
+		Amis:           amis,
+		BuilderIdValue: BuilderId,
+		StateData: map[string]interface{}{
+			"snapshots":      snapshots,
+			"disk_snapshots": diskSnapshots,
+			"import_tasks":   tasks,
 		},
-		Architecture: &p.config.Architecture,
-		BootMode:     ec2types.BootModeValues(p.config.BootMode),
-		Platform:     &p.config.Platform,
+		Config: config,
+	}
+
+	if !p.config.SkipClean {
+		for _, disk := range disks {
+			ui.Say(fmt.Sprintf("Deleting import source s3://%s/%s", p.config.S3Bucket, disk.S3Key))
+
+			_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: &p.config.S3Bucket,
+				Key:    aws.String(disk.S3Key),
+			})
+			if err != nil {
+				return nil, false, false, fmt.Errorf("Failed to delete s3://%s/%s: %s", p.config.S3Bucket, disk.S3Key, err)
+			}
+		}
+	}
+
+	return artifact, false, false, nil
+}
+
+// newUploader returns the uploader to copy disks to the S3 bucket with.
+func (p *PostProcessor) newUploader(client s3UploadAPI) *diskUploader {
+	u := &diskUploader{
+		client:      client,
+		bucket:      p.config.S3Bucket,
+		partSize:    p.config.S3UploadPartSize * 1024 * 1024,
+		concurrency: p.config.S3UploadConcurrency,
+		encryption:  p.config.S3Encryption,
+	}
+	if p.config.S3Encryption == string(s3types.ServerSideEncryptionAwsKms) {
+		u.kmsKeyID = p.config.S3EncryptionKey
+	}
+	return u
+}
+
+// importImage imports the uploaded disks with ImportImage, recording the task
+// and its final status in tasks, and returns the ID of the resulting AMI,
+// renamed to ami_name if one was configured. The device name the import
+// service picked for each disk is set on disks.
+func (p *PostProcessor) importImage(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, region string, disks []importDisk, tasks map[string]string) (string, error) {
+	// Call EC2 image import process
+	diskContainers := make([]ec2types.ImageDiskContainer, len(disks))
+	for i, disk := range disks {
+		log.Printf("Calling EC2 to import from s3://%s/%s", p.config.S3Bucket, disk.S3Key)
+		diskContainers[i] = ec2types.ImageDiskContainer{
+			Format: &p.config.Format,
+			UserBucket: &ec2types.UserBucket{
+				S3Bucket: &p.config.S3Bucket,
+				S3Key:    aws.String(disk.S3Key),
+			},
+		}
+	}
+
+	params := &ec2.ImportImageInput{
+		Encrypted:      &p.config.Encrypt,
+		DiskContainers: diskContainers,
+		Architecture:   &p.config.Architecture,
+		BootMode:       ec2types.BootModeValues(p.config.BootMode),
+		Platform:       &p.config.Platform,
 	}
 
 	if p.config.Encrypt && p.config.KMSKey != "" {
@@ -309,62 +619,46 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 	}
 
 	var importStart *ec2.ImportImageOutput
-	err = retry.Config{
+	err := retry.Config{
 		Tries:      11,
 		RetryDelay: (&retry.Backoff{InitialBackoff: 200 * time.Millisecond, MaxBackoff: 30 * time.Second, Multiplier: 2}).Linear,
 	}.Run(ctx, func(ctx context.Context) error {
+		var err error
 		importStart, err = ec2Client.ImportImage(ctx, params)
 		return err
 	})
 
 	if err != nil {
-		return nil, false, false, fmt.Errorf("Failed to start import from s3://%s/%s: %s", p.config.S3Bucket, p.config.S3Key, err)
+		return "", fmt.Errorf("Failed to start import from s3://%s/%s: %s", p.config.S3Bucket, disks[0].S3Key, err)
 	}
 
-	ui.Say(fmt.Sprintf("Started import of s3://%s/%s, task id %s", p.config.S3Bucket, p.config.S3Key,
-		*importStart.ImportTaskId))
-
-	// Wait for import process to complete, this takes a while
-	ui.Say(fmt.Sprintf("Waiting for task %s to complete (may take a while)", *importStart.ImportTaskId))
-
-	err = p.config.PollingConfig.WaitUntilImageImported(ctx, ec2Client, *importStart.ImportTaskId)
-	if err != nil {
-
-		// Retrieve the status message
-		importResult, err2 := ec2Client.DescribeImportImageTasks(ctx, &ec2.DescribeImportImageTasksInput{
-			ImportTaskIds: []string{
-				*importStart.ImportTaskId,
-			},
-		})
-
-		statusMessage := "Error retrieving status message"
+	ui.Say(fmt.Sprintf("Started import of %d disk(s) from s3://%s/%s, task id %s", len(disks), p.config.S3Bucket,
+		disks[0].S3Key, *importStart.ImportTaskId))
 
-		if err2 == nil {
-			statusMessage = *importResult.ImportImageTasks[0].StatusMessage
-		}
-		return nil, false, false, fmt.Errorf("Import task %s failed with status message: %s, error: %s", *importStart.ImportTaskId, statusMessage, err)
+	taskID := aws.ToString(importStart.ImportTaskId)
+	task, err := p.waitImageImport(ctx, ui, ec2Client, taskID)
+	if task != nil {
+		tasks[taskID] = aws.ToString(task.Status)
 	}
-
-	// Retrieve what the outcome was for the import task
-	importResult, err := ec2Client.DescribeImportImageTasks(ctx, &ec2.DescribeImportImageTasksInput{
-		ImportTaskIds: []string{
-			*importStart.ImportTaskId,
-		},
-	})
-
 	if err != nil {
-		return nil, false, false, fmt.Errorf("Failed to find import task %s: %s", *importStart.ImportTaskId, err)
-	}
-	// Check it was actually completed
-	if *importResult.ImportImageTasks[0].Status != "completed" {
-		// The most useful error message is from the job itself
-		return nil, false, false, fmt.Errorf("Import task %s failed: %s", *importStart.ImportTaskId, *importResult.ImportImageTasks[0].StatusMessage)
+		return "", err
 	}
 
-	ui.Say(fmt.Sprintf("Import task %s complete", *importStart.ImportTaskId))
-
 	// Pull AMI ID out of the completed job
-	createdami := *importResult.ImportImageTasks[0].ImageId
+	createdami := aws.ToString(task.ImageId)
+
+	// Match the disks to the devices they were attached to, renaming the
+	// AMI below keeps the device names.
+	for _, detail := range task.SnapshotDetails {
+		if detail.UserBucket == nil {
+			continue
+		}
+		for i := range disks {
+			if disks[i].S3Key == aws.ToString(detail.UserBucket.S3Key) {
+				disks[i].DeviceName = aws.ToString(detail.DeviceName)
+			}
+		}
+	}
 
 	if p.config.Name != "" {
 
@@ -373,7 +667,7 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 		copyInput := &ec2.CopyImageInput{
 			Name:          &p.config.Name,
 			SourceImageId: &createdami,
-			SourceRegion:  aws.String(config.Region),
+			SourceRegion:  aws.String(region),
 		}
 		if p.config.Encrypt {
 			copyInput.Encrypted = aws.Bool(p.config.Encrypt)
@@ -385,20 +679,20 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 		resp, err := ec2Client.CopyImage(ctx, copyInput)
 
 		if err != nil {
-			return nil, false, false, fmt.Errorf("Error Copying AMI (%s): %s", createdami, err)
+			return "", fmt.Errorf("Error Copying AMI (%s): %s", createdami, err)
 		}
 
 		ui.Say("Waiting for AMI rename to complete (may take a while)")
 
 		if err := p.config.PollingConfig.WaitUntilAMIAvailable(ctx, ec2Client, *resp.ImageId); err != nil {
-			return nil, false, false, fmt.Errorf("Error waiting for AMI (%s): %s", *resp.ImageId, err)
+			return "", fmt.Errorf("Error waiting for AMI (%s): %s", *resp.ImageId, err)
 		}
 
 		// Clean up intermediary image now that it has successfully been renamed.
 		ui.Say("Destroying intermediary AMI...")
 		err = awscommon.DestroyAMIs([]string{createdami}, ec2Client)
 		if err != nil {
-			return nil, false, false, fmt.Errorf("Error deregistering existing AMI: %s", err)
+			return "", fmt.Errorf("Error deregistering existing AMI: %s", err)
 		}
 
 		ui.Say("AMI rename completed")
@@ -406,173 +700,256 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 		createdami = *resp.ImageId
 	}
 
-	// If we have tags, then apply them now to both the AMI and snaps
-	// created by the import
-	if len(p.config.Tags) > 0 {
-		var ec2Tags []ec2types.Tag
+	return createdami, nil
+}
 
-		log.Printf("Repacking tags into AWS format")
+// importSnapshot imports each uploaded disk with ImportSnapshot and registers
+// an AMI named ami_name from the resulting snapshots, recording the tasks and
+// their final status in tasks.
+func (p *PostProcessor) importSnapshot(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, disks []importDisk, tasks map[string]string) (string, error) {
+	deviceNames := make([]string, len(disks))
+	for i, disk := range disks {
+		deviceNames[i] = disk.DeviceName
+	}
+	deviceNames, err := defaultDeviceNames(p.config.RootDeviceName, deviceNames)
+	if err != nil {
+		return "", err
+	}
 
-		for key, value := range p.config.Tags {
-			ui.Say(fmt.Sprintf("Adding tag \"%s\": \"%s\"", key, value))
-			ec2Tags = append(ec2Tags, ec2types.Tag{
-				Key:   aws.String(key),
-				Value: aws.String(value),
-			})
+	// Start all the imports first so that they run concurrently
+	taskIDs := make([]string, len(disks))
+	for i, disk := range disks {
+		taskID, err := p.startSnapshotImport(ctx, ui, ec2Client, disk)
+		if err != nil {
+			return "", err
 		}
+		taskIDs[i] = taskID
+	}
 
-		resourceIds := []string{createdami}
+	for i, taskID := range taskIDs {
+		detail, err := p.waitSnapshotImport(ctx, ui, ec2Client, taskID)
+		if detail != nil {
+			tasks[taskID] = aws.ToString(detail.Status)
+		}
+		if err != nil {
+			return "", err
+		}
+		disks[i].SnapshotId = aws.ToString(detail.SnapshotId)
+		disks[i].DeviceName = deviceNames[i]
+	}
 
-		log.Printf("Getting details of %s", createdami)
+	ui.Say(fmt.Sprintf("Registering AMI %s from %d snapshot(s)", p.config.Name, len(disks)))
+	registerResp, err := ec2Client.RegisterImage(ctx, p.buildRegisterImageInput(disks))
+	if err != nil {
+		return "", fmt.Errorf("Error registering AMI from snapshot %s: %s", disks[0].SnapshotId, err)
+	}
+	createdami := aws.ToString(registerResp.ImageId)
 
-		imageResp, err := ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
-			ImageIds: resourceIds,
-		})
+	ui.Say(fmt.Sprintf("Waiting for AMI %s to become ready...", createdami))
+	if err := p.config.PollingConfig.WaitUntilAMIAvailable(ctx, ec2Client, createdami); err != nil {
+		return "", fmt.Errorf("Error waiting for AMI (%s): %s", createdami, err)
+	}
 
-		if err != nil {
-			return nil, false, false, fmt.Errorf("Failed to retrieve details for AMI %s: %s", createdami, err)
-		}
+	return createdami, nil
+}
 
-		if len(imageResp.Images) == 0 {
-			return nil, false, false, fmt.Errorf("AMI %s has no images", createdami)
-		}
+// startSnapshotImport starts the ImportSnapshot task of disk and returns its
+// ID.
+func (p *PostProcessor) startSnapshotImport(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, disk importDisk) (string, error) {
+	log.Printf("Calling EC2 to import snapshot from s3://%s/%s", p.config.S3Bucket, disk.S3Key)
+
+	params := &ec2.ImportSnapshotInput{
+		DiskContainer: &ec2types.SnapshotDiskContainer{
+			Format: aws.String(strings.ToUpper(p.config.Format)),
+			UserBucket: &ec2types.UserBucket{
+				S3Bucket: &p.config.S3Bucket,
+				S3Key:    aws.String(disk.S3Key),
+			},
+		},
+		Encrypted: &p.config.Encrypt,
+	}
 
-		image := imageResp.Images[0]
+	if p.config.Encrypt && p.config.KMSKey != "" {
+		params.KmsKeyId = &p.config.KMSKey
+	}
 
-		log.Printf("Walking block device mappings for %s to find snapshots", createdami)
+	if p.config.RoleName != "" {
+		params.RoleName = &p.config.RoleName
+	}
 
-		for _, device := range image.BlockDeviceMappings {
-			if device.Ebs != nil && device.Ebs.SnapshotId != nil {
-				ui.Say(fmt.Sprintf("Tagging snapshot %s", *device.Ebs.SnapshotId))
-				resourceIds = append(resourceIds, *device.Ebs.SnapshotId)
-			}
-		}
+	var importStart *ec2.ImportSnapshotOutput
+	err := retry.Config{
+		Tries:      11,
+		RetryDelay: (&retry.Backoff{InitialBackoff: 200 * time.Millisecond, MaxBackoff: 30 * time.Second, Multiplier: 2}).Linear,
+	}.Run(ctx, func(ctx context.Context) error {
+		var err error
+		importStart, err = ec2Client.ImportSnapshot(ctx, params)
+		return err
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to start snapshot import from s3://%s/%s: %s", p.config.S3Bucket, disk.S3Key, err)
+	}
 
-		ui.Say(fmt.Sprintf("Tagging AMI %s", createdami))
+	taskID := aws.ToString(importStart.ImportTaskId)
+	ui.Say(fmt.Sprintf("Started snapshot import of s3://%s/%s, task id %s", p.config.S3Bucket, disk.S3Key, taskID))
+	return taskID, nil
+}
 
-		_, err = ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
-			Resources: resourceIds,
-			Tags:      ec2Tags,
-		})
+// waitImageImport waits for the ImportImage task to complete, reporting its
+// progress to ui, and returns the completed task. The task is also returned
+// along with the error when it failed.
+func (p *PostProcessor) waitImageImport(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, taskID string) (*ec2types.ImportImageTask, error) {
+	ui.Say(fmt.Sprintf("Waiting for task %s to complete (may take a while)", taskID))
+	waitErr := p.config.PollingConfig.WaitUntilImageImported(ctx, ec2Client, taskID, awscommon.ReportTaskProgress(ui))
 
-		if err != nil {
-			return nil, false, false, fmt.Errorf("Failed to add tags to resources %#v: %s", resourceIds, err)
+	// Retrieve what the outcome was for the import task, whether the wait
+	// failed or not, the task holds the most useful error message.
+	importResult, err := ec2Client.DescribeImportImageTasks(ctx, &ec2.DescribeImportImageTasksInput{
+		ImportTaskIds: []string{taskID},
+	})
+	if err != nil {
+		if waitErr != nil {
+			return nil, importFailure(taskID, "", waitErr)
 		}
+		return nil, fmt.Errorf("Failed to find import task %s: %s", taskID, err)
+	}
+	if len(importResult.ImportImageTasks) == 0 {
+		return nil, fmt.Errorf("Failed to find import task %s", taskID)
+	}
 
+	task := importResult.ImportImageTasks[0]
+	if waitErr != nil || aws.ToString(task.Status) != "completed" {
+		return &task, importFailure(taskID, aws.ToString(task.StatusMessage), waitErr)
 	}
 
-	// Apply attributes for AMI specified in config
-	// (duped from builder/amazon/common/step_modify_ami_attributes.go)
-	options := make(map[string]*ec2.ModifyImageAttributeInput)
-	if p.config.Description != "" {
-		options["description"] = &ec2.ModifyImageAttributeInput{
-			Description: &ec2types.AttributeValue{Value: &p.config.Description},
+	ui.Say(fmt.Sprintf("Import task %s complete", taskID))
+	return &task, nil
+}
+
+// waitSnapshotImport waits for the ImportSnapshot task to complete, reporting
+// its progress to ui, and returns the details of the completed task. The
+// details are also returned along with the error when the task failed.
+func (p *PostProcessor) waitSnapshotImport(ctx context.Context, ui packersdk.Ui, ec2Client clients.Ec2Client, taskID string) (*ec2types.SnapshotTaskDetail, error) {
+	ui.Say(fmt.Sprintf("Waiting for task %s to complete (may take a while)", taskID))
+	waitErr := p.config.PollingConfig.WaitUntilSnapshotImported(ctx, ec2Client, taskID, awscommon.ReportTaskProgress(ui))
+
+	// Retrieve what the outcome was for the import task, whether the wait
+	// failed or not, the task holds the most useful error message.
+	importResult, err := ec2Client.DescribeImportSnapshotTasks(ctx, &ec2.DescribeImportSnapshotTasksInput{
+		ImportTaskIds: []string{taskID},
+	})
+	if err != nil {
+		if waitErr != nil {
+			return nil, importFailure(taskID, "", waitErr)
 		}
+		return nil, fmt.Errorf("Failed to find snapshot import task %s: %s", taskID, err)
+	}
+	if len(importResult.ImportSnapshotTasks) == 0 || importResult.ImportSnapshotTasks[0].SnapshotTaskDetail == nil {
+		return nil, fmt.Errorf("Failed to find snapshot import task %s", taskID)
 	}
 
-	if len(p.config.Groups) > 0 {
-		groups := make([]string, len(p.config.Groups))
-		adds := make([]ec2types.LaunchPermission, len(p.config.Groups))
-		addGroups := &ec2.ModifyImageAttributeInput{
-			LaunchPermission: &ec2types.LaunchPermissionModifications{},
-		}
+	detail := importResult.ImportSnapshotTasks[0].SnapshotTaskDetail
+	if waitErr != nil || aws.ToString(detail.Status) != "completed" {
+		return detail, importFailure(taskID, aws.ToString(detail.StatusMessage), waitErr)
+	}
 
-		for i, g := range p.config.Groups {
-			groups[i] = g
-			adds[i] = ec2types.LaunchPermission{
-				Group: ec2types.PermissionGroup(g),
-			}
-		}
-		addGroups.UserGroups = groups
-		addGroups.LaunchPermission.Add = adds
+	ui.Say(fmt.Sprintf("Snapshot import task %s complete, snapshot: %s", taskID, aws.ToString(detail.SnapshotId)))
+	return detail, nil
+}
 
-		options["groups"] = addGroups
+// renderTemplates renders s3_key_name and ami_description, which were left
+// alone in the configure phase, with the generated data of the artifact.
+// The description is rendered only here: RegisterImage and the distribution
+// steps both use the rendered value.
+func (p *PostProcessor) renderTemplates() error {
+	var err error
+	p.config.S3Key, err = interpolate.Render(p.config.S3Key, &p.config.ctx)
+	if err != nil {
+		return fmt.Errorf("Error rendering s3_key_name template: %s", err)
 	}
+	log.Printf("Rendered s3_key_name as %s", p.config.S3Key)
 
-	if len(p.config.Users) > 0 {
-		users := make([]string, len(p.config.Users))
-		adds := make([]ec2types.LaunchPermission, len(p.config.Users))
-		for i, u := range p.config.Users {
-			users[i] = u
-			adds[i] = ec2types.LaunchPermission{UserId: aws.String(u)}
-		}
-		options["users"] = &ec2.ModifyImageAttributeInput{
-			UserIds: users,
-			LaunchPermission: &ec2types.LaunchPermissionModifications{
-				Add: adds,
-			},
-		}
+	p.config.Description, err = interpolate.Render(p.config.Description, &p.config.ctx)
+	if err != nil {
+		return fmt.Errorf("Error rendering ami_description template: %s", err)
 	}
+	return nil
+}
 
-	if len(p.config.OrgArns) > 0 {
-		orgArns := make([]string, len(p.config.OrgArns))
-		adds := make([]ec2types.LaunchPermission, len(p.config.OrgArns))
-		for i, u := range p.config.OrgArns {
-			orgArns[i] = u
-			adds[i] = ec2types.LaunchPermission{OrganizationArn: aws.String(u)}
-		}
-		options["ami org arns"] = &ec2.ModifyImageAttributeInput{
-			OrganizationArns: orgArns,
-			LaunchPermission: &ec2types.LaunchPermissionModifications{
-				Add: adds,
-			},
+// buildRegisterImageInput builds the RegisterImage request for an AMI whose
+// devices are backed by the imported snapshots, the first disk being the root
+// device.
+func (p *PostProcessor) buildRegisterImageInput(disks []importDisk) *ec2.RegisterImageInput {
+	var mappings []ec2types.BlockDeviceMapping
+	mapped := make(map[string]bool, len(disks))
+	for _, device := range p.config.AMIMappings.BuildEC2BlockDeviceMappings() {
+		for i, disk := range disks {
+			if aws.ToString(device.DeviceName) != disk.DeviceName {
+				continue
+			}
+			mapped[disk.DeviceName] = true
+			if device.Ebs == nil {
+				device.Ebs = &ec2types.EbsBlockDevice{}
+			}
+			device.Ebs.SnapshotId = aws.String(disk.SnapshotId)
+			// Encryption is inherited from the snapshot, AWS rejects
+			// RegisterImage requests that set it alongside a snapshot ID.
+			device.Ebs.Encrypted = nil
+			device.Ebs.KmsKeyId = nil
+			if i == 0 && p.config.RootVolumeSize > 0 {
+				device.Ebs.VolumeSize = aws.Int32(p.config.RootVolumeSize)
+			}
 		}
+		mappings = append(mappings, device)
 	}
 
-	if len(p.config.OuArns) > 0 {
-		ouArns := make([]string, len(p.config.OuArns))
-		adds := make([]ec2types.LaunchPermission, len(p.config.OuArns))
-		for i, u := range p.config.OuArns {
-			ouArns[i] = u
-			adds[i] = ec2types.LaunchPermission{OrganizationalUnitArn: aws.String(u)}
+	var diskMappings []ec2types.BlockDeviceMapping
+	for i, disk := range disks {
+		if mapped[disk.DeviceName] {
+			continue
 		}
-		options["ami ou arns"] = &ec2.ModifyImageAttributeInput{
-			OrganizationalUnitArns: ouArns,
-			LaunchPermission: &ec2types.LaunchPermissionModifications{
-				Add: adds,
+		device := ec2types.BlockDeviceMapping{
+			DeviceName: aws.String(disk.DeviceName),
+			Ebs: &ec2types.EbsBlockDevice{
+				DeleteOnTermination: aws.Bool(true),
+				SnapshotId:          aws.String(disk.SnapshotId),
 			},
 		}
-	}
-
-	if p.config.AMIIMDSSupport != "" {
-		options["ami imds support"] = &ec2.ModifyImageAttributeInput{
-			ImdsSupport: &ec2types.AttributeValue{Value: &p.config.AMIIMDSSupport},
+		if i == 0 && p.config.RootVolumeSize > 0 {
+			device.Ebs.VolumeSize = aws.Int32(p.config.RootVolumeSize)
 		}
-
+		diskMappings = append(diskMappings, device)
 	}
+	mappings = append(diskMappings, mappings...)
 
-	if len(options) > 0 {
-		for name, input := range options {
-			ui.Say(fmt.Sprintf("Modifying: %s", name))
-			input.ImageId = &createdami
-			_, err := ec2Client.ModifyImageAttribute(ctx, input)
-			if err != nil {
-				return nil, false, false, fmt.Errorf("Error modifying AMI attributes: %s", err)
-			}
-		}
+	registerOpts := &ec2.RegisterImageInput{
+		Name:                aws.String(p.config.Name),
+		Architecture:        ec2types.ArchitectureValues(p.config.Architecture),
+		BootMode:            ec2types.BootModeValues(p.config.BootMode),
+		RootDeviceName:      aws.String(p.config.RootDeviceName),
+		VirtualizationType:  aws.String("hvm"),
+		BlockDeviceMappings: mappings,
 	}
 
-	// Add the reported AMI ID to the artifact list
-	log.Printf("Adding created AMI ID %s in region %s to output artifacts", createdami, config.Region)
-	artifact = &awscommon.Artifact{
-		Amis: map[string]string{
-			config.Region: createdami,
-		},
-		BuilderIdValue: BuilderId,
-		Config:         config,
+	if p.config.Description != "" {
+		registerOpts.Description = aws.String(p.config.Description)
 	}
-
-	if !p.config.SkipClean {
-		ui.Say(fmt.Sprintf("Deleting import source s3://%s/%s", p.config.S3Bucket, p.config.S3Key))
-
-		_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-			Bucket: &p.config.S3Bucket,
-			Key:    &p.config.S3Key,
-		})
-		if err != nil {
-			return nil, false, false, fmt.Errorf("Failed to delete s3://%s/%s: %s", p.config.S3Bucket, p.config.S3Key, err)
-		}
+	if p.config.AMISriovNetSupport {
+		registerOpts.SriovNetSupport = aws.String("simple")
+	}
+	if p.config.AMIENASupport.True() {
+		registerOpts.EnaSupport = aws.Bool(true)
+	}
+	if p.config.UefiData != "" {
+		registerOpts.UefiData = aws.String(p.config.UefiData)
+	}
+	if p.config.TpmSupport != "" {
+		registerOpts.TpmSupport = ec2types.TpmSupportValues(p.config.TpmSupport)
+	}
+	if p.config.AMIIMDSSupport != "" {
+		registerOpts.ImdsSupport = ec2types.ImdsSupportValues(p.config.AMIIMDSSupport)
 	}
 
-	return artifact, false, false, nil
+	return registerOpts
 }
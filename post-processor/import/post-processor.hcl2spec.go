@@ -11,51 +11,71 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName       *string                           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType     *string                           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion     *string                           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug           *bool                             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce           *bool                             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError         *string                           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars        map[string]string                 `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars   []string                          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	AccessKey             *string                           `mapstructure:"access_key" required:"true" cty:"access_key" hcl:"access_key"`
-	AssumeRole            *common.FlatAssumeRoleConfig      `mapstructure:"assume_role" required:"false" cty:"assume_role" hcl:"assume_role"`
-	CustomEndpointEc2     *string                           `mapstructure:"custom_endpoint_ec2" required:"false" cty:"custom_endpoint_ec2" hcl:"custom_endpoint_ec2"`
-	CredsFilename         *string                           `mapstructure:"shared_credentials_file" required:"false" cty:"shared_credentials_file" hcl:"shared_credentials_file"`
-	DecodeAuthZMessages   *bool                             `mapstructure:"decode_authorization_messages" required:"false" cty:"decode_authorization_messages" hcl:"decode_authorization_messages"`
-	InsecureSkipTLSVerify *bool                             `mapstructure:"insecure_skip_tls_verify" required:"false" cty:"insecure_skip_tls_verify" hcl:"insecure_skip_tls_verify"`
-	MaxRetries            *int                              `mapstructure:"max_retries" required:"false" cty:"max_retries" hcl:"max_retries"`
-	MFACode               *string                           `mapstructure:"mfa_code" required:"false" cty:"mfa_code" hcl:"mfa_code"`
-	ProfileName           *string                           `mapstructure:"profile" required:"false" cty:"profile" hcl:"profile"`
-	RawRegion             *string                           `mapstructure:"region" required:"true" cty:"region" hcl:"region"`
-	SecretKey             *string                           `mapstructure:"secret_key" required:"true" cty:"secret_key" hcl:"secret_key"`
-	SkipMetadataApiCheck  *bool                             `mapstructure:"skip_metadata_api_check" cty:"skip_metadata_api_check" hcl:"skip_metadata_api_check"`
-	SkipCredsValidation   *bool                             `mapstructure:"skip_credential_validation" cty:"skip_credential_validation" hcl:"skip_credential_validation"`
-	Token                 *string                           `mapstructure:"token" required:"false" cty:"token" hcl:"token"`
-	VaultAWSEngine        *common.FlatVaultAWSEngineOptions `mapstructure:"vault_aws_engine" required:"false" cty:"vault_aws_engine" hcl:"vault_aws_engine"`
-	PollingConfig         *common.FlatAWSPollingConfig      `mapstructure:"aws_polling" required:"false" cty:"aws_polling" hcl:"aws_polling"`
-	S3Bucket              *string                           `mapstructure:"s3_bucket_name" cty:"s3_bucket_name" hcl:"s3_bucket_name"`
-	S3Key                 *string                           `mapstructure:"s3_key_name" cty:"s3_key_name" hcl:"s3_key_name"`
-	S3Encryption          *string                           `mapstructure:"s3_encryption" cty:"s3_encryption" hcl:"s3_encryption"`
-	S3EncryptionKey       *string                           `mapstructure:"s3_encryption_key" cty:"s3_encryption_key" hcl:"s3_encryption_key"`
-	SkipClean             *bool                             `mapstructure:"skip_clean" cty:"skip_clean" hcl:"skip_clean"`
-	Tags                  map[string]string                 `mapstructure:"tags" cty:"tags" hcl:"tags"`
-	Name                  *string                           `mapstructure:"ami_name" cty:"ami_name" hcl:"ami_name"`
-	Description           *string                           `mapstructure:"ami_description" cty:"ami_description" hcl:"ami_description"`
-	Users                 []string                          `mapstructure:"ami_users" cty:"ami_users" hcl:"ami_users"`
-	Groups                []string                          `mapstructure:"ami_groups" cty:"ami_groups" hcl:"ami_groups"`
-	OrgArns               []string                          `mapstructure:"ami_org_arns" cty:"ami_org_arns" hcl:"ami_org_arns"`
-	OuArns                []string                          `mapstructure:"ami_ou_arns" cty:"ami_ou_arns" hcl:"ami_ou_arns"`
-	Encrypt               *bool                             `mapstructure:"ami_encrypt" cty:"ami_encrypt" hcl:"ami_encrypt"`
-	KMSKey                *string                           `mapstructure:"ami_kms_key" cty:"ami_kms_key" hcl:"ami_kms_key"`
-	AMIIMDSSupport        *string                           `mapstructure:"imds_support" required:"false" cty:"imds_support" hcl:"imds_support"`
-	LicenseType           *string                           `mapstructure:"license_type" cty:"license_type" hcl:"license_type"`
-	RoleName              *string                           `mapstructure:"role_name" cty:"role_name" hcl:"role_name"`
-	Format                *string                           `mapstructure:"format" cty:"format" hcl:"format"`
-	Architecture          *string                           `mapstructure:"architecture" cty:"architecture" hcl:"architecture"`
-	BootMode              *string                           `mapstructure:"boot_mode" cty:"boot_mode" hcl:"boot_mode"`
-	Platform              *string                           `mapstructure:"platform" cty:"platform" hcl:"platform"`
+	PackerBuildName          *string                                     `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType        *string                                     `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion        *string                                     `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug              *bool                                       `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce              *bool                                       `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError            *string                                     `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars           map[string]string                           `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars      []string                                    `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	AccessKey                *string                                     `mapstructure:"access_key" required:"true" cty:"access_key" hcl:"access_key"`
+	AssumeRole               *common.FlatAssumeRoleConfig                `mapstructure:"assume_role" required:"false" cty:"assume_role" hcl:"assume_role"`
+	CustomEndpointEc2        *string                                     `mapstructure:"custom_endpoint_ec2" required:"false" cty:"custom_endpoint_ec2" hcl:"custom_endpoint_ec2"`
+	CredsFilename            *string                                     `mapstructure:"shared_credentials_file" required:"false" cty:"shared_credentials_file" hcl:"shared_credentials_file"`
+	DecodeAuthZMessages      *bool                                       `mapstructure:"decode_authorization_messages" required:"false" cty:"decode_authorization_messages" hcl:"decode_authorization_messages"`
+	InsecureSkipTLSVerify    *bool                                       `mapstructure:"insecure_skip_tls_verify" required:"false" cty:"insecure_skip_tls_verify" hcl:"insecure_skip_tls_verify"`
+	MaxRetries               *int                                        `mapstructure:"max_retries" required:"false" cty:"max_retries" hcl:"max_retries"`
+	MFACode                  *string                                     `mapstructure:"mfa_code" required:"false" cty:"mfa_code" hcl:"mfa_code"`
+	ProfileName              *string                                     `mapstructure:"profile" required:"false" cty:"profile" hcl:"profile"`
+	RawRegion                *string                                     `mapstructure:"region" required:"true" cty:"region" hcl:"region"`
+	SecretKey                *string                                     `mapstructure:"secret_key" required:"true" cty:"secret_key" hcl:"secret_key"`
+	SkipMetadataApiCheck     *bool                                       `mapstructure:"skip_metadata_api_check" cty:"skip_metadata_api_check" hcl:"skip_metadata_api_check"`
+	SkipCredsValidation      *bool                                       `mapstructure:"skip_credential_validation" cty:"skip_credential_validation" hcl:"skip_credential_validation"`
+	Token                    *string                                     `mapstructure:"token" required:"false" cty:"token" hcl:"token"`
+	VaultAWSEngine           *common.FlatVaultAWSEngineOptions           `mapstructure:"vault_aws_engine" required:"false" cty:"vault_aws_engine" hcl:"vault_aws_engine"`
+	PollingConfig            *common.FlatAWSPollingConfig                `mapstructure:"aws_polling" required:"false" cty:"aws_polling" hcl:"aws_polling"`
+	S3Bucket                 *string                                     `mapstructure:"s3_bucket_name" cty:"s3_bucket_name" hcl:"s3_bucket_name"`
+	S3Key                    *string                                     `mapstructure:"s3_key_name" cty:"s3_key_name" hcl:"s3_key_name"`
+	S3Encryption             *string                                     `mapstructure:"s3_encryption" cty:"s3_encryption" hcl:"s3_encryption"`
+	S3EncryptionKey          *string                                     `mapstructure:"s3_encryption_key" cty:"s3_encryption_key" hcl:"s3_encryption_key"`
+	SkipClean                *bool                                       `mapstructure:"skip_clean" cty:"skip_clean" hcl:"skip_clean"`
+	Tags                     map[string]string                           `mapstructure:"tags" cty:"tags" hcl:"tags"`
+	Name                     *string                                     `mapstructure:"ami_name" cty:"ami_name" hcl:"ami_name"`
+	Description              *string                                     `mapstructure:"ami_description" cty:"ami_description" hcl:"ami_description"`
+	Users                    []string                                    `mapstructure:"ami_users" cty:"ami_users" hcl:"ami_users"`
+	Groups                   []string                                    `mapstructure:"ami_groups" cty:"ami_groups" hcl:"ami_groups"`
+	OrgArns                  []string                                    `mapstructure:"ami_org_arns" cty:"ami_org_arns" hcl:"ami_org_arns"`
+	OuArns                   []string                                    `mapstructure:"ami_ou_arns" cty:"ami_ou_arns" hcl:"ami_ou_arns"`
+	Encrypt                  *bool                                       `mapstructure:"ami_encrypt" cty:"ami_encrypt" hcl:"ami_encrypt"`
+	KMSKey                   *string                                     `mapstructure:"ami_kms_key" cty:"ami_kms_key" hcl:"ami_kms_key"`
+	AMIIMDSSupport           *string                                     `mapstructure:"imds_support" required:"false" cty:"imds_support" hcl:"imds_support"`
+	LicenseType              *string                                     `mapstructure:"license_type" cty:"license_type" hcl:"license_type"`
+	RoleName                 *string                                     `mapstructure:"role_name" cty:"role_name" hcl:"role_name"`
+	Format                   *string                                     `mapstructure:"format" cty:"format" hcl:"format"`
+	Architecture             *string                                     `mapstructure:"architecture" cty:"architecture" hcl:"architecture"`
+	BootMode                 *string                                     `mapstructure:"boot_mode" cty:"boot_mode" hcl:"boot_mode"`
+	Platform                 *string                                     `mapstructure:"platform" cty:"platform" hcl:"platform"`
+	ImportMode               *string                                     `mapstructure:"import_mode" required:"false" cty:"import_mode" hcl:"import_mode"`
+	RootDeviceName           *string                                     `mapstructure:"root_device_name" required:"false" cty:"root_device_name" hcl:"root_device_name"`
+	RootVolumeSize           *int32                                      `mapstructure:"root_volume_size" required:"false" cty:"root_volume_size" hcl:"root_volume_size"`
+	AMIMappings              []common.FlatBlockDevice                    `mapstructure:"ami_block_device_mappings" required:"false" cty:"ami_block_device_mappings" hcl:"ami_block_device_mappings"`
+	AMIENASupport            *bool                                       `mapstructure:"ena_support" required:"false" cty:"ena_support" hcl:"ena_support"`
+	AMISriovNetSupport       *bool                                       `mapstructure:"sriov_support" required:"false" cty:"sriov_support" hcl:"sriov_support"`
+	UefiData                 *string                                     `mapstructure:"uefi_data" required:"false" cty:"uefi_data" hcl:"uefi_data"`
+	TpmSupport               *string                                     `mapstructure:"tpm_support" required:"false" cty:"tpm_support" hcl:"tpm_support"`
+	Disks                    []FlatDiskConfig                            `mapstructure:"disks" required:"false" cty:"disks" hcl:"disks"`
+	S3UploadPartSize         *int64                                      `mapstructure:"s3_upload_part_size" required:"false" cty:"s3_upload_part_size" hcl:"s3_upload_part_size"`
+	S3UploadConcurrency      *int                                        `mapstructure:"s3_upload_concurrency" required:"false" cty:"s3_upload_concurrency" hcl:"s3_upload_concurrency"`
+	CustomEndpointS3         *string                                     `mapstructure:"custom_endpoint_s3" required:"false" cty:"custom_endpoint_s3" hcl:"custom_endpoint_s3"`
+	CreateServiceRole        *bool                                       `mapstructure:"create_service_role" required:"false" cty:"create_service_role" hcl:"create_service_role"`
+	ValidateServiceRole      *bool                                       `mapstructure:"validate_service_role" required:"false" cty:"validate_service_role" hcl:"validate_service_role"`
+	AMIRegions               []string                                    `mapstructure:"ami_regions" required:"false" cty:"ami_regions" hcl:"ami_regions"`
+	AMIRegionKMSKeyIDs       map[string]string                           `mapstructure:"region_kms_key_ids" required:"false" cty:"region_kms_key_ids" hcl:"region_kms_key_ids"`
+	DeprecationTime          *string                                     `mapstructure:"deprecate_at" required:"false" cty:"deprecate_at" hcl:"deprecate_at"`
+	DeregistrationProtection *common.FlatDeregistrationProtectionOptions `mapstructure:"deregistration_protection" required:"false" cty:"deregistration_protection" hcl:"deregistration_protection"`
+	SnapshotUsers            []string                                    `mapstructure:"snapshot_users" required:"false" cty:"snapshot_users" hcl:"snapshot_users"`
+	SnapshotTags             map[string]string                           `mapstructure:"snapshot_tags" required:"false" cty:"snapshot_tags" hcl:"snapshot_tags"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -115,6 +135,26 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"architecture":                  &hcldec.AttrSpec{Name: "architecture", Type: cty.String, Required: false},
 		"boot_mode":                     &hcldec.AttrSpec{Name: "boot_mode", Type: cty.String, Required: false},
 		"platform":                      &hcldec.AttrSpec{Name: "platform", Type: cty.String, Required: false},
+		"import_mode":                   &hcldec.AttrSpec{Name: "import_mode", Type: cty.String, Required: false},
+		"root_device_name":              &hcldec.AttrSpec{Name: "root_device_name", Type: cty.String, Required: false},
+		"root_volume_size":              &hcldec.AttrSpec{Name: "root_volume_size", Type: cty.Number, Required: false},
+		"ami_block_device_mappings":     &hcldec.BlockListSpec{TypeName: "ami_block_device_mappings", Nested: hcldec.ObjectSpec((*common.FlatBlockDevice)(nil).HCL2Spec())},
+		"ena_support":                   &hcldec.AttrSpec{Name: "ena_support", Type: cty.Bool, Required: false},
+		"sriov_support":                 &hcldec.AttrSpec{Name: "sriov_support", Type: cty.Bool, Required: false},
+		"uefi_data":                     &hcldec.AttrSpec{Name: "uefi_data", Type: cty.String, Required: false},
+		"tpm_support":                   &hcldec.AttrSpec{Name: "tpm_support", Type: cty.String, Required: false},
+		"disks":                         &hcldec.BlockListSpec{TypeName: "disks", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"s3_upload_part_size":           &hcldec.AttrSpec{Name: "s3_upload_part_size", Type: cty.Number, Required: false},
+		"s3_upload_concurrency":         &hcldec.AttrSpec{Name: "s3_upload_concurrency", Type: cty.Number, Required: false},
+		"custom_endpoint_s3":            &hcldec.AttrSpec{Name: "custom_endpoint_s3", Type: cty.String, Required: false},
+		"create_service_role":           &hcldec.AttrSpec{Name: "create_service_role", Type: cty.Bool, Required: false},
+		"validate_service_role":         &hcldec.AttrSpec{Name: "validate_service_role", Type: cty.Bool, Required: false},
+		"ami_regions":                   &hcldec.AttrSpec{Name: "ami_regions", Type: cty.List(cty.String), Required: false},
+		"region_kms_key_ids":            &hcldec.AttrSpec{Name: "region_kms_key_ids", Type: cty.Map(cty.String), Required: false},
+		"deprecate_at":                  &hcldec.AttrSpec{Name: "deprecate_at", Type: cty.String, Required: false},
+		"deregistration_protection":     &hcldec.BlockSpec{TypeName: "deregistration_protection", Nested: hcldec.ObjectSpec((*common.FlatDeregistrationProtectionOptions)(nil).HCL2Spec())},
+		"snapshot_users":                &hcldec.AttrSpec{Name: "snapshot_users", Type: cty.List(cty.String), Required: false},
+		"snapshot_tags":                 &hcldec.AttrSpec{Name: "snapshot_tags", Type: cty.Map(cty.String), Required: false},
 	}
 	return s
 }
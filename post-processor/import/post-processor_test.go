@@ -0,0 +1,383 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":     "foo",
+		"secret_key":     "bar",
+		"region":         "us-east-1",
+		"s3_bucket_name": "importbucket",
+	}
+}
+
+func testSnapshotConfig() map[string]interface{} {
+	c := testConfig()
+	c["import_mode"] = "snapshot"
+	c["format"] = "raw"
+	c["ami_name"] = "imported"
+	return c
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Defaults(t *testing.T) {
+	var p PostProcessor
+	if err := p.Configure(testConfig()); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if p.config.ImportMode != "image" {
+		t.Errorf("expected import_mode to default to image, got %q", p.config.ImportMode)
+	}
+	if p.config.Format != "ova" {
+		t.Errorf("expected format to default to ova, got %q", p.config.Format)
+	}
+	if p.config.RootDeviceName != "" {
+		t.Errorf("expected root_device_name to be unset for image imports, got %q", p.config.RootDeviceName)
+	}
+	if p.config.S3UploadPartSize != 64 {
+		t.Errorf("expected s3_upload_part_size to default to 64, got %d", p.config.S3UploadPartSize)
+	}
+	if p.config.S3UploadConcurrency != 5 {
+		t.Errorf("expected s3_upload_concurrency to default to 5, got %d", p.config.S3UploadConcurrency)
+	}
+}
+
+func TestPostProcessorConfigure_ImportMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "valid snapshot import",
+			config: testSnapshotConfig(),
+		},
+		{
+			name: "snapshot import with uefi and no platform",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["boot_mode"] = "uefi"
+				c["uefi_data"] = "QU1aTlVFRkk="
+				c["tpm_support"] = "v2.0"
+				return c
+			}(),
+		},
+		{
+			name: "snapshot import with uefi-preferred",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["boot_mode"] = "uefi-preferred"
+				return c
+			}(),
+		},
+		{
+			name: "unknown import mode",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["import_mode"] = "disk"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import without ami_name",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				delete(c, "ami_name")
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import of an ova",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["format"] = "ova"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import with license type",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["license_type"] = "BYOL"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import with invalid tpm support",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["tpm_support"] = "v1.0"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import with uefi data and legacy-bios",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["boot_mode"] = "legacy-bios"
+				c["uefi_data"] = "QU1aTlVFRkk="
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import with block device missing device name",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["ami_block_device_mappings"] = []map[string]interface{}{
+					{"volume_size": 10},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "image import with uefi data",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["boot_mode"] = "uefi"
+				c["platform"] = "linux"
+				c["uefi_data"] = "QU1aTlVFRkk="
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import of several disks",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["disks"] = []map[string]interface{}{
+					{"file": "disk1.raw", "device_name": "/dev/sda1"},
+					{"file": "disk2.raw", "device_name": "/dev/sdf"},
+				}
+				return c
+			}(),
+		},
+		{
+			name: "snapshot import with root disk on another device",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				c["disks"] = []map[string]interface{}{
+					{"file": "disk1.raw", "device_name": "/dev/sdf"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "snapshot import of more disks than device names",
+			config: func() map[string]interface{} {
+				c := testSnapshotConfig()
+				var disks []map[string]interface{}
+				for i := 0; i < 27; i++ {
+					disks = append(disks, map[string]interface{}{"file": fmt.Sprintf("disk%d.raw", i)})
+				}
+				c["disks"] = disks
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "disk without file",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["format"] = "vmdk"
+				c["disks"] = []map[string]interface{}{
+					{"device_name": "/dev/sdf"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "image import of several disks with device names",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["format"] = "vmdk"
+				c["disks"] = []map[string]interface{}{
+					{"file": "disk1.vmdk"},
+					{"file": "disk2.vmdk", "device_name": "/dev/sdf"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "image import of several ova disks",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["disks"] = []map[string]interface{}{
+					{"file": "disk1.ova"},
+					{"file": "disk2.ova"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "upload part size below the S3 minimum",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["s3_upload_part_size"] = 4
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "negative upload concurrency",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["s3_upload_concurrency"] = -1
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "custom upload settings",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["s3_upload_part_size"] = 128
+				c["s3_upload_concurrency"] = 10
+				c["custom_endpoint_s3"] = "http://127.0.0.1:9000"
+				return c
+			}(),
+		},
+		{
+			name: "image import with uefi-preferred",
+			config: func() map[string]interface{} {
+				c := testConfig()
+				c["boot_mode"] = "uefi-preferred"
+				return c
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p PostProcessor
+			err := p.Configure(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Configure() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPostProcessor_buildRegisterImageInput(t *testing.T) {
+	var p PostProcessor
+	c := testSnapshotConfig()
+	c["boot_mode"] = "uefi"
+	c["ena_support"] = true
+	c["root_volume_size"] = 20
+	c["ami_block_device_mappings"] = []map[string]interface{}{
+		{
+			"device_name": "/dev/sdb",
+			"volume_size": 50,
+			"volume_type": "gp3",
+		},
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	input := p.buildRegisterImageInput([]importDisk{
+		{DeviceName: "/dev/sda1", SnapshotId: "snap-1234"},
+		{DeviceName: "/dev/sdc", SnapshotId: "snap-5678"},
+	})
+
+	if aws.ToString(input.Name) != "imported" {
+		t.Errorf("expected name %q, got %q", "imported", aws.ToString(input.Name))
+	}
+	if aws.ToString(input.RootDeviceName) != "/dev/sda1" {
+		t.Errorf("expected root device /dev/sda1, got %q", aws.ToString(input.RootDeviceName))
+	}
+	if input.BootMode != ec2types.BootModeValuesUefi {
+		t.Errorf("expected uefi boot mode, got %q", input.BootMode)
+	}
+	if !aws.ToBool(input.EnaSupport) {
+		t.Errorf("expected ENA support to be enabled")
+	}
+	if len(input.BlockDeviceMappings) != 3 {
+		t.Fatalf("expected 3 block device mappings, got %d", len(input.BlockDeviceMappings))
+	}
+
+	root := input.BlockDeviceMappings[0]
+	if aws.ToString(root.DeviceName) != "/dev/sda1" {
+		t.Errorf("expected the root device first, got %q", aws.ToString(root.DeviceName))
+	}
+	if aws.ToString(root.Ebs.SnapshotId) != "snap-1234" {
+		t.Errorf("expected root device to use snap-1234, got %q", aws.ToString(root.Ebs.SnapshotId))
+	}
+	if aws.ToInt32(root.Ebs.VolumeSize) != 20 {
+		t.Errorf("expected root volume size 20, got %d", aws.ToInt32(root.Ebs.VolumeSize))
+	}
+
+	disk := input.BlockDeviceMappings[1]
+	if aws.ToString(disk.DeviceName) != "/dev/sdc" || aws.ToString(disk.Ebs.SnapshotId) != "snap-5678" {
+		t.Errorf("expected second disk on /dev/sdc with snap-5678, got %q with %q",
+			aws.ToString(disk.DeviceName), aws.ToString(disk.Ebs.SnapshotId))
+	}
+	if disk.Ebs.VolumeSize != nil {
+		t.Errorf("expected root_volume_size to only apply to the root device")
+	}
+
+	data := input.BlockDeviceMappings[2]
+	if data.Ebs.SnapshotId != nil {
+		t.Errorf("expected additional device to have no snapshot, got %q", aws.ToString(data.Ebs.SnapshotId))
+	}
+}
+
+func TestPostProcessor_buildRegisterImageInput_RootMapping(t *testing.T) {
+	var p PostProcessor
+	c := testSnapshotConfig()
+	c["root_device_name"] = "/dev/xvda"
+	c["ami_block_device_mappings"] = []map[string]interface{}{
+		{
+			"device_name":           "/dev/xvda",
+			"volume_type":           "gp3",
+			"delete_on_termination": true,
+			"encrypted":             true,
+		},
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	input := p.buildRegisterImageInput([]importDisk{
+		{DeviceName: "/dev/xvda", SnapshotId: "snap-1234"},
+	})
+	if len(input.BlockDeviceMappings) != 1 {
+		t.Fatalf("expected the root mapping to be reused, got %d mappings", len(input.BlockDeviceMappings))
+	}
+
+	root := input.BlockDeviceMappings[0]
+	if aws.ToString(root.Ebs.SnapshotId) != "snap-1234" {
+		t.Errorf("expected root device to use snap-1234, got %q", aws.ToString(root.Ebs.SnapshotId))
+	}
+	if root.Ebs.VolumeType != ec2types.VolumeTypeGp3 {
+		t.Errorf("expected root volume type gp3, got %q", root.Ebs.VolumeType)
+	}
+	if root.Ebs.Encrypted != nil {
+		t.Errorf("expected encrypted to be unset when registering from a snapshot")
+	}
+}
@@ -0,0 +1,502 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const (
+	// defaultServiceRoleName is the role VM Import uses when role_name is
+	// not set.
+	defaultServiceRoleName = "vmimport"
+	// serviceRolePolicyName is the name of the inline policy granting the
+	// service role what the import needs.
+	serviceRolePolicyName = "packer-vmimport"
+	// vmImportPrincipal is the service principal VM Import assumes the
+	// service role as.
+	vmImportPrincipal = "vmie.amazonaws.com"
+)
+
+// iamServiceRoleAPI is the subset of the IAM API used to check and create the
+// VM Import service role, satisfied by *iam.Client.
+type iamServiceRoleAPI interface {
+	GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error)
+	CreateRole(ctx context.Context, params *iam.CreateRoleInput, optFns ...func(*iam.Options)) (*iam.CreateRoleOutput, error)
+	UpdateAssumeRolePolicy(ctx context.Context, params *iam.UpdateAssumeRolePolicyInput, optFns ...func(*iam.Options)) (*iam.UpdateAssumeRolePolicyOutput, error)
+	GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error)
+	PutRolePolicy(ctx context.Context, params *iam.PutRolePolicyInput, optFns ...func(*iam.Options)) (*iam.PutRolePolicyOutput, error)
+	SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error)
+}
+
+// serviceRoleStatement is a statement of the policy the service role needs.
+type serviceRoleStatement struct {
+	Sid      string
+	Effect   string
+	Action   []string
+	Resource []string
+}
+
+// serviceRolePolicy is the policy document granting the service role what
+// the import needs.
+type serviceRolePolicy struct {
+	Version   string
+	Statement []serviceRoleStatement
+}
+
+// simulatedActions maps the wildcard actions of the service role policy to
+// the actions checked in their place, as the policy simulator only evaluates
+// actual API operations.
+var simulatedActions = map[string][]string{
+	"ec2:Describe*":        {"ec2:DescribeImages", "ec2:DescribeSnapshots"},
+	"kms:GenerateDataKey*": {"kms:GenerateDataKey", "kms:GenerateDataKeyWithoutPlaintext"},
+	"kms:ReEncrypt*":       {"kms:ReEncryptFrom", "kms:ReEncryptTo"},
+}
+
+// serviceRoleTrustPolicy returns the trust policy letting VM Import assume the
+// service role.
+func serviceRoleTrustPolicy() map[string]interface{} {
+	return map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": []interface{}{serviceRoleTrustStatement()},
+	}
+}
+
+func serviceRoleTrustStatement() map[string]interface{} {
+	return map[string]interface{}{
+		"Effect":    "Allow",
+		"Principal": map[string]interface{}{"Service": vmImportPrincipal},
+		"Action":    "sts:AssumeRole",
+		"Condition": map[string]interface{}{
+			"StringEquals": map[string]interface{}{"sts:Externalid": "vmimport"},
+		},
+	}
+}
+
+// serviceRoleName returns the name of the service role used for the import.
+func (p *PostProcessor) serviceRoleName() string {
+	if p.config.RoleName != "" {
+		return p.config.RoleName
+	}
+	return defaultServiceRoleName
+}
+
+// serviceRoleStatements returns the statements the service role needs to
+// import from the S3 bucket, in the partition of the role ARN.
+func (p *PostProcessor) serviceRoleStatements(roleARN arn.ARN) []serviceRoleStatement {
+	bucket := fmt.Sprintf("arn:%s:s3:::%s", roleARN.Partition, p.config.S3Bucket)
+	statements := []serviceRoleStatement{
+		{
+			Sid:      "PackerImportBucket",
+			Effect:   "Allow",
+			Action:   []string{"s3:GetBucketLocation", "s3:ListBucket"},
+			Resource: []string{bucket},
+		},
+		{
+			Sid:      "PackerImportObjects",
+			Effect:   "Allow",
+			Action:   []string{"s3:GetObject"},
+			Resource: []string{bucket + "/*"},
+		},
+		{
+			Sid:    "PackerImportEC2",
+			Effect: "Allow",
+			Action: []string{
+				"ec2:CopySnapshot",
+				"ec2:Describe*",
+				"ec2:ModifySnapshotAttribute",
+				"ec2:RegisterImage",
+			},
+			Resource: []string{"*"},
+		},
+	}
+
+	var keys []string
+	if p.config.Encrypt && p.config.KMSKey != "" {
+		keys = append(keys, p.config.KMSKey)
+	}
+	if p.config.S3Encryption == "aws:kms" && p.config.S3EncryptionKey != "" {
+		keys = append(keys, p.config.S3EncryptionKey)
+	}
+	if len(keys) > 0 {
+		var resources []string
+		for _, key := range keys {
+			resources = append(resources, kmsKeyResource(key, p.config.RawRegion, roleARN))
+		}
+		statements = append(statements, serviceRoleStatement{
+			Sid:    "PackerImportKMS",
+			Effect: "Allow",
+			Action: []string{
+				"kms:CreateGrant",
+				"kms:Decrypt",
+				"kms:DescribeKey",
+				"kms:Encrypt",
+				"kms:GenerateDataKey*",
+				"kms:ReEncrypt*",
+			},
+			Resource: resources,
+		})
+	}
+
+	return statements
+}
+
+// kmsKeyResource returns the resource to grant the service role access to
+// the KMS key with. Key IDs are turned into key ARNs in the import region
+// and the account of the role, the ARN IAM simulates the policy against.
+// Aliases are rejected by Configure.
+func kmsKeyResource(key, region string, roleARN arn.ARN) string {
+	if strings.HasPrefix(key, "arn:") {
+		return key
+	}
+	return fmt.Sprintf("arn:%s:kms:%s:%s:key/%s", roleARN.Partition, region, roleARN.AccountID, key)
+}
+
+// isKMSAlias tells whether key is an alias name or alias ARN.
+func isKMSAlias(key string) bool {
+	return strings.HasPrefix(key, "alias/") || strings.Contains(key, ":alias/")
+}
+
+// ensureServiceRole checks that the VM Import service role exists, trusts VM
+// Import and has the permissions the import needs. When create is set, a
+// missing role is created and missing permissions are added to it, otherwise
+// the missing parts are reported as an error.
+func (p *PostProcessor) ensureServiceRole(ctx context.Context, ui packersdk.Ui, client iamServiceRoleAPI, create bool) error {
+	roleName := p.serviceRoleName()
+	ui.Say(fmt.Sprintf("Checking VM Import service role %s", roleName))
+
+	role, err := client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+	if err != nil {
+		var notFound *iamtypes.NoSuchEntityException
+		if !errors.As(err, &notFound) {
+			return fmt.Errorf("Failed to get service role %s: %s", roleName, err)
+		}
+		if !create {
+			return fmt.Errorf("Service role %s does not exist, set create_service_role to create it", roleName)
+		}
+		return p.createServiceRole(ctx, ui, client, roleName)
+	}
+
+	roleARN, err := arn.Parse(aws.ToString(role.Role.Arn))
+	if err != nil {
+		return fmt.Errorf("Failed to parse the ARN of service role %s: %s", roleName, err)
+	}
+
+	trusted, err := trustsVMImport(aws.ToString(role.Role.AssumeRolePolicyDocument))
+	if err != nil {
+		return fmt.Errorf("Failed to read the trust policy of service role %s: %s", roleName, err)
+	}
+	if !trusted && create {
+		ui.Message(fmt.Sprintf("Adding %s to the trust policy of %s", vmImportPrincipal, roleName))
+		if err := p.trustVMImport(ctx, client, roleName, aws.ToString(role.Role.AssumeRolePolicyDocument)); err != nil {
+			return err
+		}
+	}
+
+	missing, err := p.missingServiceRoleStatements(ctx, client, roleARN)
+	if err != nil {
+		return err
+	}
+
+	if !create {
+		var problems []string
+		if !trusted {
+			problems = append(problems, fmt.Sprintf("its trust policy must allow %s to sts:AssumeRole", vmImportPrincipal))
+		}
+		if len(missing) > 0 {
+			policy, _ := json.MarshalIndent(serviceRolePolicy{Version: "2012-10-17", Statement: missing}, "", "  ")
+			problems = append(problems, fmt.Sprintf("it is missing the following permissions:\n%s", policy))
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("Service role %s can not be used for the import, set create_service_role to fix it: %s",
+				roleName, strings.Join(problems, ", and "))
+		}
+	}
+
+	if len(missing) == 0 {
+		ui.Message(fmt.Sprintf("Service role %s has the permissions needed for the import", roleName))
+		return nil
+	}
+
+	ui.Message(fmt.Sprintf("Granting %s the permissions needed for the import", roleName))
+	return p.putServiceRolePolicy(ctx, client, roleName, roleARN)
+}
+
+func (p *PostProcessor) createServiceRole(ctx context.Context, ui packersdk.Ui, client iamServiceRoleAPI, roleName string) error {
+	ui.Message(fmt.Sprintf("Creating service role %s", roleName))
+
+	trust, err := json.Marshal(serviceRoleTrustPolicy())
+	if err != nil {
+		return err
+	}
+	role, err := client.CreateRole(ctx, &iam.CreateRoleInput{
+		RoleName:                 aws.String(roleName),
+		Description:              aws.String("Service role for VM Import, created by Packer"),
+		AssumeRolePolicyDocument: aws.String(string(trust)),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to create service role %s: %s", roleName, err)
+	}
+
+	err = iam.NewRoleExistsWaiter(client).Wait(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	}, awscommon.AwsDefaultRoleExistsWaitTimeDuration)
+	if err != nil {
+		return fmt.Errorf("Timed out waiting for service role %s: %s", roleName, err)
+	}
+
+	roleARN, err := arn.Parse(aws.ToString(role.Role.Arn))
+	if err != nil {
+		return fmt.Errorf("Failed to parse the ARN of service role %s: %s", roleName, err)
+	}
+	return p.putServiceRolePolicy(ctx, client, roleName, roleARN)
+}
+
+// putServiceRolePolicy creates or updates the inline policy of the service
+// role granting what the import needs. The role is usually shared by builds
+// importing from other buckets or with other keys, so the resources already
+// in the policy are kept and the ones of this build are added to them.
+func (p *PostProcessor) putServiceRolePolicy(ctx context.Context, client iamServiceRoleAPI, roleName string, roleARN arn.ARN) error {
+	policy := map[string]interface{}{"Version": "2012-10-17"}
+	existing, err := client.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+		RoleName:   aws.String(roleName),
+		PolicyName: aws.String(serviceRolePolicyName),
+	})
+	if err == nil {
+		policy, err = decodePolicyDocument(aws.ToString(existing.PolicyDocument))
+		if err != nil {
+			return fmt.Errorf("Failed to read policy %s of service role %s: %s", serviceRolePolicyName, roleName, err)
+		}
+	} else {
+		var notFound *iamtypes.NoSuchEntityException
+		if !errors.As(err, &notFound) {
+			return fmt.Errorf("Failed to get policy %s of service role %s: %s", serviceRolePolicyName, roleName, err)
+		}
+	}
+	policy["Statement"] = mergeStatements(policyStatements(policy), p.serviceRoleStatements(roleARN))
+
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyName:     aws.String(serviceRolePolicyName),
+		PolicyDocument: aws.String(string(document)),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to put policy %s on service role %s: %s", serviceRolePolicyName, roleName, err)
+	}
+	return nil
+}
+
+// mergeStatements adds the actions and resources of the needed statements to
+// the existing statements with the same Sid, and appends the others.
+func mergeStatements(existing []interface{}, needed []serviceRoleStatement) []interface{} {
+	for _, statement := range needed {
+		merged := false
+		for _, s := range existing {
+			current, ok := s.(map[string]interface{})
+			if !ok || current["Sid"] != statement.Sid {
+				continue
+			}
+			current["Action"] = appendMissing(current["Action"], statement.Action)
+			current["Resource"] = appendMissing(current["Resource"], statement.Resource)
+			merged = true
+			break
+		}
+		if !merged {
+			existing = append(existing, map[string]interface{}{
+				"Sid":      statement.Sid,
+				"Effect":   statement.Effect,
+				"Action":   statement.Action,
+				"Resource": statement.Resource,
+			})
+		}
+	}
+	return existing
+}
+
+// appendMissing returns the policy element, a single string or a list of
+// them, as a list with the values it does not contain yet.
+func appendMissing(element interface{}, values []string) []interface{} {
+	var list []interface{}
+	switch v := element.(type) {
+	case string:
+		list = []interface{}{v}
+	case []interface{}:
+		list = v
+	}
+	for _, value := range values {
+		if !containsString(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// missingServiceRoleStatements simulates the statements the service role
+// needs against its policies and returns the ones that are not allowed, with
+// only the denied actions and resources.
+func (p *PostProcessor) missingServiceRoleStatements(ctx context.Context, client iamServiceRoleAPI, roleARN arn.ARN) ([]serviceRoleStatement, error) {
+	var missing []serviceRoleStatement
+	for _, statement := range p.serviceRoleStatements(roleARN) {
+		var actions []string
+		for _, action := range statement.Action {
+			if simulated, ok := simulatedActions[action]; ok {
+				actions = append(actions, simulated...)
+			} else {
+				actions = append(actions, action)
+			}
+		}
+
+		denied := serviceRoleStatement{Sid: statement.Sid, Effect: statement.Effect}
+		deniedActions := map[string]bool{}
+		for _, resource := range statement.Resource {
+			resp, err := client.SimulatePrincipalPolicy(ctx, &iam.SimulatePrincipalPolicyInput{
+				PolicySourceArn: aws.String(roleARN.String()),
+				ActionNames:     actions,
+				ResourceArns:    []string{resource},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Failed to simulate the policies of service role %s: %s", roleARN, err)
+			}
+
+			resourceDenied := false
+			for _, result := range resp.EvaluationResults {
+				if result.EvalDecision == iamtypes.PolicyEvaluationDecisionTypeAllowed {
+					continue
+				}
+				resourceDenied = true
+				action := policyAction(statement.Action, aws.ToString(result.EvalActionName))
+				if !deniedActions[action] {
+					deniedActions[action] = true
+					denied.Action = append(denied.Action, action)
+				}
+			}
+			if resourceDenied {
+				denied.Resource = append(denied.Resource, resource)
+			}
+		}
+
+		if len(denied.Action) > 0 {
+			missing = append(missing, denied)
+		}
+	}
+	return missing, nil
+}
+
+// policyAction returns the action of the policy that the simulated action
+// was checked for.
+func policyAction(actions []string, simulated string) string {
+	for _, action := range actions {
+		for _, s := range simulatedActions[action] {
+			if s == simulated {
+				return action
+			}
+		}
+	}
+	return simulated
+}
+
+// trustsVMImport reports whether the URL encoded trust policy document lets
+// VM Import assume the role.
+func trustsVMImport(document string) (bool, error) {
+	policy, err := decodePolicyDocument(document)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range policyStatements(policy) {
+		statement, ok := s.(map[string]interface{})
+		if !ok || statement["Effect"] != "Allow" {
+			continue
+		}
+		if !containsString(statement["Action"], "sts:AssumeRole") {
+			continue
+		}
+		principal, ok := statement["Principal"].(map[string]interface{})
+		if ok && containsString(principal["Service"], vmImportPrincipal) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// trustVMImport adds the statement letting VM Import assume the role to its
+// trust policy, keeping the existing statements.
+func (p *PostProcessor) trustVMImport(ctx context.Context, client iamServiceRoleAPI, roleName string, document string) error {
+	policy, err := decodePolicyDocument(document)
+	if err != nil {
+		return fmt.Errorf("Failed to read the trust policy of service role %s: %s", roleName, err)
+	}
+	policy["Statement"] = append(policyStatements(policy), serviceRoleTrustStatement())
+
+	trust, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	_, err = client.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyDocument: aws.String(string(trust)),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to update the trust policy of service role %s: %s", roleName, err)
+	}
+	return nil
+}
+
+// decodePolicyDocument parses a policy document as returned by IAM, which
+// URL encodes them.
+func decodePolicyDocument(document string) (map[string]interface{}, error) {
+	decoded, err := url.QueryUnescape(document)
+	if err != nil {
+		return nil, err
+	}
+	policy := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(decoded), &policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// policyStatements returns the statements of the policy, which may be a
+// single statement or a list of them.
+func policyStatements(policy map[string]interface{}) []interface{} {
+	switch statements := policy["Statement"].(type) {
+	case []interface{}:
+		return statements
+	case map[string]interface{}:
+		return []interface{}{statements}
+	}
+	return nil
+}
+
+// containsString reports whether a policy element, a single string or a list
+// of them, contains s.
+func containsString(element interface{}, s string) bool {
+	switch v := element.(type) {
+	case string:
+		return v == s
+	case []interface{}:
+		for _, item := range v {
+			if item == s {
+				return true
+			}
+		}
+	}
+	return false
+}
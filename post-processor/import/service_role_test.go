@@ -0,0 +1,324 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const testTrustPolicy = `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"Service":"vmie.amazonaws.com"},"Action":"sts:AssumeRole"}}`
+
+// mockIAM holds a single role, and allows the simulated actions in allowed,
+// on the resources in resources when it is set.
+type mockIAM struct {
+	role      *iamtypes.Role
+	allowed   map[string]bool
+	resources map[string]bool
+	policies  map[string]string
+	trustSet  string
+	simulated []string
+}
+
+func (m *mockIAM) GetRole(ctx context.Context, input *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	if m.role == nil {
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String("role not found")}
+	}
+	return &iam.GetRoleOutput{Role: m.role}, nil
+}
+
+func (m *mockIAM) CreateRole(ctx context.Context, input *iam.CreateRoleInput, optFns ...func(*iam.Options)) (*iam.CreateRoleOutput, error) {
+	m.role = &iamtypes.Role{
+		RoleName:                 input.RoleName,
+		Arn:                      aws.String("arn:aws:iam::123456789012:role/" + aws.ToString(input.RoleName)),
+		AssumeRolePolicyDocument: aws.String(url.QueryEscape(aws.ToString(input.AssumeRolePolicyDocument))),
+	}
+	return &iam.CreateRoleOutput{Role: m.role}, nil
+}
+
+func (m *mockIAM) UpdateAssumeRolePolicy(ctx context.Context, input *iam.UpdateAssumeRolePolicyInput, optFns ...func(*iam.Options)) (*iam.UpdateAssumeRolePolicyOutput, error) {
+	m.trustSet = aws.ToString(input.PolicyDocument)
+	return &iam.UpdateAssumeRolePolicyOutput{}, nil
+}
+
+func (m *mockIAM) GetRolePolicy(ctx context.Context, input *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	policy, ok := m.policies[aws.ToString(input.PolicyName)]
+	if !ok {
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String("policy not found")}
+	}
+	return &iam.GetRolePolicyOutput{PolicyDocument: aws.String(url.QueryEscape(policy))}, nil
+}
+
+func (m *mockIAM) PutRolePolicy(ctx context.Context, input *iam.PutRolePolicyInput, optFns ...func(*iam.Options)) (*iam.PutRolePolicyOutput, error) {
+	if m.policies == nil {
+		m.policies = map[string]string{}
+	}
+	m.policies[aws.ToString(input.PolicyName)] = aws.ToString(input.PolicyDocument)
+	return &iam.PutRolePolicyOutput{}, nil
+}
+
+func (m *mockIAM) SimulatePrincipalPolicy(ctx context.Context, input *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	var results []iamtypes.EvaluationResult
+	for _, action := range input.ActionNames {
+		m.simulated = append(m.simulated, action)
+		decision := iamtypes.PolicyEvaluationDecisionTypeImplicitDeny
+		if m.allowed[action] && (m.resources == nil || m.resources[input.ResourceArns[0]]) {
+			decision = iamtypes.PolicyEvaluationDecisionTypeAllowed
+		}
+		results = append(results, iamtypes.EvaluationResult{
+			EvalActionName:   aws.String(action),
+			EvalResourceName: aws.String(input.ResourceArns[0]),
+			EvalDecision:     decision,
+		})
+	}
+	return &iam.SimulatePrincipalPolicyOutput{EvaluationResults: results}, nil
+}
+
+func testServiceRole(trust string) *iamtypes.Role {
+	return &iamtypes.Role{
+		RoleName:                 aws.String("vmimport"),
+		Arn:                      aws.String("arn:aws:iam::123456789012:role/vmimport"),
+		AssumeRolePolicyDocument: aws.String(url.QueryEscape(trust)),
+	}
+}
+
+func allowAll() map[string]bool {
+	allowed := map[string]bool{}
+	for _, action := range []string{
+		"s3:GetBucketLocation", "s3:ListBucket", "s3:GetObject",
+		"ec2:CopySnapshot", "ec2:DescribeImages", "ec2:DescribeSnapshots",
+		"ec2:ModifySnapshotAttribute", "ec2:RegisterImage",
+	} {
+		allowed[action] = true
+	}
+	return allowed
+}
+
+func testServiceRolePostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+func TestPostProcessor_ensureServiceRole_Validate(t *testing.T) {
+	p := testServiceRolePostProcessor(t, nil)
+	conn := &mockIAM{role: testServiceRole(testTrustPolicy), allowed: allowAll()}
+
+	if err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, false); err != nil {
+		t.Fatalf("ensureServiceRole() failed: %s", err)
+	}
+	if len(conn.policies) != 0 || conn.trustSet != "" {
+		t.Errorf("expected validation to leave the role alone")
+	}
+	if len(conn.simulated) == 0 {
+		t.Errorf("expected the role permissions to be simulated")
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_ValidateMissingRole(t *testing.T) {
+	p := testServiceRolePostProcessor(t, nil)
+	conn := &mockIAM{}
+
+	err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, false)
+	if err == nil || !strings.Contains(err.Error(), "create_service_role") {
+		t.Fatalf("expected an error suggesting create_service_role, got %v", err)
+	}
+	if conn.role != nil {
+		t.Errorf("expected validation not to create the role")
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_ValidateMissingStatements(t *testing.T) {
+	p := testServiceRolePostProcessor(t, nil)
+	allowed := allowAll()
+	delete(allowed, "s3:GetObject")
+	delete(allowed, "ec2:DescribeSnapshots")
+	trust := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	conn := &mockIAM{role: testServiceRole(trust), allowed: allowed}
+
+	err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, false)
+	if err == nil {
+		t.Fatalf("expected the validation to fail")
+	}
+	for _, want := range []string{"trust policy", `"s3:GetObject"`, "arn:aws:s3:::importbucket/*", `"ec2:Describe*"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to mention %s, got %s", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "s3:ListBucket") {
+		t.Errorf("expected only the missing permissions in the error, got %s", err)
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_ValidateRegionalKey(t *testing.T) {
+	p := testServiceRolePostProcessor(t, map[string]interface{}{
+		"ami_encrypt": true,
+		"ami_kms_key": "1234abcd-12ab-34cd-56ef-1234567890ab",
+	})
+	allowed := allowAll()
+	for _, action := range []string{
+		"kms:CreateGrant", "kms:Decrypt", "kms:DescribeKey", "kms:Encrypt",
+		"kms:GenerateDataKey", "kms:GenerateDataKeyWithoutPlaintext",
+		"kms:ReEncryptFrom", "kms:ReEncryptTo",
+	} {
+		allowed[action] = true
+	}
+	conn := &mockIAM{role: testServiceRole(testTrustPolicy), allowed: allowed, resources: map[string]bool{
+		"arn:aws:s3:::importbucket":   true,
+		"arn:aws:s3:::importbucket/*": true,
+		"*":                           true,
+		"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab": true,
+	}}
+
+	if err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, false); err != nil {
+		t.Fatalf("expected a grant on the key ARN of the import region to be enough: %s", err)
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_CreateMissingRole(t *testing.T) {
+	p := testServiceRolePostProcessor(t, map[string]interface{}{
+		"role_name":   "packer-import",
+		"ami_encrypt": true,
+		"ami_kms_key": "1234abcd-12ab-34cd-56ef-1234567890ab",
+	})
+	conn := &mockIAM{}
+
+	if err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, true); err != nil {
+		t.Fatalf("ensureServiceRole() failed: %s", err)
+	}
+	if aws.ToString(conn.role.RoleName) != "packer-import" {
+		t.Fatalf("expected role packer-import to be created, got %v", conn.role)
+	}
+	trusted, err := trustsVMImport(aws.ToString(conn.role.AssumeRolePolicyDocument))
+	if err != nil || !trusted {
+		t.Errorf("expected the created role to trust VM Import: %v", err)
+	}
+
+	policy := conn.policies[serviceRolePolicyName]
+	for _, want := range []string{
+		"arn:aws:s3:::importbucket/*",
+		"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"ec2:RegisterImage",
+	} {
+		if !strings.Contains(policy, want) {
+			t.Errorf("expected the role policy to contain %s, got %s", want, policy)
+		}
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_CreateUpdatesRole(t *testing.T) {
+	p := testServiceRolePostProcessor(t, nil)
+	trust := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	allowed := allowAll()
+	delete(allowed, "s3:GetObject")
+	conn := &mockIAM{role: testServiceRole(trust), allowed: allowed}
+
+	if err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, true); err != nil {
+		t.Fatalf("ensureServiceRole() failed: %s", err)
+	}
+	if !strings.Contains(conn.trustSet, "ec2.amazonaws.com") || !strings.Contains(conn.trustSet, vmImportPrincipal) {
+		t.Errorf("expected VM Import to be added to the existing trust policy, got %s", conn.trustSet)
+	}
+	if _, ok := conn.policies[serviceRolePolicyName]; !ok {
+		t.Errorf("expected the missing permissions to be granted")
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_CreateKeepsOtherBuckets(t *testing.T) {
+	p := testServiceRolePostProcessor(t, nil)
+	allowed := allowAll()
+	delete(allowed, "s3:GetObject")
+	conn := &mockIAM{role: testServiceRole(testTrustPolicy), allowed: allowed, policies: map[string]string{
+		serviceRolePolicyName: `{"Version":"2012-10-17","Statement":[` +
+			`{"Sid":"PackerImportObjects","Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::otherbucket/*"},` +
+			`{"Sid":"Custom","Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::logs/*"}]}`,
+	}}
+
+	if err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, true); err != nil {
+		t.Fatalf("ensureServiceRole() failed: %s", err)
+	}
+	policy := conn.policies[serviceRolePolicyName]
+	for _, want := range []string{
+		`"Resource":["arn:aws:s3:::otherbucket/*","arn:aws:s3:::importbucket/*"]`,
+		`"Sid":"Custom"`,
+		`"Sid":"PackerImportBucket"`,
+	} {
+		if !strings.Contains(policy, want) {
+			t.Errorf("expected the role policy to contain %s, got %s", want, policy)
+		}
+	}
+	if strings.Count(policy, `"Sid":"PackerImportObjects"`) != 1 {
+		t.Errorf("expected the statements of both buckets to be merged, got %s", policy)
+	}
+}
+
+func TestPostProcessor_ensureServiceRole_CreateLeavesCompleteRole(t *testing.T) {
+	p := testServiceRolePostProcessor(t, nil)
+	conn := &mockIAM{role: testServiceRole(testTrustPolicy), allowed: allowAll()}
+
+	if err := p.ensureServiceRole(context.Background(), packersdk.TestUi(t), conn, true); err != nil {
+		t.Fatalf("ensureServiceRole() failed: %s", err)
+	}
+	if len(conn.policies) != 0 || conn.trustSet != "" {
+		t.Errorf("expected a complete role to be left alone")
+	}
+}
+
+func TestKmsKeyResource(t *testing.T) {
+	roleARN, _ := arn.Parse("arn:aws-cn:iam::123456789012:role/vmimport")
+	tests := map[string]string{
+		"1234abcd-12ab-34cd-56ef-1234567890ab":                                            "arn:aws-cn:kms:cn-north-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws-cn:kms:cn-north-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab": "arn:aws-cn:kms:cn-north-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+	}
+	for key, want := range tests {
+		if got := kmsKeyResource(key, "cn-north-1", roleARN); got != want {
+			t.Errorf("kmsKeyResource(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestPostProcessorConfigure_ServiceRoleModes(t *testing.T) {
+	var p PostProcessor
+	c := testConfig()
+	c["create_service_role"] = true
+	c["validate_service_role"] = true
+	if err := p.Configure(c); err == nil {
+		t.Fatalf("expected create_service_role and validate_service_role to be mutually exclusive")
+	}
+}
+
+func TestPostProcessorConfigure_ServiceRoleKMSAlias(t *testing.T) {
+	for _, key := range []string{"alias/import", "arn:aws:kms:us-east-1:123456789012:alias/import"} {
+		var p PostProcessor
+		c := testConfig()
+		c["create_service_role"] = true
+		c["s3_encryption"] = "aws:kms"
+		c["s3_encryption_key"] = key
+		if err := p.Configure(c); err == nil || !strings.Contains(err.Error(), "not the alias") {
+			t.Errorf("expected alias %s to be rejected, got %v", key, err)
+		}
+	}
+
+	var p PostProcessor
+	c := testConfig()
+	c["ami_encrypt"] = true
+	c["ami_kms_key"] = "alias/import"
+	if err := p.Configure(c); err != nil {
+		t.Errorf("aliases should be accepted without a service role mode: %s", err)
+	}
+}
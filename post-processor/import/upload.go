@@ -0,0 +1,434 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const (
+	// sha256MetadataKey is the user metadata key the hex SHA-256 of the
+	// uploaded file is stored under.
+	sha256MetadataKey = "sha256"
+
+	// S3 refuses parts smaller than 5 MiB (but the last) and uploads of more
+	// than 10000 parts.
+	minUploadPartSize = 5 * 1024 * 1024
+	maxUploadParts    = 10000
+
+	defaultUploadPartSizeMB  = 64
+	defaultUploadConcurrency = 5
+)
+
+// s3UploadAPI is the subset of the S3 API used to upload disks, satisfied by
+// *s3.Client.
+type s3UploadAPI interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	s3.ListPartsAPIClient
+	s3.ListMultipartUploadsAPIClient
+}
+
+// diskUploader uploads local files to an S3 bucket. Every part is sent with
+// its SHA-256 checksum so S3 verifies it on arrival, and the SHA-256 of the
+// whole file is stored in the object metadata. An object whose checksum
+// already matches the local file is not uploaded again. Large files go through
+// a multipart upload that is left in place on failure, so that the next run
+// picks it up and only sends the parts S3 does not already have.
+type diskUploader struct {
+	client      s3UploadAPI
+	bucket      string
+	partSize    int64
+	concurrency int
+	encryption  string
+	kmsKeyID    string
+}
+
+// uploadPart is a slice of the local file, uploaded as one multipart part.
+type uploadPart struct {
+	number   int32
+	offset   int64
+	size     int64
+	digest   []byte
+	checksum string
+}
+
+// localFile describes the local file to upload and how it is split in parts.
+type localFile struct {
+	size   int64
+	sha256 string
+	parts  []uploadPart
+}
+
+// objectChecksum returns the SHA-256 checksum S3 reports for the object once
+// the file is uploaded: the checksum of the file for a single part upload,
+// and the checksum of the part checksums followed by the number of parts for
+// a multipart upload.
+func (f *localFile) objectChecksum() string {
+	if len(f.parts) == 1 {
+		return f.parts[0].checksum
+	}
+	h := sha256.New()
+	for _, part := range f.parts {
+		h.Write(part.digest)
+	}
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(h.Sum(nil)), len(f.parts))
+}
+
+// Upload copies the file at source to key in the bucket, unless an object with
+// the same content is already there.
+func (u *diskUploader) Upload(ctx context.Context, ui packersdk.Ui, source string, key string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("Failed to open %s: %s", source, err)
+	}
+	defer file.Close()
+
+	ui.Message(fmt.Sprintf("Computing the SHA-256 of %s", source))
+	local, err := u.describeFile(file)
+	if err != nil {
+		return fmt.Errorf("Failed to read %s: %s", source, err)
+	}
+	log.Printf("SHA-256 of %s is %s (%d bytes, %d parts)", source, local.sha256, local.size, len(local.parts))
+
+	uploaded, err := u.alreadyUploaded(ctx, key, local)
+	if err != nil {
+		return err
+	}
+	if uploaded {
+		ui.Say(fmt.Sprintf("s3://%s/%s already matches %s, skipping upload", u.bucket, key, source))
+		return nil
+	}
+
+	ui.Say(fmt.Sprintf("Uploading %s to s3://%s/%s", source, u.bucket, key))
+	if len(local.parts) <= 1 {
+		err = u.putObject(ctx, file, key, local)
+	} else {
+		err = u.multipartUpload(ctx, ui, file, key, local)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to upload %s: %s", source, err)
+	}
+
+	ui.Say(fmt.Sprintf("Completed upload of %s to s3://%s/%s", source, u.bucket, key))
+	return nil
+}
+
+// effectivePartSize returns the part size to split a file of size bytes
+// with, growing the configured size when the file would need more parts than
+// S3 allows.
+func (u *diskUploader) effectivePartSize(size int64) int64 {
+	partSize := u.partSize
+	if partSize < minUploadPartSize {
+		partSize = minUploadPartSize
+	}
+	if min := (size + maxUploadParts - 1) / maxUploadParts; partSize < min {
+		partSize = min
+	}
+	return partSize
+}
+
+// describeFile reads file once, computing the SHA-256 of the whole file and
+// of each of its parts.
+func (u *diskUploader) describeFile(file *os.File) (*localFile, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	local := &localFile{size: info.Size()}
+	partSize := u.effectivePartSize(local.size)
+
+	whole := sha256.New()
+	for offset := int64(0); offset < local.size || offset == 0; offset += partSize {
+		size := partSize
+		if offset+size > local.size {
+			size = local.size - offset
+		}
+		part := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(whole, part), io.NewSectionReader(file, offset, size)); err != nil {
+			return nil, err
+		}
+		digest := part.Sum(nil)
+		local.parts = append(local.parts, uploadPart{
+			number:   int32(len(local.parts) + 1),
+			offset:   offset,
+			size:     size,
+			digest:   digest,
+			checksum: base64.StdEncoding.EncodeToString(digest),
+		})
+		if size == 0 {
+			break
+		}
+	}
+	local.sha256 = hex.EncodeToString(whole.Sum(nil))
+
+	return local, nil
+}
+
+// alreadyUploaded reports whether key already holds the content of local.
+// This needs a fixed s3_key_name and skip_clean, the default key holds a
+// timestamp and the object is deleted after the import.
+func (u *diskUploader) alreadyUploaded(ctx context.Context, key string, local *localFile) (bool, error) {
+	head, err := u.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(u.bucket),
+		Key:          aws.String(key),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NotFound" || apiErr.ErrorCode() == "NoSuchKey") {
+			return false, nil
+		}
+		return false, fmt.Errorf("Failed to check for s3://%s/%s: %s", u.bucket, key, err)
+	}
+
+	return aws.ToInt64(head.ContentLength) == local.size && aws.ToString(head.ChecksumSHA256) == local.objectChecksum(), nil
+}
+
+func (u *diskUploader) putObject(ctx context.Context, file *os.File, key string, local *localFile) error {
+	input := &s3.PutObjectInput{
+		Bucket:            aws.String(u.bucket),
+		Key:               aws.String(key),
+		Body:              io.NewSectionReader(file, 0, local.size),
+		ContentLength:     aws.Int64(local.size),
+		ChecksumAlgorithm: s3types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    aws.String(local.parts[0].checksum),
+		Metadata:          map[string]string{sha256MetadataKey: local.sha256},
+	}
+	if u.encryption != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryption(u.encryption)
+		if u.encryption == string(s3types.ServerSideEncryptionAwsKms) && u.kmsKeyID != "" {
+			input.SSEKMSKeyId = aws.String(u.kmsKeyID)
+		}
+	}
+
+	_, err := u.client.PutObject(ctx, input)
+	return err
+}
+
+func (u *diskUploader) multipartUpload(ctx context.Context, ui packersdk.Ui, file *os.File, key string, local *localFile) error {
+	uploadID, done, err := u.resumableUpload(ctx, key, local)
+	if err != nil {
+		return err
+	}
+	if uploadID == "" {
+		input := &s3.CreateMultipartUploadInput{
+			Bucket:            aws.String(u.bucket),
+			Key:               aws.String(key),
+			ChecksumAlgorithm: s3types.ChecksumAlgorithmSha256,
+			Metadata:          map[string]string{sha256MetadataKey: local.sha256},
+		}
+		if u.encryption != "" {
+			input.ServerSideEncryption = s3types.ServerSideEncryption(u.encryption)
+			if u.encryption == string(s3types.ServerSideEncryptionAwsKms) && u.kmsKeyID != "" {
+				input.SSEKMSKeyId = aws.String(u.kmsKeyID)
+			}
+		}
+		created, err := u.client.CreateMultipartUpload(ctx, input)
+		if err != nil {
+			return fmt.Errorf("Failed to start multipart upload: %s", err)
+		}
+		uploadID = aws.ToString(created.UploadId)
+	} else {
+		ui.Message(fmt.Sprintf("Resuming multipart upload %s, %d of %d parts already uploaded", uploadID, len(done), len(local.parts)))
+	}
+
+	var todo []uploadPart
+	for _, part := range local.parts {
+		if _, ok := done[part.number]; !ok {
+			todo = append(todo, part)
+		}
+	}
+
+	concurrency := u.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	partCh := make(chan uploadPart)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	errs := new(packersdk.MultiError)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for part := range partCh {
+				resp, err := u.client.UploadPart(ctx, &s3.UploadPartInput{
+					Bucket:            aws.String(u.bucket),
+					Key:               aws.String(key),
+					UploadId:          aws.String(uploadID),
+					PartNumber:        aws.Int32(part.number),
+					Body:              io.NewSectionReader(file, part.offset, part.size),
+					ContentLength:     aws.Int64(part.size),
+					ChecksumAlgorithm: s3types.ChecksumAlgorithmSha256,
+					ChecksumSHA256:    aws.String(part.checksum),
+				})
+				lock.Lock()
+				if err != nil {
+					errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("part %d: %s", part.number, err))
+					cancel()
+				} else {
+					done[part.number] = s3types.CompletedPart{
+						PartNumber:     aws.Int32(part.number),
+						ETag:           resp.ETag,
+						ChecksumSHA256: aws.String(part.checksum),
+					}
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, part := range todo {
+		select {
+		case partCh <- part:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(partCh)
+	wg.Wait()
+
+	if len(errs.Errors) > 0 {
+		// The multipart upload is left in place so that the next run only
+		// sends the parts that are missing.
+		return fmt.Errorf("multipart upload %s interrupted, run again to resume it: %s", uploadID, errs)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	completed := make([]s3types.CompletedPart, 0, len(done))
+	for _, part := range done {
+		completed = append(completed, part)
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return aws.ToInt32(completed[i].PartNumber) < aws.ToInt32(completed[j].PartNumber)
+	})
+
+	_, err = u.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to complete multipart upload %s: %s", uploadID, err)
+	}
+	return nil
+}
+
+// resumableUpload looks for a multipart upload of key left behind by a
+// previous run and returns its ID along with its parts. An upload is only
+// resumed when every part already sent matches the local file; uploads of
+// another file are aborted. An empty ID means there is nothing to resume.
+// This needs a fixed s3_key_name, the default key holds a timestamp.
+func (u *diskUploader) resumableUpload(ctx context.Context, key string, local *localFile) (string, map[int32]s3types.CompletedPart, error) {
+	var pending []s3types.MultipartUpload
+	uploads := s3.NewListMultipartUploadsPaginator(u.client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(u.bucket),
+		Prefix: aws.String(key),
+	})
+	for uploads.HasMorePages() {
+		page, err := uploads.NextPage(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed to list multipart uploads of s3://%s/%s: %s", u.bucket, key, err)
+		}
+		for _, upload := range page.Uploads {
+			if aws.ToString(upload.Key) == key {
+				pending = append(pending, upload)
+			}
+		}
+	}
+	// Most recent first
+	sort.Slice(pending, func(i, j int) bool {
+		return aws.ToTime(pending[i].Initiated).After(aws.ToTime(pending[j].Initiated))
+	})
+
+	for _, upload := range pending {
+		uploadID := aws.ToString(upload.UploadId)
+		parts, err := u.matchingParts(ctx, key, uploadID, local)
+		if err != nil {
+			return "", nil, err
+		}
+		if parts != nil {
+			return uploadID, parts, nil
+		}
+
+		log.Printf("Multipart upload %s of s3://%s/%s is for another file, aborting it", uploadID, u.bucket, key)
+		_, err = u.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(u.bucket),
+			Key:      aws.String(key),
+			UploadId: aws.String(uploadID),
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed to abort multipart upload %s: %s", uploadID, err)
+		}
+	}
+
+	return "", map[int32]s3types.CompletedPart{}, nil
+}
+
+// matchingParts returns the parts of the multipart upload uploadID, or nil if
+// any of them does not match the local file.
+func (u *diskUploader) matchingParts(ctx context.Context, key string, uploadID string, local *localFile) (map[int32]s3types.CompletedPart, error) {
+	expected := map[int32]uploadPart{}
+	for _, part := range local.parts {
+		expected[part.number] = part
+	}
+
+	done := map[int32]s3types.CompletedPart{}
+	parts := s3.NewListPartsPaginator(u.client, &s3.ListPartsInput{
+		Bucket:   aws.String(u.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for parts.HasMorePages() {
+		page, err := parts.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list parts of multipart upload %s: %s", uploadID, err)
+		}
+		for _, part := range page.Parts {
+			number := aws.ToInt32(part.PartNumber)
+			want, ok := expected[number]
+			if !ok || aws.ToInt64(part.Size) != want.size || aws.ToString(part.ChecksumSHA256) != want.checksum {
+				log.Printf("Part %d of multipart upload %s does not match the local file", number, uploadID)
+				return nil, nil
+			}
+			done[number] = s3types.CompletedPart{
+				PartNumber:     aws.Int32(number),
+				ETag:           part.ETag,
+				ChecksumSHA256: part.ChecksumSHA256,
+			}
+		}
+	}
+
+	return done, nil
+}
@@ -0,0 +1,430 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package amazonimport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// fakeS3 is a minimal S3 stand-in serving path-style requests for a single
+// bucket. It verifies the SHA-256 checksums sent with the data like S3 does.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string]*fakeObject
+	uploads  map[string]*fakeUpload
+	nextID   int
+	requests map[string]int
+	// failPart makes the next upload of this part number fail.
+	failPart int
+}
+
+type fakeObject struct {
+	data     []byte
+	metadata map[string]string
+	checksum string
+}
+
+type fakeUpload struct {
+	key       string
+	initiated time.Time
+	metadata  map[string]string
+	parts     map[int]fakePart
+}
+
+type fakePart struct {
+	data     []byte
+	checksum string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects:  map[string]*fakeObject{},
+		uploads:  map[string]*fakeUpload{},
+		requests: map[string]int{},
+	}
+}
+
+func sha256Base64(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func metadataFrom(h http.Header) map[string]string {
+	md := map[string]string{}
+	for name := range h {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			md[strings.ToLower(strings.TrimPrefix(strings.ToLower(name), "x-amz-meta-"))] = h.Get(name)
+		}
+	}
+	return md
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+	}{Code: code})
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Path-style: /bucket/key
+	key := ""
+	if parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2); len(parts) == 2 {
+		key = parts[1]
+	}
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodHead:
+		f.requests["HeadObject"]++
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for k, v := range obj.metadata {
+			w.Header().Set("x-amz-meta-"+k, v)
+		}
+		w.Header().Set("x-amz-checksum-sha256", obj.checksum)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		f.requests["UploadPart"]++
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			f.failPart = 0
+			writeError(w, http.StatusBadRequest, "RequestTimeout")
+			return
+		}
+		checksum := sha256Base64(body)
+		if r.Header.Get("x-amz-checksum-sha256") != checksum {
+			writeError(w, http.StatusBadRequest, "BadDigest")
+			return
+		}
+		upload.parts[number] = fakePart{data: body, checksum: checksum}
+		w.Header().Set("ETag", fmt.Sprintf("%q", checksum))
+		w.Header().Set("x-amz-checksum-sha256", checksum)
+
+	case r.Method == http.MethodPut:
+		f.requests["PutObject"]++
+		checksum := sha256Base64(body)
+		if r.Header.Get("x-amz-checksum-sha256") != checksum {
+			writeError(w, http.StatusBadRequest, "BadDigest")
+			return
+		}
+		f.objects[key] = &fakeObject{data: body, metadata: metadataFrom(r.Header), checksum: checksum}
+		w.Header().Set("ETag", fmt.Sprintf("%q", checksum))
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.requests["CreateMultipartUpload"]++
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = &fakeUpload{
+			key:       key,
+			initiated: time.Now().Add(time.Duration(f.nextID) * time.Second),
+			metadata:  metadataFrom(r.Header),
+			parts:     map[int]fakePart{},
+		}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Key      string
+			UploadId string
+		}{Key: key, UploadId: id})
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.requests["CompleteMultipartUpload"]++
+		id := query.Get("uploadId")
+		upload, ok := f.uploads[id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var req struct {
+			Parts []struct {
+				PartNumber     int
+				ChecksumSHA256 string
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data []byte
+		digests := sha256.New()
+		for _, p := range req.Parts {
+			part, ok := upload.parts[p.PartNumber]
+			if !ok || part.checksum != p.ChecksumSHA256 {
+				writeError(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			data = append(data, part.data...)
+			digest, _ := base64.StdEncoding.DecodeString(part.checksum)
+			digests.Write(digest)
+		}
+		f.objects[key] = &fakeObject{
+			data:     data,
+			metadata: upload.metadata,
+			checksum: fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(digests.Sum(nil)), len(req.Parts)),
+		}
+		delete(f.uploads, id)
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Key     string
+		}{Key: key})
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.requests["AbortMultipartUpload"]++
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodGet && query.Has("uploadId"):
+		f.requests["ListParts"]++
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		type part struct {
+			PartNumber     int
+			ETag           string
+			Size           int
+			ChecksumSHA256 string
+		}
+		var parts []part
+		for number, p := range upload.parts {
+			parts = append(parts, part{number, fmt.Sprintf("%q", p.checksum), len(p.data), p.checksum})
+		}
+		sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+		writeXML(w, struct {
+			XMLName     xml.Name `xml:"ListPartsResult"`
+			Parts       []part   `xml:"Part"`
+			IsTruncated bool
+		}{Parts: parts})
+
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		f.requests["ListMultipartUploads"]++
+		type upload struct {
+			Key       string
+			UploadId  string
+			Initiated string
+		}
+		var uploads []upload
+		for id, u := range f.uploads {
+			if strings.HasPrefix(u.key, query.Get("prefix")) {
+				uploads = append(uploads, upload{u.key, id, u.initiated.UTC().Format(time.RFC3339)})
+			}
+		}
+		writeXML(w, struct {
+			XMLName     xml.Name `xml:"ListMultipartUploadsResult"`
+			Uploads     []upload `xml:"Upload"`
+			IsTruncated bool
+		}{Uploads: uploads})
+
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func testUploader(t *testing.T, fake *fakeS3, partSize int64, concurrency int) *diskUploader {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("foo", "bar", ""),
+		Retryer:      aws.NopRetryer{},
+	})
+	return &diskUploader{
+		client:      client,
+		bucket:      "importbucket",
+		partSize:    partSize,
+		concurrency: concurrency,
+	}
+}
+
+func testDiskFile(t *testing.T, size int) (string, []byte) {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	path := filepath.Join(t.TempDir(), "disk.raw")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write test disk: %s", err)
+	}
+	return path, data
+}
+
+func TestDiskUploader_SinglePart(t *testing.T) {
+	fake := newFakeS3()
+	u := testUploader(t, fake, minUploadPartSize, 1)
+	path, data := testDiskFile(t, 1024)
+	ui := packersdk.TestUi(t)
+
+	if err := u.Upload(context.Background(), ui, path, "disk.raw"); err != nil {
+		t.Fatalf("Upload() failed: %s", err)
+	}
+	obj, ok := fake.objects["disk.raw"]
+	if !ok {
+		t.Fatalf("expected disk.raw to be uploaded")
+	}
+	if !bytes.Equal(obj.data, data) {
+		t.Errorf("uploaded object does not match the local file")
+	}
+	sum := sha256.Sum256(data)
+	if obj.metadata[sha256MetadataKey] != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected sha256 metadata %x, got %q", sum, obj.metadata[sha256MetadataKey])
+	}
+
+	if err := u.Upload(context.Background(), ui, path, "disk.raw"); err != nil {
+		t.Fatalf("second Upload() failed: %s", err)
+	}
+	if fake.requests["PutObject"] != 1 {
+		t.Errorf("expected the second upload to be skipped, got %d PutObject calls", fake.requests["PutObject"])
+	}
+}
+
+func TestDiskUploader_ReplacesChangedObject(t *testing.T) {
+	fake := newFakeS3()
+	u := testUploader(t, fake, minUploadPartSize, 1)
+	path, data := testDiskFile(t, 2048)
+	fake.objects["disk.raw"] = &fakeObject{
+		data:     []byte("stale"),
+		checksum: sha256Base64([]byte("stale")),
+	}
+
+	if err := u.Upload(context.Background(), packersdk.TestUi(t), path, "disk.raw"); err != nil {
+		t.Fatalf("Upload() failed: %s", err)
+	}
+	if !bytes.Equal(fake.objects["disk.raw"].data, data) {
+		t.Errorf("expected the stale object to be replaced")
+	}
+}
+
+func TestDiskUploader_Multipart(t *testing.T) {
+	fake := newFakeS3()
+	u := testUploader(t, fake, minUploadPartSize, 3)
+	path, data := testDiskFile(t, 2*minUploadPartSize+1024)
+	ui := packersdk.TestUi(t)
+
+	if err := u.Upload(context.Background(), ui, path, "disk.raw"); err != nil {
+		t.Fatalf("Upload() failed: %s", err)
+	}
+	if fake.requests["UploadPart"] != 3 {
+		t.Errorf("expected 3 parts to be uploaded, got %d", fake.requests["UploadPart"])
+	}
+	if !bytes.Equal(fake.objects["disk.raw"].data, data) {
+		t.Errorf("uploaded object does not match the local file")
+	}
+
+	if err := u.Upload(context.Background(), ui, path, "disk.raw"); err != nil {
+		t.Fatalf("second Upload() failed: %s", err)
+	}
+	if fake.requests["CreateMultipartUpload"] != 1 {
+		t.Errorf("expected the second upload to be skipped, got %d multipart uploads", fake.requests["CreateMultipartUpload"])
+	}
+}
+
+func TestDiskUploader_ResumesMultipart(t *testing.T) {
+	fake := newFakeS3()
+	u := testUploader(t, fake, minUploadPartSize, 1)
+	path, data := testDiskFile(t, 3*minUploadPartSize)
+	ui := packersdk.TestUi(t)
+
+	fake.failPart = 2
+	if err := u.Upload(context.Background(), ui, path, "disk.raw"); err == nil {
+		t.Fatalf("expected the interrupted upload to fail")
+	}
+	if len(fake.uploads) != 1 {
+		t.Fatalf("expected the multipart upload to be left in place, got %d", len(fake.uploads))
+	}
+
+	fake.requests = map[string]int{}
+	if err := u.Upload(context.Background(), ui, path, "disk.raw"); err != nil {
+		t.Fatalf("resumed Upload() failed: %s", err)
+	}
+	if fake.requests["CreateMultipartUpload"] != 0 {
+		t.Errorf("expected the multipart upload to be resumed, got %d new uploads", fake.requests["CreateMultipartUpload"])
+	}
+	if fake.requests["UploadPart"] != 2 {
+		t.Errorf("expected only the 2 missing parts to be uploaded, got %d", fake.requests["UploadPart"])
+	}
+	if !bytes.Equal(fake.objects["disk.raw"].data, data) {
+		t.Errorf("uploaded object does not match the local file")
+	}
+}
+
+func TestDiskUploader_AbortsUploadOfAnotherFile(t *testing.T) {
+	fake := newFakeS3()
+	u := testUploader(t, fake, minUploadPartSize, 2)
+	path, data := testDiskFile(t, 2*minUploadPartSize)
+	fake.uploads["stale"] = &fakeUpload{
+		key:       "disk.raw",
+		initiated: time.Now(),
+		parts: map[int]fakePart{
+			1: {data: []byte("stale"), checksum: sha256Base64([]byte("stale"))},
+		},
+	}
+
+	if err := u.Upload(context.Background(), packersdk.TestUi(t), path, "disk.raw"); err != nil {
+		t.Fatalf("Upload() failed: %s", err)
+	}
+	if fake.requests["AbortMultipartUpload"] != 1 {
+		t.Errorf("expected the stale upload to be aborted")
+	}
+	if !bytes.Equal(fake.objects["disk.raw"].data, data) {
+		t.Errorf("uploaded object does not match the local file")
+	}
+}
+
+func TestDiskUploader_effectivePartSize(t *testing.T) {
+	u := &diskUploader{partSize: 1024}
+	if got := u.effectivePartSize(1024); got != minUploadPartSize {
+		t.Errorf("expected part size to be raised to %d, got %d", minUploadPartSize, got)
+	}
+
+	u.partSize = minUploadPartSize
+	size := int64(maxUploadParts)*minUploadPartSize + 1
+	got := u.effectivePartSize(size)
+	if (size+got-1)/got > maxUploadParts {
+		t.Errorf("part size %d splits %d bytes in more than %d parts", got, size, maxUploadParts)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package launchtemplate contains a post-processor creating launch template
+// versions running the AMIs of an artifact.
+package launchtemplate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-launch-template"
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The ID of the launch template to update. Launch template IDs are
+	// regional, so use `launch_template_name` or `launch_template_tags` for
+	// artifacts with AMIs in several regions.
+	LaunchTemplateID string `mapstructure:"launch_template_id" required:"false"`
+	// The name of the launch template to update, in every region of the
+	// artifact.
+	LaunchTemplateName string `mapstructure:"launch_template_name" required:"false"`
+	// Tags identifying the launch template to update, in every region of the
+	// artifact. Exactly one launch template must have all of these tags.
+	//
+	// Exactly one of `launch_template_id`, `launch_template_name` or
+	// `launch_template_tags` must be set.
+	LaunchTemplateTags map[string]string `mapstructure:"launch_template_tags" required:"false"`
+	// The version the new versions are based on: `$Default`, `$Latest` or a
+	// version number. The new versions only change the AMI of this version.
+	// Defaults to `$Default`.
+	SourceVersion string `mapstructure:"source_version" required:"false"`
+	// The description of the new versions.
+	VersionDescription string `mapstructure:"version_description" required:"false"`
+	// Make the new versions the default versions of the launch templates.
+	// Default `false`.
+	SetDefaultVersion bool `mapstructure:"set_default_version" required:"false"`
+	// The number of versions to keep in each launch template, the newest
+	// ones. Older versions are deleted, except the default version. By
+	// default no version is deleted.
+	KeepVersions int `mapstructure:"keep_versions" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.SourceVersion == "" {
+		p.config.SourceVersion = "$Default"
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	selectors := 0
+	if p.config.LaunchTemplateID != "" {
+		selectors++
+	}
+	if p.config.LaunchTemplateName != "" {
+		selectors++
+	}
+	if len(p.config.LaunchTemplateTags) > 0 {
+		selectors++
+	}
+	if selectors != 1 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("exactly one of launch_template_id, launch_template_name or launch_template_tags must be set"))
+	}
+	switch p.config.SourceVersion {
+	case "$Default", "$Latest":
+	default:
+		if version, err := strconv.Atoi(p.config.SourceVersion); err != nil || version < 1 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid source_version %q, must be $Default, $Latest or a version number", p.config.SourceVersion))
+		}
+	}
+	if p.config.KeepVersions < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid keep_versions %d, must be positive", p.config.KeepVersions))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := make([]string, 0, len(amis))
+	for region := range amis {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	for _, region := range regions {
+		conn, err := awscommon.GetRegionConn(ctx, &p.config.AccessConfig, region)
+		if err != nil {
+			return nil, false, false, err
+		}
+		if err := p.update(ctx, ui, conn, region, amis[region]); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	// The AMIs are unchanged, pass them on to the next post-processors.
+	return artifact, true, false, nil
+}
@@ -0,0 +1,239 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package launchtemplate
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":           "foo",
+		"secret_key":           "bar",
+		"region":               "us-east-1",
+		"launch_template_name": "web",
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+// mockTemplateEC2 holds the versions of a single launch template.
+type mockTemplateEC2 struct {
+	clients.Ec2Client
+
+	templates      []ec2types.LaunchTemplate
+	versions       []int64
+	defaultVersion int64
+
+	describeInput *ec2.DescribeLaunchTemplatesInput
+	createInput   *ec2.CreateLaunchTemplateVersionInput
+	deleted       []string
+}
+
+func newMockTemplateEC2(versions int64) *mockTemplateEC2 {
+	m := &mockTemplateEC2{defaultVersion: 1}
+	for v := int64(1); v <= versions; v++ {
+		m.versions = append(m.versions, v)
+	}
+	m.templates = []ec2types.LaunchTemplate{{
+		LaunchTemplateId:     aws.String("lt-1"),
+		LaunchTemplateName:   aws.String("web"),
+		DefaultVersionNumber: aws.Int64(m.defaultVersion),
+	}}
+	return m
+}
+
+func (m *mockTemplateEC2) DescribeLaunchTemplates(ctx context.Context, input *ec2.DescribeLaunchTemplatesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplatesOutput, error) {
+	m.describeInput = input
+	return &ec2.DescribeLaunchTemplatesOutput{LaunchTemplates: m.templates}, nil
+}
+
+func (m *mockTemplateEC2) CreateLaunchTemplateVersion(ctx context.Context, input *ec2.CreateLaunchTemplateVersionInput, optFns ...func(*ec2.Options)) (*ec2.CreateLaunchTemplateVersionOutput, error) {
+	m.createInput = input
+	version := m.versions[len(m.versions)-1] + 1
+	m.versions = append(m.versions, version)
+	return &ec2.CreateLaunchTemplateVersionOutput{LaunchTemplateVersion: &ec2types.LaunchTemplateVersion{
+		LaunchTemplateId: input.LaunchTemplateId,
+		VersionNumber:    aws.Int64(version),
+	}}, nil
+}
+
+func (m *mockTemplateEC2) ModifyLaunchTemplate(ctx context.Context, input *ec2.ModifyLaunchTemplateInput, optFns ...func(*ec2.Options)) (*ec2.ModifyLaunchTemplateOutput, error) {
+	version, err := strconv.ParseInt(aws.ToString(input.DefaultVersion), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	m.defaultVersion = version
+	return &ec2.ModifyLaunchTemplateOutput{}, nil
+}
+
+func (m *mockTemplateEC2) DescribeLaunchTemplateVersions(ctx context.Context, input *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	var versions []ec2types.LaunchTemplateVersion
+	for _, v := range m.versions {
+		versions = append(versions, ec2types.LaunchTemplateVersion{VersionNumber: aws.Int64(v)})
+	}
+	return &ec2.DescribeLaunchTemplateVersionsOutput{LaunchTemplateVersions: versions}, nil
+}
+
+func (m *mockTemplateEC2) DeleteLaunchTemplateVersions(ctx context.Context, input *ec2.DeleteLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteLaunchTemplateVersionsOutput, error) {
+	m.deleted = append(m.deleted, input.Versions...)
+	return &ec2.DeleteLaunchTemplateVersionsOutput{}, nil
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"no template":      {"launch_template_name": ""},
+		"two templates":    {"launch_template_id": "lt-1"},
+		"invalid version":  {"source_version": "latest"},
+		"negative version": {"source_version": "-1"},
+		"negative keep":    {"keep_versions": -1},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessorConfigure_Defaults(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	if p.config.SourceVersion != "$Default" {
+		t.Errorf("expected source_version to default to $Default, got %q", p.config.SourceVersion)
+	}
+}
+
+func TestPostProcessor_update(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"source_version":      "$Latest",
+		"version_description": "Nightly",
+	})
+	conn := newMockTemplateEC2(3)
+
+	if err := p.update(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new"); err != nil {
+		t.Fatalf("update() failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(conn.describeInput.LaunchTemplateNames, []string{"web"}) {
+		t.Errorf("expected the launch template to be found by name, got %+v", conn.describeInput)
+	}
+	input := conn.createInput
+	if aws.ToString(input.LaunchTemplateId) != "lt-1" || aws.ToString(input.SourceVersion) != "$Latest" ||
+		aws.ToString(input.VersionDescription) != "Nightly" || aws.ToString(input.LaunchTemplateData.ImageId) != "ami-new" {
+		t.Errorf("unexpected CreateLaunchTemplateVersion input %+v", input)
+	}
+	if conn.defaultVersion != 1 {
+		t.Errorf("expected the default version to be left alone, got %d", conn.defaultVersion)
+	}
+	if len(conn.deleted) != 0 {
+		t.Errorf("expected no version to be deleted, got %v", conn.deleted)
+	}
+}
+
+func TestPostProcessor_update_SetDefaultAndPrune(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"set_default_version": true,
+		"keep_versions":       2,
+	})
+	conn := newMockTemplateEC2(5)
+
+	if err := p.update(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new"); err != nil {
+		t.Fatalf("update() failed: %s", err)
+	}
+
+	if conn.defaultVersion != 6 {
+		t.Errorf("expected version 6 to be the default version, got %d", conn.defaultVersion)
+	}
+	sort.Strings(conn.deleted)
+	if expected := []string{"1", "2", "3", "4"}; !reflect.DeepEqual(conn.deleted, expected) {
+		t.Errorf("expected versions %v to be deleted, got %v", expected, conn.deleted)
+	}
+}
+
+func TestPostProcessor_update_PruneKeepsDefault(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"keep_versions": 2,
+	})
+	conn := newMockTemplateEC2(4)
+
+	if err := p.update(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new"); err != nil {
+		t.Fatalf("update() failed: %s", err)
+	}
+
+	sort.Strings(conn.deleted)
+	if expected := []string{"2", "3"}; !reflect.DeepEqual(conn.deleted, expected) {
+		t.Errorf("expected versions %v to be deleted, got %v", expected, conn.deleted)
+	}
+}
+
+func TestPostProcessor_update_Tags(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"launch_template_name": "",
+		"launch_template_tags": map[string]string{"Service": "web"},
+	})
+	conn := newMockTemplateEC2(1)
+
+	if err := p.update(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new"); err != nil {
+		t.Fatalf("update() failed: %s", err)
+	}
+	filters := conn.describeInput.Filters
+	if len(filters) != 1 || aws.ToString(filters[0].Name) != "tag:Service" || filters[0].Values[0] != "web" {
+		t.Errorf("expected the launch template to be found by tag, got %+v", filters)
+	}
+}
+
+func TestPostProcessor_update_Ambiguous(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"launch_template_name": "",
+		"launch_template_tags": map[string]string{"Service": "web"},
+	})
+
+	conn := newMockTemplateEC2(1)
+	conn.templates = nil
+	err := p.update(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new")
+	if err == nil || !strings.Contains(err.Error(), "No launch template tagged Service=web") {
+		t.Errorf("expected a missing launch template error, got %v", err)
+	}
+
+	conn = newMockTemplateEC2(1)
+	conn.templates = append(conn.templates, ec2types.LaunchTemplate{LaunchTemplateId: aws.String("lt-2")})
+	err = p.update(context.Background(), &packersdk.MockUi{}, conn, "us-east-1", "ami-new")
+	if err == nil || !strings.Contains(err.Error(), "lt-1, lt-2") {
+		t.Errorf("expected an ambiguous launch template error, got %v", err)
+	}
+	if conn.createInput != nil {
+		t.Errorf("expected no version to be created")
+	}
+}
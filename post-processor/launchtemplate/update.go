@@ -0,0 +1,173 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package launchtemplate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// maxDeletedVersions is the number of versions DeleteLaunchTemplateVersions
+// accepts at once.
+const maxDeletedVersions = 200
+
+// update creates a version of the launch template of region running ami,
+// makes it the default version and prunes the old versions, as configured.
+func (p *PostProcessor) update(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, region, ami string) error {
+	template, err := p.findTemplate(ctx, conn, region)
+	if err != nil {
+		return err
+	}
+	id := aws.ToString(template.LaunchTemplateId)
+	name := aws.ToString(template.LaunchTemplateName)
+
+	ui.Say(fmt.Sprintf("Creating a version of launch template %s (%s) in %s running %s", name, id, region, ami))
+	input := &ec2.CreateLaunchTemplateVersionInput{
+		LaunchTemplateId: aws.String(id),
+		SourceVersion:    aws.String(p.config.SourceVersion),
+		LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+			ImageId: aws.String(ami),
+		},
+	}
+	if p.config.VersionDescription != "" {
+		input.VersionDescription = aws.String(p.config.VersionDescription)
+	}
+	resp, err := conn.CreateLaunchTemplateVersion(ctx, input)
+	if err != nil {
+		return fmt.Errorf("Failed to create a version of launch template %s in %s: %s", id, region, err)
+	}
+	if resp.Warning != nil {
+		for _, warning := range resp.Warning.Errors {
+			ui.Error(fmt.Sprintf("Launch template %s: %s: %s", id, aws.ToString(warning.Code), aws.ToString(warning.Message)))
+		}
+	}
+	version := aws.ToInt64(resp.LaunchTemplateVersion.VersionNumber)
+	ui.Message(fmt.Sprintf("Created version %d of launch template %s", version, id))
+
+	defaultVersion := aws.ToInt64(template.DefaultVersionNumber)
+	if p.config.SetDefaultVersion {
+		_, err := conn.ModifyLaunchTemplate(ctx, &ec2.ModifyLaunchTemplateInput{
+			LaunchTemplateId: aws.String(id),
+			DefaultVersion:   aws.String(strconv.FormatInt(version, 10)),
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to make version %d the default version of launch template %s: %s", version, id, err)
+		}
+		defaultVersion = version
+		ui.Message(fmt.Sprintf("Version %d is now the default version of launch template %s", version, id))
+	}
+
+	if p.config.KeepVersions > 0 {
+		return p.prune(ctx, ui, conn, id, defaultVersion)
+	}
+	return nil
+}
+
+// findTemplate returns the launch template of region matching the
+// configured ID, name or tags.
+func (p *PostProcessor) findTemplate(ctx context.Context, conn clients.Ec2Client, region string) (*ec2types.LaunchTemplate, error) {
+	input := &ec2.DescribeLaunchTemplatesInput{}
+	selector := ""
+	switch {
+	case p.config.LaunchTemplateID != "":
+		input.LaunchTemplateIds = []string{p.config.LaunchTemplateID}
+		selector = p.config.LaunchTemplateID
+	case p.config.LaunchTemplateName != "":
+		input.LaunchTemplateNames = []string{p.config.LaunchTemplateName}
+		selector = p.config.LaunchTemplateName
+	default:
+		var tags []string
+		for key, value := range p.config.LaunchTemplateTags {
+			input.Filters = append(input.Filters, ec2types.Filter{
+				Name:   aws.String("tag:" + key),
+				Values: []string{value},
+			})
+			tags = append(tags, key+"="+value)
+		}
+		sort.Strings(tags)
+		selector = "tagged " + strings.Join(tags, ", ")
+	}
+
+	var templates []ec2types.LaunchTemplate
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(conn, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to find launch template %s in %s: %s", selector, region, err)
+		}
+		templates = append(templates, page.LaunchTemplates...)
+	}
+
+	switch len(templates) {
+	case 0:
+		return nil, fmt.Errorf("No launch template %s in %s", selector, region)
+	case 1:
+		return &templates[0], nil
+	}
+	var ids []string
+	for _, template := range templates {
+		ids = append(ids, aws.ToString(template.LaunchTemplateId))
+	}
+	return nil, fmt.Errorf("Found %d launch templates %s in %s, expected one: %s", len(templates), selector, region, strings.Join(ids, ", "))
+}
+
+// prune deletes the versions of the launch template id but the newest
+// keep_versions ones and the default version.
+func (p *PostProcessor) prune(ctx context.Context, ui packersdk.Ui, conn clients.Ec2Client, id string, defaultVersion int64) error {
+	var versions []int64
+	paginator := ec2.NewDescribeLaunchTemplateVersionsPaginator(conn, &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId: aws.String(id),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to list the versions of launch template %s: %s", id, err)
+		}
+		for _, version := range page.LaunchTemplateVersions {
+			versions = append(versions, aws.ToInt64(version.VersionNumber))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var deleted []string
+	for i, version := range versions {
+		if i >= p.config.KeepVersions && version != defaultVersion {
+			deleted = append(deleted, strconv.FormatInt(version, 10))
+		}
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	ui.Message(fmt.Sprintf("Deleting %d old versions of launch template %s", len(deleted), id))
+	for len(deleted) > 0 {
+		batch := deleted[:min(len(deleted), maxDeletedVersions)]
+		deleted = deleted[len(batch):]
+
+		resp, err := conn.DeleteLaunchTemplateVersions(ctx, &ec2.DeleteLaunchTemplateVersionsInput{
+			LaunchTemplateId: aws.String(id),
+			Versions:         batch,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to delete old versions of launch template %s: %s", id, err)
+		}
+		for _, failure := range resp.UnsuccessfullyDeletedLaunchTemplateVersions {
+			msg := ""
+			if failure.ResponseError != nil {
+				msg = aws.ToString(failure.ResponseError.Message)
+			}
+			ui.Error(fmt.Sprintf("Failed to delete version %d of launch template %s: %s", aws.ToInt64(failure.VersionNumber), id, msg))
+		}
+	}
+	return nil
+}
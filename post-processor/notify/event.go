@@ -0,0 +1,185 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// templateFuncs are the template functions of the plugin, and json, which
+// encodes a field of the event to JSON in payload_template.
+var templateFuncs = func() template.FuncMap {
+	funcs := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	for name, f := range awscommon.TemplateFuncs {
+		funcs[name] = f
+	}
+	return funcs
+}()
+
+// event describes the AMIs of an artifact. It is the default payload, and
+// the data payload_template is rendered with.
+type event struct {
+	// The name of the build, such as `amazon-ebs.ubuntu`.
+	BuildName string `json:"build_name"`
+	// The type of the builder, such as `amazon-ebs`.
+	BuilderType string `json:"builder_type"`
+	// The BuilderId of the artifact.
+	BuilderId string `json:"builder_id"`
+	// The ID of the artifact, such as `us-east-1:ami-0123456789abcdef0`.
+	ArtifactId string `json:"artifact_id"`
+	// The AMIs of the artifact, by region.
+	Amis map[string]string `json:"amis"`
+	// The snapshots of the AMIs, by region.
+	Snapshots map[string][]string `json:"snapshots"`
+	// The tags of the AMIs, by region.
+	Tags map[string]map[string]string `json:"tags"`
+	// The AMI the build started from, and its name.
+	SourceAMI     string `json:"source_ami,omitempty"`
+	SourceAMIName string `json:"source_ami_name,omitempty"`
+	// The data generated by the build, such as `SourceAMIOwner`.
+	GeneratedData map[string]interface{} `json:"generated_data,omitempty"`
+}
+
+// newEvent returns the event of artifact, without the snapshots and the
+// tags of its AMIs.
+func (p *PostProcessor) newEvent(artifact packersdk.Artifact, amis map[string]string) *event {
+	e := &event{
+		BuildName:   p.config.PackerBuildName,
+		BuilderType: p.config.PackerBuilderType,
+		BuilderId:   artifact.BuilderId(),
+		ArtifactId:  artifact.Id(),
+		Amis:        amis,
+		Snapshots:   make(map[string][]string, len(amis)),
+		Tags:        make(map[string]map[string]string, len(amis)),
+	}
+	if generated, ok := artifact.State("generated_data").(map[string]interface{}); ok {
+		e.GeneratedData = generated
+		e.SourceAMI, _ = generated["SourceAMI"].(string)
+		e.SourceAMIName, _ = generated["SourceAMIName"].(string)
+	}
+	return e
+}
+
+// describe adds the snapshots and the tags of ami, in region, to the event.
+func (e *event) describe(ctx context.Context, conn clients.Ec2Client, region, ami string) error {
+	resp, err := conn.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{ami},
+	})
+	if err != nil {
+		return fmt.Errorf("Error describing AMI %s in %s: %s", ami, region, err)
+	}
+	if len(resp.Images) == 0 {
+		return fmt.Errorf("AMI %s not found in %s", ami, region)
+	}
+	image := resp.Images[0]
+
+	snapshots := []string{}
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping.Ebs != nil && mapping.Ebs.SnapshotId != nil {
+			snapshots = append(snapshots, aws.ToString(mapping.Ebs.SnapshotId))
+		}
+	}
+	e.Snapshots[region] = snapshots
+
+	tags := make(map[string]string, len(image.Tags))
+	for _, tag := range image.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	e.Tags[region] = tags
+	return nil
+}
+
+// payload returns the event encoded to JSON, or rendered with
+// payload_template when it is set.
+func (p *PostProcessor) payload(e *event) (string, error) {
+	if p.config.PayloadTemplate == "" {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return "", fmt.Errorf("Error encoding event: %s", err)
+		}
+		return string(payload), nil
+	}
+
+	ictx := p.config.ctx
+	ictx.Data = e
+	payload, err := interpolate.Render(p.config.PayloadTemplate, &ictx)
+	if err != nil {
+		return "", fmt.Errorf("Error interpolating payload_template: %s", err)
+	}
+	return payload, nil
+}
+
+// snsAPI is the part of SNS needed to publish the event.
+type snsAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// publish publishes payload to the SNS topic.
+func (p *PostProcessor) publish(ctx context.Context, ui packersdk.Ui, client snsAPI, payload string) error {
+	ui.Say(fmt.Sprintf("Publishing build event to %s", p.config.SNSTopicArn))
+	input := &sns.PublishInput{
+		TopicArn: aws.String(p.config.SNSTopicArn),
+		Message:  aws.String(payload),
+	}
+	if p.config.SNSSubject != "" {
+		input.Subject = aws.String(p.config.SNSSubject)
+	}
+	resp, err := client.Publish(ctx, input)
+	if err != nil {
+		return fmt.Errorf("Error publishing to %s: %s", p.config.SNSTopicArn, err)
+	}
+	ui.Message(fmt.Sprintf("Published message %s", aws.ToString(resp.MessageId)))
+	return nil
+}
+
+// eventsAPI is the part of EventBridge needed to put the event on a bus.
+type eventsAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// putEvent puts payload on the EventBridge event bus.
+func (p *PostProcessor) putEvent(ctx context.Context, ui packersdk.Ui, client eventsAPI, payload string) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &object); err != nil || object == nil {
+		return fmt.Errorf("The payload put on event bus %s must be a JSON object, got %q", p.config.EventBusName, payload)
+	}
+	ui.Say(fmt.Sprintf("Putting build event on event bus %s", p.config.EventBusName))
+	resp, err := client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []eventbridgetypes.PutEventsRequestEntry{{
+			EventBusName: aws.String(p.config.EventBusName),
+			Source:       aws.String(p.config.EventSource),
+			DetailType:   aws.String(p.config.EventDetailType),
+			Detail:       aws.String(payload),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("Error putting event on %s: %s", p.config.EventBusName, err)
+	}
+	if len(resp.Entries) != 1 {
+		return fmt.Errorf("Error putting event on %s: expected 1 entry in the response, got %d", p.config.EventBusName, len(resp.Entries))
+	}
+	if entry := resp.Entries[0]; resp.FailedEntryCount > 0 || entry.ErrorCode != nil {
+		return fmt.Errorf("Error putting event on %s: %s: %s", p.config.EventBusName, aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	ui.Message(fmt.Sprintf("Put event %s", aws.ToString(resp.Entries[0].EventId)))
+	return nil
+}
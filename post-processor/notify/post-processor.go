@@ -0,0 +1,199 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package notify contains a post-processor sending an event describing the
+// AMIs of an artifact to an SNS topic or an EventBridge event bus.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-notify"
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The ARN of the SNS topic to publish the event to. The event is
+	// published in the region of the topic. At least one of `sns_topic_arn`
+	// and `event_bus_name` must be set.
+	SNSTopicArn string `mapstructure:"sns_topic_arn" required:"false"`
+	// The subject of the SNS message, used by the email subscriptions of the
+	// topic.
+	SNSSubject string `mapstructure:"sns_subject" required:"false"`
+	// The name or the ARN of the EventBridge event bus to put the event on,
+	// such as `default`. The event is put in the region of the bus when it
+	// is an ARN, in `region` otherwise.
+	EventBusName string `mapstructure:"event_bus_name" required:"false"`
+	// The detail type of the EventBridge event. Defaults to
+	// `Packer Build Completed`.
+	EventDetailType string `mapstructure:"event_detail_type" required:"false"`
+	// The source of the EventBridge event, which rules can match on.
+	// Defaults to `packer`. It cannot start with `aws.`.
+	EventSource string `mapstructure:"event_source" required:"false"`
+	// A template of the payload to send instead of the default JSON event.
+	// The fields of the event are available to the template, see
+	// [Event](#event), and `{{ json .Amis }}` encodes a field to JSON. The
+	// payload put on an EventBridge bus must be a JSON object.
+	PayloadTemplate string `mapstructure:"payload_template" required:"false"`
+	// The SNS endpoint to use instead of the default one of the region of
+	// the topic, such as a local stand-in for SNS.
+	CustomEndpointSNS string `mapstructure:"custom_endpoint_sns" required:"false"`
+	// The EventBridge endpoint to use instead of the default one of the
+	// region of the bus, such as a local stand-in for EventBridge.
+	CustomEndpointEvents string `mapstructure:"custom_endpoint_events" required:"false"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = templateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"payload_template",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.EventDetailType == "" {
+		p.config.EventDetailType = "Packer Build Completed"
+	}
+	if p.config.EventSource == "" {
+		p.config.EventSource = "packer"
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if p.config.SNSTopicArn == "" && p.config.EventBusName == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("at least one of sns_topic_arn and event_bus_name must be set"))
+	}
+	if p.config.SNSTopicArn != "" {
+		if topic, err := arn.Parse(p.config.SNSTopicArn); err != nil || topic.Service != "sns" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid sns_topic_arn %q, must be the ARN of an SNS topic", p.config.SNSTopicArn))
+		}
+	}
+	if p.config.SNSSubject != "" && p.config.SNSTopicArn == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("sns_subject can only be set with sns_topic_arn"))
+	}
+	if strings.HasPrefix(p.config.EventSource, "aws.") {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid event_source %q, cannot start with aws.", p.config.EventSource))
+	}
+	if p.config.PayloadTemplate != "" {
+		if _, err := p.payload(&event{}); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := make([]string, 0, len(amis))
+	for region := range amis {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	e := p.newEvent(artifact, amis)
+	for _, region := range regions {
+		conn, err := awscommon.GetRegionConn(ctx, &p.config.AccessConfig, region)
+		if err != nil {
+			return nil, false, false, err
+		}
+		if err := e.describe(ctx, conn, region, amis[region]); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	payload, err := p.payload(e)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	if p.config.SNSTopicArn != "" {
+		if err := p.publish(ctx, ui, p.snsClient(awsConfig), payload); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	if p.config.EventBusName != "" {
+		if err := p.putEvent(ctx, ui, p.eventsClient(awsConfig), payload); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	// The AMIs are unchanged, pass them on to the next post-processors.
+	return artifact, true, false, nil
+}
+
+// snsClient returns a client for SNS in the region of the topic.
+func (p *PostProcessor) snsClient(awsConfig *aws.Config) *sns.Client {
+	topic, _ := arn.Parse(p.config.SNSTopicArn)
+	return sns.NewFromConfig(*awsConfig, func(o *sns.Options) {
+		o.Region = topic.Region
+		if p.config.CustomEndpointSNS != "" {
+			o.BaseEndpoint = aws.String(p.config.CustomEndpointSNS)
+		}
+	})
+}
+
+// eventsClient returns a client for EventBridge in the region of the bus.
+func (p *PostProcessor) eventsClient(awsConfig *aws.Config) *eventbridge.Client {
+	return eventbridge.NewFromConfig(*awsConfig, func(o *eventbridge.Options) {
+		if bus, err := arn.Parse(p.config.EventBusName); err == nil {
+			o.Region = bus.Region
+		}
+		if p.config.CustomEndpointEvents != "" {
+			o.BaseEndpoint = aws.String(p.config.CustomEndpointEvents)
+		}
+	})
+}
@@ -0,0 +1,314 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-amazon/common/clients"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const testTopicArn = "arn:aws:sns:eu-west-1:123456789012:golden-amis"
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":    "foo",
+		"secret_key":    "bar",
+		"region":        "us-east-1",
+		"sns_topic_arn": testTopicArn,
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+func testArtifact() *awscommon.Artifact {
+	return &awscommon.Artifact{
+		Amis:           map[string]string{"us-east-1": "ami-1", "eu-west-1": "ami-2"},
+		BuilderIdValue: "mitchellh.amazonebs",
+		StateData: map[string]interface{}{
+			"generated_data": map[string]interface{}{
+				"SourceAMI":     "ami-source",
+				"SourceAMIName": "ubuntu-22.04",
+			},
+		},
+	}
+}
+
+// mockImagesEC2 describes AMIs with one snapshot and one tag.
+type mockImagesEC2 struct {
+	clients.Ec2Client
+}
+
+func (m *mockImagesEC2) DescribeImages(ctx context.Context, input *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	ami := input.ImageIds[0]
+	return &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId: aws.String(ami),
+		BlockDeviceMappings: []ec2types.BlockDeviceMapping{
+			{DeviceName: aws.String("/dev/sda1"), Ebs: &ec2types.EbsBlockDevice{SnapshotId: aws.String("snap-" + ami)}},
+			{DeviceName: aws.String("/dev/sdb"), VirtualName: aws.String("ephemeral0")},
+		},
+		Tags: []ec2types.Tag{{Key: aws.String("Version"), Value: aws.String("1.2.3")}},
+	}}}, nil
+}
+
+// testEvent returns the event of testArtifact.
+func testEvent(t *testing.T, p *PostProcessor) *event {
+	artifact := testArtifact()
+	e := p.newEvent(artifact, artifact.Amis)
+	for region, ami := range artifact.Amis {
+		if err := e.describe(context.Background(), &mockImagesEC2{}, region, ami); err != nil {
+			t.Fatalf("describe() failed: %s", err)
+		}
+	}
+	return e
+}
+
+// testAWSConfig returns the config of a client of the stand-ins.
+func testAWSConfig() *aws.Config {
+	return &aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("foo", "bar", ""),
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	}
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Defaults(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{"event_bus_name": "default"})
+	if p.config.EventDetailType != "Packer Build Completed" {
+		t.Errorf("unexpected default event_detail_type %q", p.config.EventDetailType)
+	}
+	if p.config.EventSource != "packer" {
+		t.Errorf("unexpected default event_source %q", p.config.EventSource)
+	}
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"no destination":   {"sns_topic_arn": ""},
+		"invalid topic":    {"sns_topic_arn": "golden-amis"},
+		"not a topic":      {"sns_topic_arn": "arn:aws:sqs:eu-west-1:123456789012:golden-amis"},
+		"subject only":     {"sns_topic_arn": "", "event_bus_name": "default", "sns_subject": "New AMI"},
+		"aws source":       {"event_source": "aws.ec2"},
+		"invalid template": {"payload_template": "{{ .Amis"},
+		"unknown field":    {"payload_template": "{{ .Region }}"},
+		"unknown function": {"payload_template": "{{ yaml .Amis }}"},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessor_payload(t *testing.T) {
+	p := testPostProcessor(t, nil)
+	p.config.PackerBuildName = "amazon-ebs.ubuntu"
+	p.config.PackerBuilderType = "amazon-ebs"
+
+	payload, err := p.payload(testEvent(t, p))
+	if err != nil {
+		t.Fatalf("payload() failed: %s", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("invalid payload %q: %s", payload, err)
+	}
+	if got["build_name"] != "amazon-ebs.ubuntu" || got["builder_type"] != "amazon-ebs" ||
+		got["builder_id"] != "mitchellh.amazonebs" || got["source_ami"] != "ami-source" ||
+		got["source_ami_name"] != "ubuntu-22.04" {
+		t.Errorf("unexpected payload %s", payload)
+	}
+	for _, want := range []string{
+		`"amis":{"eu-west-1":"ami-2","us-east-1":"ami-1"}`,
+		`"snapshots":{"eu-west-1":["snap-ami-2"],"us-east-1":["snap-ami-1"]}`,
+		`"tags":{"eu-west-1":{"Version":"1.2.3"},"us-east-1":{"Version":"1.2.3"}}`,
+		`"generated_data":{"SourceAMI":"ami-source","SourceAMIName":"ubuntu-22.04"}`,
+	} {
+		if !strings.Contains(payload, want) {
+			t.Errorf("expected %s in payload %s", want, payload)
+		}
+	}
+}
+
+func TestPostProcessor_payload_Template(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"payload_template": `{"image": "{{ index .Amis "us-east-1" }}", "snapshots": {{ json .Snapshots }}, "version": "{{ index .Tags "eu-west-1" "Version" }}"}`,
+	})
+
+	payload, err := p.payload(testEvent(t, p))
+	if err != nil {
+		t.Fatalf("payload() failed: %s", err)
+	}
+	want := `{"image": "ami-1", "snapshots": {"eu-west-1":["snap-ami-2"],"us-east-1":["snap-ami-1"]}, "version": "1.2.3"}`
+	if payload != want {
+		t.Errorf("expected payload %s, got %s", want, payload)
+	}
+}
+
+func TestPostProcessor_publish(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/sns/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<PublishResponse xmlns="http://sns.amazonaws.com/doc/2010-03-31/">
+  <PublishResult><MessageId>message-1</MessageId></PublishResult>
+  <ResponseMetadata><RequestId>request-1</RequestId></ResponseMetadata>
+</PublishResponse>`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := testPostProcessor(t, map[string]interface{}{
+		"sns_subject":         "New golden AMI",
+		"custom_endpoint_sns": srv.URL,
+	})
+	ui := &packersdk.MockUi{}
+
+	if err := p.publish(context.Background(), ui, p.snsClient(testAWSConfig()), `{"amis": {}}`); err != nil {
+		t.Fatalf("publish() failed: %s", err)
+	}
+	if form.Get("Action") != "Publish" || form.Get("TopicArn") != testTopicArn ||
+		form.Get("Message") != `{"amis": {}}` || form.Get("Subject") != "New golden AMI" {
+		t.Errorf("unexpected Publish request %v", form)
+	}
+	if len(ui.SayMessages) != 2 || !strings.Contains(ui.SayMessages[1].Message, "message-1") {
+		t.Errorf("expected the message ID to be reported, got %v", ui.SayMessages)
+	}
+}
+
+// eventEntry is an entry of PutEvents, with the region the request was
+// signed for.
+type eventEntry struct {
+	Region       string `json:"-"`
+	EventBusName string
+	Source       string
+	DetailType   string
+	Detail       string
+}
+
+// testEventsServer returns a stand-in for EventBridge failing the entries
+// of the failing bus, and the entries it received.
+func testEventsServer(t *testing.T) (*httptest.Server, *[]eventEntry) {
+	var entries []eventEntry
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/events/aws4_request") ||
+			r.Header.Get("X-Amz-Target") != "AWSEvents.PutEvents" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "UnknownOperationException"}`))
+			return
+		}
+		var input struct {
+			Entries []eventEntry
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		// The credential scope is <date>/<region>/events/aws4_request.
+		scope := strings.Split(r.Header.Get("Authorization"), "/")
+		for _, entry := range input.Entries {
+			entry.Region = scope[len(scope)-3]
+			entries = append(entries, entry)
+		}
+		if input.Entries[0].EventBusName == "failing" {
+			_, _ = w.Write([]byte(`{"FailedEntryCount": 1, "Entries": [{"ErrorCode": "InternalFailure", "ErrorMessage": "try again"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"FailedEntryCount": 0, "Entries": [{"EventId": "event-1"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &entries
+}
+
+func TestPostProcessor_putEvent(t *testing.T) {
+	srv, entries := testEventsServer(t)
+	p := testPostProcessor(t, map[string]interface{}{
+		"sns_topic_arn":          "",
+		"event_bus_name":         "arn:aws:events:eu-west-1:123456789012:event-bus/golden",
+		"event_detail_type":      "Golden AMI Released",
+		"event_source":           "acme.images",
+		"custom_endpoint_events": srv.URL,
+	})
+	ui := &packersdk.MockUi{}
+
+	if err := p.putEvent(context.Background(), ui, p.eventsClient(testAWSConfig()), `{"amis": {}}`); err != nil {
+		t.Fatalf("putEvent() failed: %s", err)
+	}
+	want := eventEntry{
+		Region:       "eu-west-1",
+		EventBusName: "arn:aws:events:eu-west-1:123456789012:event-bus/golden",
+		Source:       "acme.images",
+		DetailType:   "Golden AMI Released",
+		Detail:       `{"amis": {}}`,
+	}
+	if len(*entries) != 1 || (*entries)[0] != want {
+		t.Errorf("unexpected PutEvents entries %+v", *entries)
+	}
+	if len(ui.SayMessages) != 2 || !strings.Contains(ui.SayMessages[1].Message, "event-1") {
+		t.Errorf("expected the event ID to be reported, got %v", ui.SayMessages)
+	}
+}
+
+func TestPostProcessor_putEvent_Errors(t *testing.T) {
+	srv, entries := testEventsServer(t)
+	tests := map[string]struct {
+		bus     string
+		payload string
+		err     string
+	}{
+		"not an object": {"default", `["ami-1"]`, "must be a JSON object"},
+		"not json":      {"default", `ami-1`, "must be a JSON object"},
+		"failed entry":  {"failing", `{}`, "InternalFailure: try again"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := testPostProcessor(t, map[string]interface{}{
+				"event_bus_name":         tt.bus,
+				"custom_endpoint_events": srv.URL,
+			})
+			err := p.putEvent(context.Background(), &packersdk.MockUi{}, p.eventsClient(testAWSConfig()), tt.payload)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+	if len(*entries) != 1 {
+		t.Errorf("expected invalid payloads not to be sent, got %+v", *entries)
+	}
+}
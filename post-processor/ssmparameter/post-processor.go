@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+// Package ssmparameter contains a post-processor publishing the AMIs of an
+// artifact to SSM parameters, the write side of the parameterstore data
+// source.
+package ssmparameter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	awscommon "github.com/hashicorp/packer-plugin-amazon/common"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.amazon-ssm-parameter"
+
+type Config struct {
+	common.PackerConfig    `mapstructure:",squash"`
+	awscommon.AccessConfig `mapstructure:",squash"`
+
+	// The name of the parameter to write the AMI ID to, in the region of
+	// each AMI of the artifact, such as `/golden/ubuntu-22.04/latest`. The
+	// parameter is created, or overwritten with a new version. The name is
+	// a template, `{{ .BuildRegion }}` is the region of the AMI.
+	ParameterName string `mapstructure:"parameter_name" required:"true"`
+	// The name of a parameter to write the AMI ID `parameter_name` held
+	// before this build to, such as `/golden/ubuntu-22.04/previous`, for
+	// rollbacks. It is left alone when `parameter_name` does not exist yet,
+	// or already holds the AMI of the artifact. A template like
+	// `parameter_name`.
+	PreviousParameterName string `mapstructure:"previous_parameter_name" required:"false"`
+	// The description of the parameters.
+	Description string `mapstructure:"description" required:"false"`
+	// The tier of the parameters, `Standard`, `Advanced` or
+	// `Intelligent-Tiering`. Defaults to the tier of the account.
+	Tier string `mapstructure:"tier" required:"false"`
+	// Labels to attach to the new version of `parameter_name`, such as the
+	// version of the image. A label is moved from the version it was
+	// attached to.
+	Labels []string `mapstructure:"labels" required:"false"`
+	// Key/value pair tags applied to the parameters.
+	Tags map[string]string `mapstructure:"tags" required:"false"`
+
+	ctx interpolate.Context
+}
+
+// nameData is the data available to the templates of the parameter names.
+type nameData struct {
+	// The region of the AMI.
+	BuildRegion string
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	p.config.ctx.Funcs = awscommon.TemplateFuncs
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"parameter_name",
+				"previous_parameter_name",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.AccessConfig.Prepare(&p.config.PackerConfig)...)
+
+	if p.config.ParameterName == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("parameter_name must be set"))
+	}
+	if p.config.PreviousParameterName != "" && p.config.PreviousParameterName == p.config.ParameterName {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("previous_parameter_name must differ from parameter_name"))
+	}
+	switch p.config.Tier {
+	case "", "Standard", "Advanced", "Intelligent-Tiering":
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid tier %q, must be Standard, Advanced or Intelligent-Tiering", p.config.Tier))
+	}
+	for _, label := range p.config.Labels {
+		if label == "" || strings.HasPrefix(label, "aws") || strings.HasPrefix(label, "ssm") {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid label %q, labels cannot be empty or start with aws or ssm", label))
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.AccessKey, p.config.SecretKey, p.config.Token)
+	log.Println(p.config)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	amis, err := awscommon.ArtifactAmis(artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	awsConfig, err := p.config.Config(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	regions := make([]string, 0, len(amis))
+	for region := range amis {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	for _, region := range regions {
+		client := ssm.NewFromConfig(*awsConfig, func(o *ssm.Options) {
+			o.Region = region
+		})
+		if err := p.publish(ctx, ui, client, region, amis[region]); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	// The AMIs are unchanged, pass them on to the next post-processors.
+	return artifact, true, false, nil
+}
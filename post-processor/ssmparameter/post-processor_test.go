@@ -0,0 +1,186 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package ssmparameter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/retry"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":     "foo",
+		"secret_key":     "bar",
+		"region":         "us-east-1",
+		"parameter_name": "/golden/{{ .BuildRegion }}/latest",
+	}
+}
+
+func testPostProcessor(t *testing.T, extra map[string]interface{}) *PostProcessor {
+	var p PostProcessor
+	c := testConfig()
+	for k, v := range extra {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return &p
+}
+
+// mockSSM holds parameters, and rejects the AMI IDs it does not know like
+// SSM does: the version is never created.
+type mockSSM struct {
+	values   map[string][]string
+	inputs   []*ssm.PutParameterInput
+	labels   *ssm.LabelParameterVersionInput
+	tags     map[string][]ssmtypes.Tag
+	rejected map[string]bool
+}
+
+func newMockSSM() *mockSSM {
+	return &mockSSM{
+		values:   map[string][]string{},
+		tags:     map[string][]ssmtypes.Tag{},
+		rejected: map[string]bool{},
+	}
+}
+
+func (m *mockSSM) GetParameter(ctx context.Context, input *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	values := m.values[aws.ToString(input.Name)]
+	if len(values) == 0 {
+		return nil, &ssmtypes.ParameterNotFound{}
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{
+		Name:    input.Name,
+		Value:   aws.String(values[len(values)-1]),
+		Version: int64(len(values)),
+	}}, nil
+}
+
+func (m *mockSSM) PutParameter(ctx context.Context, input *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	m.inputs = append(m.inputs, input)
+	name := aws.ToString(input.Name)
+	version := int64(len(m.values[name]) + 1)
+	if !m.rejected[aws.ToString(input.Value)] {
+		m.values[name] = append(m.values[name], aws.ToString(input.Value))
+	}
+	return &ssm.PutParameterOutput{Version: version}, nil
+}
+
+func (m *mockSSM) LabelParameterVersion(ctx context.Context, input *ssm.LabelParameterVersionInput, optFns ...func(*ssm.Options)) (*ssm.LabelParameterVersionOutput, error) {
+	m.labels = input
+	return &ssm.LabelParameterVersionOutput{}, nil
+}
+
+func (m *mockSSM) AddTagsToResource(ctx context.Context, input *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	m.tags[aws.ToString(input.ResourceId)] = input.Tags
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessorConfigure_Errors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"missing name":  {"parameter_name": ""},
+		"same previous": {"previous_parameter_name": "/golden/{{ .BuildRegion }}/latest"},
+		"invalid tier":  {"tier": "Premium"},
+		"invalid label": {"labels": []string{"aws-latest"}},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			c := testConfig()
+			for k, v := range extra {
+				c[k] = v
+			}
+			if err := p.Configure(c); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPostProcessor_publish(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"previous_parameter_name": "/golden/{{ .BuildRegion }}/previous",
+		"labels":                  []string{"v1-2-3"},
+		"tags":                    map[string]string{"Team": "platform"},
+		"description":             "The golden AMI",
+	})
+	client := newMockSSM()
+	client.values["/golden/eu-west-1/latest"] = []string{"ami-old"}
+
+	if err := p.publish(context.Background(), &packersdk.MockUi{}, client, "eu-west-1", "ami-new"); err != nil {
+		t.Fatalf("publish() failed: %s", err)
+	}
+
+	if values := client.values["/golden/eu-west-1/previous"]; len(values) != 1 || values[0] != "ami-old" {
+		t.Errorf("expected the previous AMI to be saved, got %v", values)
+	}
+	if values := client.values["/golden/eu-west-1/latest"]; len(values) != 2 || values[1] != "ami-new" {
+		t.Errorf("expected a new version with the new AMI, got %v", values)
+	}
+	for _, input := range client.inputs {
+		if aws.ToString(input.DataType) != "aws:ec2:image" || !aws.ToBool(input.Overwrite) ||
+			aws.ToString(input.Description) != "The golden AMI" {
+			t.Errorf("unexpected PutParameter input %+v", input)
+		}
+	}
+	if client.labels == nil || aws.ToInt64(client.labels.ParameterVersion) != 2 || client.labels.Labels[0] != "v1-2-3" {
+		t.Errorf("expected version 2 to be labeled, got %+v", client.labels)
+	}
+	if len(client.tags["/golden/eu-west-1/latest"]) != 1 || len(client.tags["/golden/eu-west-1/previous"]) != 1 {
+		t.Errorf("expected both parameters to be tagged, got %v", client.tags)
+	}
+}
+
+func TestPostProcessor_publish_NoPrevious(t *testing.T) {
+	p := testPostProcessor(t, map[string]interface{}{
+		"previous_parameter_name": "/golden/{{ .BuildRegion }}/previous",
+	})
+
+	// The parameter does not exist yet.
+	client := newMockSSM()
+	if err := p.publish(context.Background(), &packersdk.MockUi{}, client, "us-east-1", "ami-new"); err != nil {
+		t.Fatalf("publish() failed: %s", err)
+	}
+	if _, ok := client.values["/golden/us-east-1/previous"]; ok {
+		t.Errorf("expected no previous parameter for a new parameter")
+	}
+
+	// The parameter already holds the AMI, publishing it again keeps the
+	// previous one.
+	client.values["/golden/us-east-1/previous"] = []string{"ami-old"}
+	if err := p.publish(context.Background(), &packersdk.MockUi{}, client, "us-east-1", "ami-new"); err != nil {
+		t.Fatalf("publish() failed: %s", err)
+	}
+	if values := client.values["/golden/us-east-1/previous"]; len(values) != 1 {
+		t.Errorf("expected the previous parameter to be left alone, got %v", values)
+	}
+}
+
+func TestPostProcessor_publish_Rejected(t *testing.T) {
+	defer func(r retry.Config) { validationRetry = r }(validationRetry)
+	validationRetry = retry.Config{Tries: 2, RetryDelay: func() time.Duration { return time.Millisecond }}
+
+	p := testPostProcessor(t, nil)
+	client := newMockSSM()
+	client.rejected["ami-gone"] = true
+
+	err := p.publish(context.Background(), &packersdk.MockUi{}, client, "us-east-1", "ami-gone")
+	if err == nil || !strings.Contains(err.Error(), "did not accept ami-gone") {
+		t.Fatalf("expected the AMI to be rejected, got %v", err)
+	}
+}
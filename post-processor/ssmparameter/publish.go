@@ -0,0 +1,159 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package ssmparameter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/retry"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// imageDataType makes SSM check the value of a parameter is an AMI ID the
+// account can use.
+const imageDataType = "aws:ec2:image"
+
+// ssmAPI is the part of the SSM client the post-processor uses.
+type ssmAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	LabelParameterVersion(ctx context.Context, params *ssm.LabelParameterVersionInput, optFns ...func(*ssm.Options)) (*ssm.LabelParameterVersionOutput, error)
+	AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput, optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
+}
+
+// validationRetry is how long SSM is waited for to check an AMI ID.
+var validationRetry = retry.Config{
+	Tries:      15,
+	RetryDelay: (&retry.Backoff{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, Multiplier: 2}).Linear,
+}
+
+// publish writes ami to the parameters of region, after saving the AMI the
+// parameter held to the previous parameter.
+func (p *PostProcessor) publish(ctx context.Context, ui packersdk.Ui, client ssmAPI, region, ami string) error {
+	ictx := p.config.ctx
+	ictx.Data = &nameData{BuildRegion: region}
+	name, err := interpolate.Render(p.config.ParameterName, &ictx)
+	if err != nil {
+		return fmt.Errorf("Error interpolating parameter_name: %s", err)
+	}
+
+	if p.config.PreviousParameterName != "" {
+		previousName, err := interpolate.Render(p.config.PreviousParameterName, &ictx)
+		if err != nil {
+			return fmt.Errorf("Error interpolating previous_parameter_name: %s", err)
+		}
+		current, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name)})
+		var notFound *ssmtypes.ParameterNotFound
+		switch {
+		case errors.As(err, &notFound):
+			ui.Message(fmt.Sprintf("Parameter %s does not exist yet, leaving %s alone", name, previousName))
+		case err != nil:
+			return fmt.Errorf("Failed to read parameter %s in %s: %s", name, region, err)
+		case aws.ToString(current.Parameter.Value) == ami:
+			ui.Message(fmt.Sprintf("Parameter %s already holds %s, leaving %s alone", name, ami, previousName))
+		default:
+			previous := aws.ToString(current.Parameter.Value)
+			ui.Say(fmt.Sprintf("Saving %s to parameter %s in %s", previous, previousName, region))
+			if _, err := p.put(ctx, client, previousName, previous); err != nil {
+				return fmt.Errorf("Failed to write parameter %s in %s: %s", previousName, region, err)
+			}
+		}
+	}
+
+	ui.Say(fmt.Sprintf("Writing %s to parameter %s in %s", ami, name, region))
+	version, err := p.put(ctx, client, name, ami)
+	if err != nil {
+		return fmt.Errorf("Failed to write parameter %s in %s: %s", name, region, err)
+	}
+	ui.Message(fmt.Sprintf("Parameter %s is at version %d", name, version))
+
+	if len(p.config.Labels) > 0 {
+		resp, err := client.LabelParameterVersion(ctx, &ssm.LabelParameterVersionInput{
+			Name:             aws.String(name),
+			ParameterVersion: aws.Int64(version),
+			Labels:           p.config.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to label version %d of parameter %s: %s", version, name, err)
+		}
+		if len(resp.InvalidLabels) > 0 {
+			return fmt.Errorf("Invalid labels for parameter %s: %s", name, strings.Join(resp.InvalidLabels, ", "))
+		}
+		ui.Message(fmt.Sprintf("Labeled version %d of parameter %s with %s", version, name, strings.Join(p.config.Labels, ", ")))
+	}
+	return nil
+}
+
+// put writes value to the parameter name, waits for SSM to accept it as an
+// AMI ID, and returns the version of the parameter.
+func (p *PostProcessor) put(ctx context.Context, client ssmAPI, name, value string) (int64, error) {
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      ssmtypes.ParameterTypeString,
+		DataType:  aws.String(imageDataType),
+		Overwrite: aws.Bool(true),
+	}
+	if p.config.Description != "" {
+		input.Description = aws.String(p.config.Description)
+	}
+	if p.config.Tier != "" {
+		input.Tier = ssmtypes.ParameterTier(p.config.Tier)
+	}
+	resp, err := client.PutParameter(ctx, input)
+	if err != nil {
+		return 0, err
+	}
+	version := resp.Version
+
+	// SSM checks the AMI ID after PutParameter returns, and only creates
+	// the version if it is valid.
+	err = validationRetry.Run(ctx, func(ctx context.Context) error {
+		current, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name)})
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("parameter %s not created yet", name)
+		}
+		if err != nil {
+			return err
+		}
+		if current.Parameter.Version < version || aws.ToString(current.Parameter.Value) != value {
+			return fmt.Errorf("version %d of parameter %s not created yet", version, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("SSM did not accept %s as an AMI ID: %s", value, err)
+	}
+
+	if len(p.config.Tags) > 0 {
+		keys := make([]string, 0, len(p.config.Tags))
+		for key := range p.config.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var tags []ssmtypes.Tag
+		for _, key := range keys {
+			tags = append(tags, ssmtypes.Tag{Key: aws.String(key), Value: aws.String(p.config.Tags[key])})
+		}
+		_, err := client.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+			ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
+			ResourceId:   aws.String(name),
+			Tags:         tags,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("Failed to tag parameter %s: %s", name, err)
+		}
+	}
+	return version, nil
+}